@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/flyits/migro/internal/config"
+)
+
+// confirmProduction asks for confirmation before a command changes the
+// schema of a production environment. It is skipped with --force, and fails
+// when stdin is not a terminal so that scripts never hang on the prompt.
+func confirmProduction(cfg *config.Config, force bool, action string) error {
+	if !cfg.Production || force {
+		return nil
+	}
+
+	target := "the production configuration"
+	if cfg.Environment != "" {
+		target = fmt.Sprintf("the production environment %q", cfg.Environment)
+	}
+
+	if !isTerminal(os.Stdin) {
+		return fmt.Errorf("refusing to %s %s without confirmation, use --force", action, target)
+	}
+	return confirm(os.Stdin, os.Stdout, fmt.Sprintf("You are about to %s %s. Continue? [y/N] ", action, target))
+}
+
+// confirm prints prompt and returns an error unless the answer is yes
+func confirm(in io.Reader, out io.Writer, prompt string) error {
+	fmt.Fprint(out, prompt)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err == io.EOF && answer == "" {
+		fmt.Fprintln(out)
+		return fmt.Errorf("aborted: no answer, use --force to skip the confirmation")
+	}
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return fmt.Errorf("aborted")
+	}
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/flyits/migro/internal/config"
+)
+
+// 测试目标：验证生产环境的确认提示
+func TestConfirm(t *testing.T) {
+	for _, tt := range []struct {
+		answer  string
+		wantErr bool
+	}{
+		{"y\n", false},
+		{"YES\n", false},
+		{"n\n", true},
+		{"\n", true},
+		{"", true},
+	} {
+		var out bytes.Buffer
+		err := confirm(strings.NewReader(tt.answer), &out, "Continue? [y/N] ")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: expected error %v, got %v", tt.answer, tt.wantErr, err)
+		}
+		if !strings.HasPrefix(out.String(), "Continue? [y/N] ") {
+			t.Errorf("unexpected prompt %q", out.String())
+		}
+	}
+}
+
+func TestConfirmProduction(t *testing.T) {
+	if err := confirmProduction(&config.Config{Environment: "staging"}, false, "migrate"); err != nil {
+		t.Errorf("expected no confirmation outside production, got %v", err)
+	}
+
+	prod := &config.Config{Environment: "prod", Production: true}
+	if err := confirmProduction(prod, true, "migrate"); err != nil {
+		t.Errorf("expected --force to skip the confirmation, got %v", err)
+	}
+
+	// go test 的 stdin 不是终端，必须使用 --force
+	if isTerminal(os.Stdin) {
+		t.Skip("stdin is a terminal")
+	}
+	err := confirmProduction(prod, false, "migrate")
+	if err == nil || !strings.Contains(err.Error(), `production environment "prod"`) {
+		t.Errorf("expected a refusal without a terminal, got %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/flyits/migro/internal/config"
+	"github.com/flyits/migro/pkg/driver"
+	"github.com/flyits/migro/pkg/secret"
+)
+
+// connectDatabase connects drv to the database of cfg
+func connectDatabase(drv driver.Driver, cfg *config.Config) error {
+	if verbose {
+		fmt.Printf("Connecting to %s\n", describeConnection(cfg))
+	}
+	if err := drv.Connect(cfg.ToDriverConfig()); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return nil
+}
+
+// describeConnection describes the database of cfg without the password
+func describeConnection(cfg *config.Config) string {
+	var sb strings.Builder
+	if cfg.Driver == "sqlite" {
+		fmt.Fprintf(&sb, "sqlite %s", cfg.Connection.Database)
+	} else {
+		fmt.Fprintf(&sb, "%s %s@%s:%d/%s", cfg.Driver, cfg.Connection.Username, cfg.Connection.Host, cfg.Connection.Port, cfg.Connection.Database)
+	}
+	if cfg.Environment != "" {
+		fmt.Fprintf(&sb, " (environment %s)", cfg.Environment)
+	}
+	return secret.Redact(sb.String())
+}
+
+// redactWriter hides the remembered secrets in what is written to w
+type redactWriter struct {
+	w io.Writer
+}
+
+func (r redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, secret.Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/flyits/migro/internal/config"
+	"github.com/flyits/migro/pkg/secret"
+)
+
+// 测试目标：验证连接描述和 JSON 日志不会泄露密码
+func TestDescribeConnection(t *testing.T) {
+	cfg := &config.Config{
+		Driver:      "postgres",
+		Environment: "prod",
+		Connection:  config.ConnectionConfig{Host: "db", Port: 5432, Username: "app", Password: "hunter2-pass", Database: "app"},
+	}
+	if got, want := describeConnection(cfg), "postgres app@db:5432/app (environment prod)"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	cfg = &config.Config{Driver: "sqlite", Connection: config.ConnectionConfig{Database: "./app.db"}}
+	if got, want := describeConnection(cfg), "sqlite ./app.db"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRedactWriter(t *testing.T) {
+	secret.Remember("hunter2-pass")
+
+	var buf bytes.Buffer
+	line := `{"event":"error","error":"auth failed for hunter2-pass"}` + "\n"
+	n, err := redactWriter{w: &buf}.Write([]byte(line))
+	if err != nil || n != len(line) {
+		t.Fatalf("unexpected write result %d, %v", n, err)
+	}
+	if strings.Contains(buf.String(), "hunter2-pass") || !strings.Contains(buf.String(), secret.Mask) {
+		t.Errorf("expected the password to be redacted, got %q", buf.String())
+	}
+}
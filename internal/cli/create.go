@@ -12,7 +12,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var createTable string
+var (
+	createTable  string
+	createSQL    bool
+	createChange bool
+)
 
 var createCmd = &cobra.Command{
 	Use:   "create <name>",
@@ -24,6 +28,8 @@ var createCmd = &cobra.Command{
 
 func init() {
 	createCmd.Flags().StringVar(&createTable, "table", "", "table name for the migration")
+	createCmd.Flags().BoolVar(&createSQL, "sql", false, "create a pair of plain SQL migration files (.up.sql/.down.sql)")
+	createCmd.Flags().BoolVar(&createChange, "change", false, "create a reversible migration with a Change method instead of Up/Down")
 	rootCmd.AddCommand(createCmd)
 }
 
@@ -31,7 +37,7 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	name := args[0]
 
 	// Load config to get migrations path
-	loader := config.NewLoader(cfgFile)
+	loader := newLoader()
 	cfg, err := loader.Load()
 	if err != nil {
 		// Use default if config doesn't exist
@@ -41,15 +47,19 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	// Generate timestamp
 	timestamp := time.Now().Format("20060102150405")
 
-	// Generate filename
-	filename := fmt.Sprintf("%s_%s.go", timestamp, toSnakeCase(name))
-	filepath := filepath.Join(cfg.Migrations.Path, filename)
-
 	// Ensure migrations directory exists
 	if err := os.MkdirAll(cfg.Migrations.Path, 0755); err != nil {
 		return fmt.Errorf("failed to create migrations directory: %w", err)
 	}
 
+	if createSQL {
+		return createSQLMigration(cfg.Migrations.Path, name, timestamp)
+	}
+
+	// Generate filename
+	filename := fmt.Sprintf("%s_%s.go", timestamp, toSnakeCase(name))
+	filepath := filepath.Join(cfg.Migrations.Path, filename)
+
 	// Determine table name
 	tableName := createTable
 	if tableName == "" {
@@ -58,6 +68,9 @@ func runCreate(cmd *cobra.Command, args []string) error {
 
 	// Generate migration content
 	content := generateMigrationTemplate(name, tableName, timestamp)
+	if createChange {
+		content = generateChangeMigrationTemplate(name, tableName, timestamp)
+	}
 
 	// Write file
 	if err := os.WriteFile(filepath, []byte(content), 0644); err != nil {
@@ -69,6 +82,43 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// createSQLMigration writes the .up.sql and .down.sql files of a plain SQL migration
+func createSQLMigration(dir, name, timestamp string) error {
+	base := fmt.Sprintf("%s_%s", timestamp, toSnakeCase(name))
+
+	files := []struct {
+		path    string
+		content string
+	}{
+		{filepath.Join(dir, base+".up.sql"), generateSQLMigrationTemplate(base, "up")},
+		{filepath.Join(dir, base+".down.sql"), generateSQLMigrationTemplate(base, "down")},
+	}
+
+	for _, f := range files {
+		if err := os.WriteFile(f.path, []byte(f.content), 0644); err != nil {
+			return fmt.Errorf("failed to create migration file: %w", err)
+		}
+		fmt.Printf("Created migration: %s\n", f.path)
+	}
+
+	return nil
+}
+
+func generateSQLMigrationTemplate(name, direction string) string {
+	if direction == "up" {
+		return fmt.Sprintf(`-- Migration: %s
+-- Write the statements that apply this migration below.
+-- Separate statements with semicolons.
+
+`, name)
+	}
+	return fmt.Sprintf(`-- Migration: %s
+-- Write the statements that reverse this migration below.
+-- Separate statements with semicolons.
+
+`, name)
+}
+
 func toSnakeCase(s string) string {
 	// Replace special characters with underscores
 	// This handles version numbers (v1.4.6), hyphenated names (v2.0.0-beta), etc.
@@ -140,7 +190,10 @@ func generateMigrationTemplate(name, tableName, timestamp string) string {
 	return fmt.Sprintf(`package migrations
 
 import (
-	"github.com/flyits/migro/internal/migrator"
+	"context"
+
+	"github.com/flyits/migro/pkg/migration"
+	"github.com/flyits/migro/pkg/migrator"
 	"github.com/flyits/migro/pkg/schema"
 )
 
@@ -153,8 +206,8 @@ func (m *%s) Name() string {
 }
 
 // Up runs the migration
-func (m *%s) Up(e *migrator.Executor) error {
-	return e.CreateTable("%s", func(t *schema.Table) {
+func (m *%s) Up(ctx context.Context, e *migrator.Executor) error {
+	return e.CreateTable(ctx, "%s", func(t *schema.Table) {
 		t.ID()
 		// Add your columns here
 		// t.String("name", 100)
@@ -164,18 +217,56 @@ func (m *%s) Up(e *migrator.Executor) error {
 }
 
 // Down reverses the migration
-func (m *%s) Down(e *migrator.Executor) error {
-	return e.DropTableIfExists("%s")
+func (m *%s) Down(ctx context.Context, e *migrator.Executor) error {
+	return e.DropTableIfExists(ctx, "%s")
 }
 
 func init() {
-	// Register this migration
-	// migrator.Register(&%s{})
+	migration.Register(&%s{})
 }
 `, structName, structName, structName, timestamp, toSnakeCase(name),
 		structName, tableName, structName, tableName, structName)
 }
 
+func generateChangeMigrationTemplate(name, tableName, timestamp string) string {
+	structName := toCamelCase(name)
+
+	return fmt.Sprintf(`package migrations
+
+import (
+	"context"
+
+	"github.com/flyits/migro/pkg/migration"
+	"github.com/flyits/migro/pkg/migrator"
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// %s migration
+type %s struct{}
+
+// Name returns the migration name
+func (m *%s) Name() string {
+	return "%s_%s"
+}
+
+// Change runs the migration, its inverse is derived on rollback
+func (m *%s) Change(ctx context.Context, e *migrator.Executor) error {
+	return e.CreateTable(ctx, "%s", func(t *schema.Table) {
+		t.ID()
+		// Add your columns here
+		// t.String("name", 100)
+		// t.String("email", 100).Unique()
+		t.Timestamps()
+	})
+}
+
+func init() {
+	migration.Register(migration.Reversible(&%s{}))
+}
+`, structName, structName, structName, timestamp, toSnakeCase(name),
+		structName, tableName, structName)
+}
+
 func toCamelCase(s string) string {
 	s = toSnakeCase(s)
 	parts := strings.Split(s, "_")
@@ -3,6 +3,9 @@ package cli
 import (
 	"go/parser"
 	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -246,3 +249,41 @@ func isLetter(r rune) bool {
 func isDigit(r rune) bool {
 	return r >= '0' && r <= '9'
 }
+
+// 测试目标：验证 --sql 生成成对的 SQL 迁移文件
+func TestCreateSQLMigration(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := createSQLMigration(dir, "CreateUsersTable", "20260204120000"); err != nil {
+		t.Fatalf("createSQLMigration() error: %v", err)
+	}
+
+	for _, name := range []string{
+		"20260204120000_create_users_table.up.sql",
+		"20260204120000_create_users_table.down.sql",
+	} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected file %s to be created: %v", name, err)
+		}
+	}
+}
+
+// 测试目标：验证 --change 生成合法的可逆迁移模板
+func TestGenerateChangeMigrationTemplate(t *testing.T) {
+	content := generateChangeMigrationTemplate("create_users_table", "users", "20260204120000")
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "test.go", content, parser.AllErrors); err != nil {
+		t.Fatalf("generateChangeMigrationTemplate() 生成了无效的Go代码: %v\n%s", err, content)
+	}
+	for _, want := range []string{
+		"func (m *CreateUsersTable) Change(ctx context.Context, e *migrator.Executor) error",
+		"migration.Register(migration.Reversible(&CreateUsersTable{}))",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected template to contain %q", want)
+		}
+	}
+	if strings.Contains(content, ") Down(") {
+		t.Error("expected no Down method in a change migration")
+	}
+}
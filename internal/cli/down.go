@@ -4,18 +4,22 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/flyits/migro/internal/config"
-	"github.com/flyits/migro/internal/migrator"
 	"github.com/flyits/migro/pkg/driver"
 	_ "github.com/flyits/migro/pkg/driver/mysql"
 	_ "github.com/flyits/migro/pkg/driver/postgres"
 	_ "github.com/flyits/migro/pkg/driver/sqlite"
+	"github.com/flyits/migro/pkg/migration"
+	"github.com/flyits/migro/pkg/migrator"
 	"github.com/spf13/cobra"
 )
 
 var (
-	downStep  int
-	downForce bool
+	downStep    int
+	downDryRun  bool
+	downOutput  string
+	downForce   bool
+	downNoLock  bool
+	downBatchTx bool
 )
 
 var downCmd = &cobra.Command{
@@ -27,16 +31,23 @@ var downCmd = &cobra.Command{
 
 func init() {
 	downCmd.Flags().IntVar(&downStep, "step", 0, "number of migrations to rollback (0 = last batch)")
+	downCmd.Flags().BoolVar(&downDryRun, "dry-run", false, "show SQL without executing")
+	downCmd.Flags().StringVarP(&downOutput, "output", "o", "", "write the dry run SQL to a file")
 	downCmd.Flags().BoolVar(&downForce, "force", false, "force rollback without confirmation")
+	downCmd.Flags().BoolVar(&downNoLock, "no-lock", false, "do not acquire the migration lock")
+	downCmd.Flags().BoolVar(&downBatchTx, "batch-transaction", false, "run all migrations in a single transaction")
 	rootCmd.AddCommand(downCmd)
 }
 
 func runDown(cmd *cobra.Command, args []string) error {
-	// Load config
-	loader := config.NewLoader(cfgFile)
-	cfg, err := loader.Load()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+	// Load config, or compile and run the Go migrations of the project if needed
+	cfg, delegated, err := loadConfig(cmd)
+	if delegated || err != nil {
+		return err
+	}
+
+	if err := confirmProduction(cfg, downForce || downDryRun, "roll back"); err != nil {
+		return err
 	}
 
 	// Get driver
@@ -46,13 +57,20 @@ func runDown(cmd *cobra.Command, args []string) error {
 	}
 
 	// Connect to database
-	if err := drv.Connect(cfg.ToDriverConfig()); err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+	if err := connectDatabase(drv, cfg); err != nil {
+		return err
 	}
 	defer drv.Close()
 
 	// Create migrator
 	m := migrator.NewMigrator(drv, cfg.Migrations.Path, cfg.Migrations.Table)
+	migration.Load(m)
+	configureLock(m, cfg, downNoLock)
+	m.SetBatchTransaction(downBatchTx || cfg.Migrations.BatchTransaction)
+	if err := configureLogging(m); err != nil {
+		return err
+	}
+	m.SetDryRun(downDryRun)
 
 	ctx := context.Background()
 
@@ -67,6 +85,10 @@ func runDown(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if downDryRun {
+		return printDryRun(m.DryRunResults(), cfg.Driver, downOutput)
+	}
+
 	fmt.Println("Migrations rolled back:")
 	for _, name := range rolledBack {
 		fmt.Printf("  - %s\n", name)
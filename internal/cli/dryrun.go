@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/flyits/migro/pkg/migrator"
+	"github.com/flyits/migro/pkg/secret"
+)
+
+// printDryRun prints the SQL collected in dry run mode, or writes it to
+// output as a SQL script when output is not empty. Remembered secrets are
+// redacted.
+func printDryRun(results []migrator.DryRunResult, dialect, output string) error {
+	script := secret.Redact(formatDryRun(results, dialect))
+
+	if output == "" {
+		fmt.Println("Dry run - SQL statements that would be executed:")
+		fmt.Println()
+		fmt.Print(script)
+		return nil
+	}
+
+	header := fmt.Sprintf("-- Generated by migro dry run at %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
+	if err := os.WriteFile(output, []byte(header+script), 0644); err != nil {
+		return fmt.Errorf("failed to write dry run output: %w", err)
+	}
+	fmt.Printf("SQL written to %s\n", output)
+	return nil
+}
+
+// formatDryRun renders dry run results as a SQL script with one commented
+// section per migration. Migrations running in a transaction of their own
+// are wrapped in BEGIN/COMMIT, a batch transaction wraps the whole batch.
+// MySQL statements containing semicolons, such as procedure bodies, are
+// written in DELIMITER blocks so that the script can be replayed.
+func formatDryRun(results []migrator.DryRunResult, dialect string) string {
+	var sb strings.Builder
+
+	for i, result := range results {
+		batch := result.Transaction == migrator.BatchTransaction
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		if batch && !sameBatch(results, i-1, i) {
+			sb.WriteString("BEGIN;\n\n")
+		}
+		if result.Rollback {
+			sb.WriteString("-- Rollback: ")
+		} else {
+			sb.WriteString("-- Migration: ")
+		}
+		sb.WriteString(result.Migration)
+		sb.WriteString("\n")
+
+		if len(result.SQL) == 0 {
+			sb.WriteString("-- (no SQL statements)\n")
+		} else {
+			if result.Transaction == migrator.MigrationTransaction {
+				sb.WriteString("BEGIN;\n")
+			}
+			for _, stmt := range result.SQL {
+				writeStatement(&sb, strings.TrimSpace(stmt), dialect)
+			}
+			if result.Transaction == migrator.MigrationTransaction {
+				sb.WriteString("COMMIT;\n")
+			}
+		}
+
+		if batch && !sameBatch(results, i, i+1) {
+			sb.WriteString("\nCOMMIT;\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// writeStatement writes a statement followed by its terminator, unless it
+// already ends with one
+func writeStatement(sb *strings.Builder, stmt, dialect string) {
+	if dialect == "mysql" && len(migrator.SplitStatements(stmt, dialect)) > 1 {
+		sb.WriteString("DELIMITER $$\n")
+		sb.WriteString(strings.TrimSpace(strings.TrimSuffix(stmt, ";")))
+		sb.WriteString("$$\nDELIMITER ;\n")
+		return
+	}
+	sb.WriteString(stmt)
+	if !strings.HasSuffix(stmt, ";") {
+		sb.WriteString(";")
+	}
+	sb.WriteString("\n")
+}
+
+// sameBatch reports whether the results i and j run in the same batch
+// transaction. Up and Down run separate batches, e.g. in a refresh.
+func sameBatch(results []migrator.DryRunResult, i, j int) bool {
+	if i < 0 || j >= len(results) {
+		return false
+	}
+	return results[i].Transaction == migrator.BatchTransaction &&
+		results[j].Transaction == migrator.BatchTransaction &&
+		results[i].Rollback == results[j].Rollback
+}
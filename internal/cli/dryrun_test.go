@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flyits/migro/pkg/migrator"
+	"github.com/flyits/migro/pkg/secret"
+)
+
+// 测试目标：验证 dry run 结果渲染为可审阅的 SQL 脚本
+func TestFormatDryRun(t *testing.T) {
+	results := []migrator.DryRunResult{
+		{Migration: "001_create_users", Rollback: true, SQL: []string{"DROP TABLE users"}},
+		{Migration: "001_create_users", SQL: []string{"CREATE TABLE users (id INT);", "CREATE INDEX idx ON users (id)"}},
+		{Migration: "002_noop"},
+	}
+
+	expected := `-- Rollback: 001_create_users
+DROP TABLE users;
+
+-- Migration: 001_create_users
+CREATE TABLE users (id INT);
+CREATE INDEX idx ON users (id);
+
+-- Migration: 002_noop
+-- (no SQL statements)
+`
+	if got := formatDryRun(results, "postgres"); got != expected {
+		t.Errorf("unexpected script:\n%s", got)
+	}
+}
+
+// 测试目标：验证 dry run 脚本反映迁移的事务边界
+func TestFormatDryRun_Transactions(t *testing.T) {
+	results := []migrator.DryRunResult{
+		{Migration: "001_create_users", Transaction: migrator.MigrationTransaction, SQL: []string{"CREATE TABLE users (id INT)"}},
+		{Migration: "002_add_index", SQL: []string{"CREATE INDEX CONCURRENTLY i ON users (id)"}},
+		{Migration: "002_add_index", Rollback: true, Transaction: migrator.BatchTransaction, SQL: []string{"DROP INDEX i"}},
+		{Migration: "001_create_users", Rollback: true, Transaction: migrator.BatchTransaction, SQL: []string{"DROP TABLE users"}},
+		{Migration: "001_create_users", Transaction: migrator.BatchTransaction, SQL: []string{"CREATE TABLE users (id INT)"}},
+	}
+
+	expected := `-- Migration: 001_create_users
+BEGIN;
+CREATE TABLE users (id INT);
+COMMIT;
+
+-- Migration: 002_add_index
+CREATE INDEX CONCURRENTLY i ON users (id);
+
+BEGIN;
+
+-- Rollback: 002_add_index
+DROP INDEX i;
+
+-- Rollback: 001_create_users
+DROP TABLE users;
+
+COMMIT;
+
+BEGIN;
+
+-- Migration: 001_create_users
+CREATE TABLE users (id INT);
+
+COMMIT;
+`
+	if got := formatDryRun(results, "postgres"); got != expected {
+		t.Errorf("unexpected script:\n%s", got)
+	}
+}
+
+// 测试目标：验证 dry run 结果写入文件
+func TestPrintDryRun_Output(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "plan.sql")
+	results := []migrator.DryRunResult{{Migration: "001_create_users", SQL: []string{"CREATE TABLE users (id INT)"}}}
+
+	if err := printDryRun(results, "postgres", output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "-- Generated by migro dry run") {
+		t.Error("expected header comment")
+	}
+	if !strings.Contains(string(content), "CREATE TABLE users (id INT);") {
+		t.Error("expected SQL statement in output")
+	}
+}
+
+// 测试目标：验证 MySQL 含分号的语句写入 DELIMITER 块，已有结束符的语句不重复添加
+func TestFormatDryRun_MySQLDelimiter(t *testing.T) {
+	results := []migrator.DryRunResult{{Migration: "001_create_procedure", SQL: []string{
+		"CREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND;",
+		"SELECT 'a;b';",
+		"CALL p()",
+	}}}
+
+	expected := `-- Migration: 001_create_procedure
+DELIMITER $$
+CREATE PROCEDURE p()
+BEGIN
+  SELECT 1;
+  SELECT 2;
+END$$
+DELIMITER ;
+SELECT 'a;b';
+CALL p();
+`
+	if got := formatDryRun(results, "mysql"); got != expected {
+		t.Errorf("unexpected script:\n%s", got)
+	}
+}
+
+// 测试目标：验证 dry run 输出隐藏已记录的密钥
+func TestPrintDryRun_Redact(t *testing.T) {
+	secret.Remember("dry-run-s3cret")
+	output := filepath.Join(t.TempDir(), "plan.sql")
+	results := []migrator.DryRunResult{{Migration: "001_create_user", SQL: []string{"CREATE USER app IDENTIFIED BY 'dry-run-s3cret'"}}}
+
+	if err := printDryRun(results, "mysql", output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if strings.Contains(string(content), "dry-run-s3cret") {
+		t.Error("expected the secret to be redacted")
+	}
+	if !strings.Contains(string(content), "IDENTIFIED BY '****'") {
+		t.Errorf("expected the masked secret, got:\n%s", content)
+	}
+}
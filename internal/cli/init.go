@@ -25,7 +25,7 @@ func init() {
 
 func runInit(cmd *cobra.Command, args []string) error {
 	// Check if config already exists
-	loader := config.NewLoader(cfgFile)
+	loader := newLoader()
 	if loader.Exists() {
 		return fmt.Errorf("configuration file %s already exists", cfgFile)
 	}
@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/flyits/migro/pkg/migrator"
+)
+
+// configureLogging registers the event logger selected with --log-format.
+// The text format keeps the regular command output only, the json format
+// also writes every migration event to stderr as a line of JSON.
+func configureLogging(m *migrator.Migrator) error {
+	switch logFormat {
+	case "", "text":
+		return nil
+	case "json":
+		m.AddHook(migrator.NewJSONLogger(redactWriter{w: os.Stderr}))
+		return nil
+	default:
+		return fmt.Errorf("unknown log format %q, expected text or json", logFormat)
+	}
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/flyits/migro/pkg/migrator"
+)
+
+// 测试目标：验证 --log-format 的取值校验
+func TestConfigureLogging(t *testing.T) {
+	defer func(format string) { logFormat = format }(logFormat)
+
+	for _, tt := range []struct {
+		format  string
+		wantErr bool
+	}{
+		{"text", false},
+		{"json", false},
+		{"xml", true},
+	} {
+		logFormat = tt.format
+		err := configureLogging(migrator.NewMigrator(nil, "", "migrations"))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error %v, got %v", tt.format, tt.wantErr, err)
+		}
+	}
+}
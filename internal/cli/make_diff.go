@@ -0,0 +1,253 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/flyits/migro/pkg/diff"
+	"github.com/flyits/migro/pkg/driver"
+	_ "github.com/flyits/migro/pkg/driver/mysql"
+	_ "github.com/flyits/migro/pkg/driver/postgres"
+	_ "github.com/flyits/migro/pkg/driver/sqlite"
+	"github.com/flyits/migro/pkg/migration"
+	"github.com/flyits/migro/pkg/migrator"
+	"github.com/flyits/migro/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffSchemaPath string
+	diffDryRun     bool
+)
+
+var makeDiffCmd = &cobra.Command{
+	Use:   "make:diff [name]",
+	Short: "Generate a migration from the desired schema",
+	Long: `Compares the tables defined with migration.DefineTable against the database
+and writes a migration that applies the differences, with the inverse
+operations in its Down method.
+
+The definitions are compiled from the migrations directory and from the
+package given with --schema. Columns are only renamed when the desired column
+is marked with RenamedFrom; otherwise the old column is dropped and the new
+one added. Tables that are not defined are left alone.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runMakeDiff,
+}
+
+func init() {
+	makeDiffCmd.Flags().StringVar(&diffSchemaPath, "schema", "", "directory of the Go package defining the desired schema")
+	makeDiffCmd.Flags().BoolVar(&diffDryRun, "dry-run", false, "print the migration instead of writing it")
+	rootCmd.AddCommand(makeDiffCmd)
+}
+
+func runMakeDiff(cmd *cobra.Command, args []string) error {
+	name := "schema_diff"
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	// Load config, or compile the migrations and the schema definitions of
+	// the project if needed
+	var dirs []string
+	if diffSchemaPath != "" {
+		dirs = append(dirs, diffSchemaPath)
+	}
+	cfg, delegated, err := loadConfig(cmd, dirs...)
+	if delegated || err != nil {
+		return err
+	}
+
+	desired := migration.DefinedTables()
+	if len(desired) == 0 {
+		return fmt.Errorf("no schema definitions found: define tables with migration.DefineTable and pass their package with --schema")
+	}
+
+	// Get driver
+	drv, err := driver.Get(cfg.Driver)
+	if err != nil {
+		return fmt.Errorf("failed to get driver: %w", err)
+	}
+	ins, ok := drv.(driver.Inspector)
+	if !ok {
+		return fmt.Errorf("driver %s does not support schema inspection", drv.Name())
+	}
+
+	// Connect to database
+	if err := connectDatabase(drv, cfg); err != nil {
+		return err
+	}
+	defer drv.Close()
+
+	ctx := context.Background()
+
+	// The diff is taken against the database, so pending migrations would be repeated
+	m := migrator.NewMigrator(drv, cfg.Migrations.Path, cfg.Migrations.Table)
+	migration.Load(m)
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get migration status: %w", err)
+	}
+	pending := 0
+	for _, s := range statuses {
+		if !s.Ran {
+			pending++
+		}
+	}
+	if pending > 0 {
+		fmt.Printf("Warning: %d pending migration(s) are not part of the diff; run 'migro up' first\n", pending)
+	}
+
+	current, err := inspectTables(ctx, ins, drv, desired)
+	if err != nil {
+		return fmt.Errorf("failed to inspect database: %w", err)
+	}
+
+	diffs := diff.Compare(drv.Grammar(), desired, current)
+	if len(diffs) == 0 {
+		fmt.Println("Schema is up to date, no migration created.")
+		return nil
+	}
+
+	timestamp := time.Now().Format("20060102150405")
+	up, down := diff.Generate(diffs)
+	content, err := generateDiffMigrationTemplate(name, timestamp, up, down)
+	if err != nil {
+		return err
+	}
+
+	if diffDryRun {
+		fmt.Print(content)
+		return nil
+	}
+
+	if err := os.MkdirAll(cfg.Migrations.Path, 0755); err != nil {
+		return fmt.Errorf("failed to create migrations directory: %w", err)
+	}
+	path := filepath.Join(cfg.Migrations.Path, fmt.Sprintf("%s_%s.go", timestamp, toSnakeCase(name)))
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to create migration file: %w", err)
+	}
+
+	fmt.Printf("Created migration: %s\n", path)
+	printDiffSummary(diffs)
+	return nil
+}
+
+// inspectTables reads the current definition of the desired tables that exist
+func inspectTables(ctx context.Context, ins driver.Inspector, q driver.Queryer, desired []*schema.Table) (map[string]*schema.Table, error) {
+	existing, err := ins.GetTables(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		exists[name] = true
+	}
+
+	current := make(map[string]*schema.Table)
+	for _, table := range desired {
+		if !exists[table.Name] {
+			continue
+		}
+		def, err := driver.InspectTable(ctx, ins, q, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		current[table.Name] = def
+	}
+	return current, nil
+}
+
+// printDiffSummary lists the changes of each table and warns about the
+// operations that lose data
+func printDiffSummary(diffs []*diff.TableDiff) {
+	var dropped []string
+	for _, d := range diffs {
+		if d.Create != nil {
+			fmt.Printf("  %s: create table\n", d.Table)
+			continue
+		}
+
+		var parts []string
+		for _, item := range []struct {
+			count int
+			label string
+		}{
+			{len(d.AddColumns), "added"},
+			{len(d.ChangeColumns), "changed"},
+			{len(d.RenameColumns), "renamed"},
+			{len(d.DropColumns), "dropped"},
+		} {
+			if item.count > 0 {
+				parts = append(parts, fmt.Sprintf("%d column(s) %s", item.count, item.label))
+			}
+		}
+		if n := len(d.AddIndexes) + len(d.DropIndexes); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d index change(s)", n))
+		}
+		if n := len(d.AddForeignKeys) + len(d.DropForeignKeys); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d foreign key change(s)", n))
+		}
+		if n := len(d.AddChecks) + len(d.DropChecks); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d check change(s)", n))
+		}
+		fmt.Printf("  %s: %s\n", d.Table, strings.Join(parts, ", "))
+
+		for _, col := range d.DropColumns {
+			dropped = append(dropped, d.Table+"."+col.Name)
+		}
+	}
+
+	if len(dropped) > 0 {
+		fmt.Printf("Warning: the migration drops %s; mark renamed columns with RenamedFrom and review it before running\n",
+			strings.Join(dropped, ", "))
+	}
+}
+
+func generateDiffMigrationTemplate(name, timestamp, up, down string) (string, error) {
+	structName := toCamelCase(name)
+
+	content := fmt.Sprintf(`package migrations
+
+import (
+	"context"
+
+	"github.com/flyits/migro/pkg/migration"
+	"github.com/flyits/migro/pkg/migrator"
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// %s migration generated by migro make:diff
+type %s struct{}
+
+// Name returns the migration name
+func (m *%s) Name() string {
+	return "%s_%s"
+}
+
+// Up runs the migration
+func (m *%s) Up(ctx context.Context, e *migrator.Executor) error {
+%s}
+
+// Down reverses the migration
+func (m *%s) Down(ctx context.Context, e *migrator.Executor) error {
+%s}
+
+func init() {
+	migration.Register(&%s{})
+}
+`, structName, structName, structName, timestamp, toSnakeCase(name),
+		structName, up, structName, down, structName)
+
+	formatted, err := format.Source([]byte(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to format generated migration: %w", err)
+	}
+	return string(formatted), nil
+}
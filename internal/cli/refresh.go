@@ -4,16 +4,23 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/flyits/migro/internal/config"
-	"github.com/flyits/migro/internal/migrator"
 	"github.com/flyits/migro/pkg/driver"
 	_ "github.com/flyits/migro/pkg/driver/mysql"
 	_ "github.com/flyits/migro/pkg/driver/postgres"
 	_ "github.com/flyits/migro/pkg/driver/sqlite"
+	"github.com/flyits/migro/pkg/migration"
+	"github.com/flyits/migro/pkg/migrator"
 	"github.com/spf13/cobra"
 )
 
-var refreshForce bool
+var (
+	refreshDryRun  bool
+	refreshOutput  string
+	refreshForce   bool
+	refreshNoLock  bool
+	refreshBatchTx bool
+	refreshNote    string
+)
 
 var refreshCmd = &cobra.Command{
 	Use:   "refresh",
@@ -23,16 +30,24 @@ var refreshCmd = &cobra.Command{
 }
 
 func init() {
+	refreshCmd.Flags().BoolVar(&refreshDryRun, "dry-run", false, "show SQL without executing")
+	refreshCmd.Flags().StringVarP(&refreshOutput, "output", "o", "", "write the dry run SQL to a file")
 	refreshCmd.Flags().BoolVar(&refreshForce, "force", false, "force refresh without confirmation")
+	refreshCmd.Flags().BoolVar(&refreshNoLock, "no-lock", false, "do not acquire the migration lock")
+	refreshCmd.Flags().BoolVar(&refreshBatchTx, "batch-transaction", false, "run all migrations in a single transaction")
+	refreshCmd.Flags().StringVar(&refreshNote, "note", "", "note recorded with the applied migrations")
 	rootCmd.AddCommand(refreshCmd)
 }
 
 func runRefresh(cmd *cobra.Command, args []string) error {
-	// Load config
-	loader := config.NewLoader(cfgFile)
-	cfg, err := loader.Load()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+	// Load config, or compile and run the Go migrations of the project if needed
+	cfg, delegated, err := loadConfig(cmd)
+	if delegated || err != nil {
+		return err
+	}
+
+	if err := confirmProduction(cfg, refreshForce || refreshDryRun, "refresh"); err != nil {
+		return err
 	}
 
 	// Get driver
@@ -42,13 +57,21 @@ func runRefresh(cmd *cobra.Command, args []string) error {
 	}
 
 	// Connect to database
-	if err := drv.Connect(cfg.ToDriverConfig()); err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+	if err := connectDatabase(drv, cfg); err != nil {
+		return err
 	}
 	defer drv.Close()
 
 	// Create migrator
 	m := migrator.NewMigrator(drv, cfg.Migrations.Path, cfg.Migrations.Table)
+	migration.Load(m)
+	configureLock(m, cfg, refreshNoLock)
+	m.SetBatchTransaction(refreshBatchTx || cfg.Migrations.BatchTransaction)
+	m.SetRunInfo(runInfo(refreshNote))
+	if err := configureLogging(m); err != nil {
+		return err
+	}
+	m.SetDryRun(refreshDryRun)
 
 	ctx := context.Background()
 
@@ -58,6 +81,10 @@ func runRefresh(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("refresh failed: %w", err)
 	}
 
+	if refreshDryRun && (len(rolledBack) > 0 || len(executed) > 0) {
+		return printDryRun(m.DryRunResults(), cfg.Driver, refreshOutput)
+	}
+
 	if len(rolledBack) > 0 {
 		fmt.Println("Migrations rolled back:")
 		for _, name := range rolledBack {
@@ -4,16 +4,20 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/flyits/migro/internal/config"
-	"github.com/flyits/migro/internal/migrator"
 	"github.com/flyits/migro/pkg/driver"
 	_ "github.com/flyits/migro/pkg/driver/mysql"
 	_ "github.com/flyits/migro/pkg/driver/postgres"
 	_ "github.com/flyits/migro/pkg/driver/sqlite"
+	"github.com/flyits/migro/pkg/migration"
+	"github.com/flyits/migro/pkg/migrator"
 	"github.com/spf13/cobra"
 )
 
-var resetForce bool
+var (
+	resetForce   bool
+	resetNoLock  bool
+	resetBatchTx bool
+)
 
 var resetCmd = &cobra.Command{
 	Use:   "reset",
@@ -24,15 +28,20 @@ var resetCmd = &cobra.Command{
 
 func init() {
 	resetCmd.Flags().BoolVar(&resetForce, "force", false, "force reset without confirmation")
+	resetCmd.Flags().BoolVar(&resetNoLock, "no-lock", false, "do not acquire the migration lock")
+	resetCmd.Flags().BoolVar(&resetBatchTx, "batch-transaction", false, "run all migrations in a single transaction")
 	rootCmd.AddCommand(resetCmd)
 }
 
 func runReset(cmd *cobra.Command, args []string) error {
-	// Load config
-	loader := config.NewLoader(cfgFile)
-	cfg, err := loader.Load()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+	// Load config, or compile and run the Go migrations of the project if needed
+	cfg, delegated, err := loadConfig(cmd)
+	if delegated || err != nil {
+		return err
+	}
+
+	if err := confirmProduction(cfg, resetForce, "reset"); err != nil {
+		return err
 	}
 
 	// Get driver
@@ -42,13 +51,19 @@ func runReset(cmd *cobra.Command, args []string) error {
 	}
 
 	// Connect to database
-	if err := drv.Connect(cfg.ToDriverConfig()); err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+	if err := connectDatabase(drv, cfg); err != nil {
+		return err
 	}
 	defer drv.Close()
 
 	// Create migrator
 	m := migrator.NewMigrator(drv, cfg.Migrations.Path, cfg.Migrations.Table)
+	migration.Load(m)
+	configureLock(m, cfg, resetNoLock)
+	m.SetBatchTransaction(resetBatchTx || cfg.Migrations.BatchTransaction)
+	if err := configureLogging(m); err != nil {
+		return err
+	}
 
 	ctx := context.Background()
 
@@ -0,0 +1,245 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/flyits/migro/internal/config"
+	"github.com/flyits/migro/pkg/migration"
+	"github.com/spf13/cobra"
+)
+
+// runnerEnv marks a process as a compiled migration runner so it never
+// tries to compile the migrations again
+const runnerEnv = "MIGRO_RUNNER"
+
+// exitError carries the exit code of a migration runner process
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("migration runner exited with code %d", e.code)
+}
+
+// runnerTemplate is the main package generated for the migration runner
+const runnerTemplate = `// Code generated by migro. DO NOT EDIT.
+
+package main
+
+import (
+%s
+	"github.com/flyits/migro/pkg/runner"
+)
+
+func main() {
+	runner.Main()
+}
+`
+
+// loadConfig loads the configuration of a command running the migrations of
+// the project. When the command is delegated to a migration runner, the
+// configuration is read without its secrets: the runner resolves them once,
+// with the resolvers registered by the project.
+func loadConfig(cmd *cobra.Command, dirs ...string) (*config.Config, bool, error) {
+	loader := newLoader()
+	loader.SetSkipSecrets(true)
+	cfg, err := loader.Load()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if delegated, err := delegateToRunner(cmd, cfg, dirs...); delegated {
+		return nil, true, err
+	}
+
+	loader.SetSkipSecrets(false)
+	if cfg, err = loader.Load(); err != nil {
+		return nil, false, fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg, false, nil
+}
+
+// delegateToRunner compiles the Go migrations found in the migrations
+// directory, and the Go packages in dirs, into a temporary runner binary and
+// re-executes the current command with it. It returns false when the command
+// should run in-process.
+func delegateToRunner(cmd *cobra.Command, cfg *config.Config, dirs ...string) (bool, error) {
+	if os.Getenv(runnerEnv) != "" || len(migration.Registered()) > 0 || len(migration.DefinedTables()) > 0 {
+		return false, nil
+	}
+
+	var importPaths []string
+	var moduleRoot string
+	for _, dir := range append([]string{cfg.Migrations.Path}, dirs...) {
+		hasGo, err := hasGoFiles(dir)
+		if err != nil {
+			return true, err
+		}
+		if !hasGo {
+			continue
+		}
+
+		importPath, root, err := migrationsImportPath(dir)
+		if err != nil {
+			return true, err
+		}
+		if moduleRoot != "" && root != moduleRoot {
+			return true, fmt.Errorf("%s is not in the Go module of %s", dir, moduleRoot)
+		}
+		moduleRoot = root
+		if !containsString(importPaths, importPath) {
+			importPaths = append(importPaths, importPath)
+		}
+	}
+	if len(importPaths) == 0 {
+		return false, nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "migro-runner-")
+	if err != nil {
+		return true, fmt.Errorf("failed to create runner directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	mainFile := filepath.Join(tmpDir, "main.go")
+	if err := os.WriteFile(mainFile, []byte(generateRunnerMain(importPaths...)), 0644); err != nil {
+		return true, fmt.Errorf("failed to write runner main package: %w", err)
+	}
+
+	binary := filepath.Join(tmpDir, "migro-runner")
+	if verbose {
+		fmt.Printf("Compiling %s\n", strings.Join(importPaths, ", "))
+	}
+
+	// The runner reports the version of this CLI, e.g. in the migrations table
+	build := exec.Command("go", "build", "-ldflags", "-X github.com/flyits/migro/internal/cli.Version="+Version, "-o", binary, mainFile)
+	build.Dir = moduleRoot
+	build.Stdout = os.Stderr
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		return true, fmt.Errorf("failed to compile migrations (make sure github.com/flyits/migro is required in %s): %w",
+			filepath.Join(moduleRoot, "go.mod"), err)
+	}
+
+	run := exec.Command(binary, os.Args[1:]...)
+	run.Env = append(os.Environ(), runnerEnv+"=1")
+	run.Stdin = os.Stdin
+	run.Stdout = os.Stdout
+	run.Stderr = os.Stderr
+	if err := run.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// The runner has already reported the failure
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+			return true, &exitError{code: exitErr.ExitCode()}
+		}
+		return true, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return true, nil
+}
+
+// generateRunnerMain generates the main package of the migration runner,
+// importing the given packages for their init() functions
+func generateRunnerMain(importPaths ...string) string {
+	var imports strings.Builder
+	for _, path := range importPaths {
+		fmt.Fprintf(&imports, "\t_ %q\n", path)
+	}
+	return fmt.Sprintf(runnerTemplate, imports.String())
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// hasGoFiles reports whether dir contains Go source files other than tests
+func hasGoFiles(dir string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// migrationsImportPath returns the import path of the migrations package
+// and the root directory of the Go module containing it
+func migrationsImportPath(dir string) (string, string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve migrations path: %w", err)
+	}
+
+	root := absDir
+	for {
+		if _, err := os.Stat(filepath.Join(root, "go.mod")); err == nil {
+			break
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return "", "", fmt.Errorf("no go.mod found for migrations directory %s", dir)
+		}
+		root = parent
+	}
+
+	modulePath, err := readModulePath(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", "", err
+	}
+
+	rel, err := filepath.Rel(root, absDir)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve migrations path: %w", err)
+	}
+	if rel == "." {
+		return modulePath, root, nil
+	}
+	return modulePath + "/" + filepath.ToSlash(rel), root, nil
+}
+
+// readModulePath reads the module path declared in a go.mod file
+func readModulePath(goMod string) (string, error) {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", goMod, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module") {
+			path := strings.TrimSpace(strings.TrimPrefix(line, "module"))
+			path = strings.Trim(path, "\"`")
+			if path != "" {
+				return path, nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", goMod, err)
+	}
+	return "", fmt.Errorf("no module declaration found in %s", goMod)
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试目标：验证生成的 runner main 包为合法的 Go 代码并导入迁移包与表结构包
+func TestGenerateRunnerMain(t *testing.T) {
+	content := generateRunnerMain("example.com/app/migrations", "example.com/app/db/schema")
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", content, parser.ImportsOnly)
+	if err != nil {
+		t.Fatalf("generated runner is not valid Go: %v\n%s", err, content)
+	}
+
+	imports := make(map[string]bool)
+	for _, imp := range file.Imports {
+		imports[strings.Trim(imp.Path.Value, `"`)] = true
+	}
+	for _, want := range []string{"example.com/app/migrations", "example.com/app/db/schema", "github.com/flyits/migro/pkg/runner"} {
+		if !imports[want] {
+			t.Errorf("expected runner to import %q", want)
+		}
+	}
+}
+
+// 测试目标：验证 hasGoFiles 只识别非测试的 Go 源文件
+func TestHasGoFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		expected bool
+	}{
+		{"空目录", nil, false},
+		{"包含迁移文件", []string{"20260101000000_create_users.go"}, true},
+		{"只有测试文件", []string{"migrations_test.go"}, false},
+		{"只有SQL文件", []string{"20260101000000_create_users.up.sql"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, f), []byte("package migrations\n"), 0644); err != nil {
+					t.Fatalf("failed to write file: %v", err)
+				}
+			}
+
+			got, err := hasGoFiles(dir)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("hasGoFiles() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	t.Run("目录不存在", func(t *testing.T) {
+		got, err := hasGoFiles(filepath.Join(t.TempDir(), "missing"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got {
+			t.Error("expected false for a missing directory")
+		}
+	})
+}
+
+// 测试目标：验证迁移目录的导入路径解析
+func TestMigrationsImportPath(t *testing.T) {
+	root := t.TempDir()
+	goMod := "module example.com/app\n\ngo 1.21\n"
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte(goMod), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	dir := filepath.Join(root, "db", "migrations")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create migrations dir: %v", err)
+	}
+
+	importPath, moduleRoot, err := migrationsImportPath(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if importPath != "example.com/app/db/migrations" {
+		t.Errorf("expected import path 'example.com/app/db/migrations', got %q", importPath)
+	}
+	if moduleRoot != root {
+		t.Errorf("expected module root %q, got %q", root, moduleRoot)
+	}
+
+	t.Run("没有 go.mod", func(t *testing.T) {
+		if _, _, err := migrationsImportPath(t.TempDir()); err == nil {
+			t.Error("expected error when no go.mod exists")
+		}
+	})
+}
+
+// 测试目标：验证 go.mod 中模块路径的读取
+func TestReadModulePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		wantErr  bool
+	}{
+		{"普通模块", "module example.com/app\n\ngo 1.21\n", "example.com/app", false},
+		{"带引号", "module \"example.com/app\"\n", "example.com/app", false},
+		{"缺少模块声明", "go 1.21\n", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "go.mod")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write go.mod: %v", err)
+			}
+
+			got, err := readModulePath(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readModulePath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("readModulePath() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
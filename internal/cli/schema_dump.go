@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/flyits/migro/pkg/driver"
+	_ "github.com/flyits/migro/pkg/driver/mysql"
+	_ "github.com/flyits/migro/pkg/driver/postgres"
+	_ "github.com/flyits/migro/pkg/driver/sqlite"
+	"github.com/flyits/migro/pkg/migrator"
+	"github.com/spf13/cobra"
+)
+
+var schemaDumpPrune bool
+
+var schemaDumpCmd = &cobra.Command{
+	Use:   "schema:dump",
+	Short: "Dump the database schema to squash executed migrations",
+	Long: `Writes the DDL of the database and the records of the migrations table to
+<schema_path>/<driver>-schema.sql. When the migrations table is empty,
+'migro up' loads the dump first and then runs only the migrations it does
+not record.
+
+--prune deletes the files of the migrations recorded in the dump. Pruned
+migrations can no longer be rolled back.`,
+	RunE: runSchemaDump,
+}
+
+func init() {
+	schemaDumpCmd.Flags().BoolVar(&schemaDumpPrune, "prune", false, "delete the migration files recorded in the dump")
+	rootCmd.AddCommand(schemaDumpCmd)
+}
+
+func runSchemaDump(cmd *cobra.Command, args []string) error {
+	// Load config
+	loader := newLoader()
+	cfg, err := loader.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Get driver
+	drv, err := driver.Get(cfg.Driver)
+	if err != nil {
+		return fmt.Errorf("failed to get driver: %w", err)
+	}
+
+	// Connect to database
+	if err := connectDatabase(drv, cfg); err != nil {
+		return err
+	}
+	defer drv.Close()
+
+	m := migrator.NewMigrator(drv, cfg.Migrations.Path, cfg.Migrations.Table)
+	content, dumped, err := m.DumpSchema(context.Background())
+	if err != nil {
+		return err
+	}
+
+	path := migrator.SchemaDumpFile(cfg.Migrations.SchemaPath, cfg.Driver)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create schema directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write schema dump: %w", err)
+	}
+	fmt.Printf("Schema dumped: %s (%d migration(s))\n", path, len(dumped))
+
+	if !schemaDumpPrune {
+		return nil
+	}
+
+	pruned, err := pruneMigrationFiles(cfg.Migrations.Path, dumped)
+	if len(pruned) > 0 {
+		fmt.Println("Migration files pruned:")
+		for _, file := range pruned {
+			fmt.Printf("  - %s\n", file)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to prune migrations: %w", err)
+	}
+	if len(pruned) == 0 {
+		fmt.Println("No migration files to prune.")
+	}
+	return nil
+}
+
+// pruneMigrationFiles deletes the Go and SQL files of the named migrations
+// and returns the deleted paths
+func pruneMigrationFiles(dir string, names []string) ([]string, error) {
+	var pruned []string
+	for _, name := range names {
+		for _, ext := range []string{".go", ".up.sql", ".down.sql"} {
+			path := filepath.Join(dir, name+ext)
+			if err := os.Remove(path); err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return pruned, err
+			}
+			pruned = append(pruned, path)
+		}
+	}
+	return pruned, nil
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 测试目标：验证 --prune 只删除 dump 中记录的迁移文件
+func TestPruneMigrationFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"001_create_users.go",
+		"002_add_index.up.sql",
+		"002_add_index.down.sql",
+		"003_create_posts.go",
+		"migrations.go",
+	}
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	pruned, err := pruneMigrationFiles(dir, []string{"001_create_users", "002_add_index", "000_missing"})
+	if err != nil {
+		t.Fatalf("pruneMigrationFiles failed: %v", err)
+	}
+	if len(pruned) != 3 {
+		t.Errorf("expected 3 pruned files, got %v", pruned)
+	}
+
+	for name, exists := range map[string]bool{
+		"001_create_users.go":    false,
+		"002_add_index.up.sql":   false,
+		"002_add_index.down.sql": false,
+		"003_create_posts.go":    true,
+		"migrations.go":          true,
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if (err == nil) != exists {
+			t.Errorf("%s: expected exists=%v", name, exists)
+		}
+	}
+}
@@ -4,16 +4,19 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
-	"github.com/flyits/migro/internal/config"
-	"github.com/flyits/migro/internal/migrator"
 	"github.com/flyits/migro/pkg/driver"
 	_ "github.com/flyits/migro/pkg/driver/mysql"
 	_ "github.com/flyits/migro/pkg/driver/postgres"
 	_ "github.com/flyits/migro/pkg/driver/sqlite"
+	"github.com/flyits/migro/pkg/migration"
+	"github.com/flyits/migro/pkg/migrator"
 	"github.com/spf13/cobra"
 )
 
+var statusLong bool
+
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show migration status",
@@ -22,15 +25,15 @@ var statusCmd = &cobra.Command{
 }
 
 func init() {
+	statusCmd.Flags().BoolVarP(&statusLong, "long", "l", false, "show the duration, host, user, version and note of executed migrations")
 	rootCmd.AddCommand(statusCmd)
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
-	// Load config
-	loader := config.NewLoader(cfgFile)
-	cfg, err := loader.Load()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+	// Load config, or compile and run the Go migrations of the project if needed
+	cfg, delegated, err := loadConfig(cmd)
+	if delegated || err != nil {
+		return err
 	}
 
 	// Get driver
@@ -40,13 +43,14 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	// Connect to database
-	if err := drv.Connect(cfg.ToDriverConfig()); err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+	if err := connectDatabase(drv, cfg); err != nil {
+		return err
 	}
 	defer drv.Close()
 
 	// Create migrator
 	m := migrator.NewMigrator(drv, cfg.Migrations.Path, cfg.Migrations.Table)
+	migration.Load(m)
 
 	ctx := context.Background()
 
@@ -61,32 +65,87 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if statusLong {
+		fmt.Print(formatStatusLong(statuses))
+		printModifiedWarning(statuses)
+		return nil
+	}
+
 	// Print table header
-	fmt.Println(strings.Repeat("-", 80))
-	fmt.Printf("| %-40s | %-6s | %-5s | %-19s |\n", "Migration", "Status", "Batch", "Executed At")
-	fmt.Println(strings.Repeat("-", 80))
+	fmt.Println(strings.Repeat("-", 85))
+	fmt.Printf("| %-40s | %-8s | %-5s | %-19s |\n", "Migration", "Status", "Batch", "Executed At")
+	fmt.Println(strings.Repeat("-", 85))
 
 	// Print migrations
 	for _, s := range statuses {
-		status := "Pending"
-		batch := ""
-		executedAt := ""
-
-		if s.Ran {
-			status = "Ran"
-			batch = fmt.Sprintf("%d", s.Batch)
-			executedAt = s.ExecutedAt
-		}
+		status, batch, executedAt := statusColumns(s)
+		fmt.Printf("| %-40s | %-8s | %-5s | %-19s |\n", truncate(s.Name, 40), status, batch, executedAt)
+	}
 
-		name := s.Name
-		if len(name) > 40 {
-			name = name[:37] + "..."
-		}
+	fmt.Println(strings.Repeat("-", 85))
+	printModifiedWarning(statuses)
+
+	return nil
+}
 
-		fmt.Printf("| %-40s | %-6s | %-5s | %-19s |\n", name, status, batch, executedAt)
+// statusColumns returns the status, batch and execution time columns of a migration
+func statusColumns(s migrator.MigrationStatus) (status, batch, executedAt string) {
+	status = "Pending"
+	if s.Ran {
+		status = "Ran"
+		batch = fmt.Sprintf("%d", s.Batch)
+		executedAt = s.ExecutedAt
 	}
+	if s.Modified {
+		status = "Modified"
+	}
+	return status, batch, executedAt
+}
 
-	fmt.Println(strings.Repeat("-", 80))
+// formatStatusLong renders the status table with the metadata recorded when
+// the migrations ran
+func formatStatusLong(statuses []migrator.MigrationStatus) string {
+	const width = 170
+	row := "| %-40s | %-8s | %-5s | %-19s | %-9s | %-20s | %-12s | %-10s | %-20s |\n"
 
-	return nil
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat("-", width) + "\n")
+	fmt.Fprintf(&sb, row, "Migration", "Status", "Batch", "Executed At", "Duration", "Host", "User", "Version", "Note")
+	sb.WriteString(strings.Repeat("-", width) + "\n")
+
+	for _, s := range statuses {
+		status, batch, executedAt := statusColumns(s)
+		// Migrations executed by older versions have no metadata
+		duration := ""
+		if s.Ran && (s.Duration > 0 || s.Host != "" || s.Version != "") {
+			duration = s.Duration.Round(time.Millisecond).String()
+		}
+		fmt.Fprintf(&sb, row, truncate(s.Name, 40), status, batch, executedAt, truncate(duration, 9),
+			truncate(s.Host, 20), truncate(s.User, 12), truncate(s.Version, 10), truncate(s.Note, 20))
+	}
+
+	sb.WriteString(strings.Repeat("-", width) + "\n")
+	return sb.String()
+}
+
+// printModifiedWarning warns about executed migrations that changed since they ran
+func printModifiedWarning(statuses []migrator.MigrationStatus) {
+	modified := 0
+	for _, s := range statuses {
+		if s.Modified {
+			modified++
+		}
+	}
+	if modified > 0 {
+		fmt.Printf("\nWarning: %d executed migration(s) changed since they ran. Run 'migro verify' for details.\n", modified)
+	}
+}
+
+// truncate shortens s to at most n characters
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
 }
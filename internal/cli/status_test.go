@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flyits/migro/pkg/migrator"
+)
+
+// 测试目标：验证 status --long 输出执行元数据并截断过长的值
+func TestFormatStatusLong(t *testing.T) {
+	output := formatStatusLong([]migrator.MigrationStatus{
+		{
+			Name:       "20260102150405_create_users",
+			Ran:        true,
+			Batch:      1,
+			ExecutedAt: "2026-01-02 15:04:05",
+			Duration:   1500 * time.Millisecond,
+			Host:       "deploy-1",
+			User:       "alice",
+			Version:    "1.2.0",
+			Note:       "release 42 with a very long description",
+		},
+		{Name: "20260103150405_create_posts"},
+	})
+
+	for _, want := range []string{"Duration", "1.5s", "deploy-1", "alice", "1.2.0", "release 42 with a..."} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q:\n%s", want, output)
+		}
+	}
+	if !strings.Contains(output, "| 20260103150405_create_posts              | Pending  |") {
+		t.Errorf("expected the pending migration row:\n%s", output)
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flyits/migro/internal/config"
+	"github.com/flyits/migro/pkg/driver"
+	_ "github.com/flyits/migro/pkg/driver/mysql"
+	_ "github.com/flyits/migro/pkg/driver/postgres"
+	_ "github.com/flyits/migro/pkg/driver/sqlite"
+	"github.com/flyits/migro/pkg/migrator"
+	"github.com/spf13/cobra"
+)
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Release a stuck migration lock",
+	Long: `Forcibly releases the migration lock left behind by a crashed or killed run.
+Only use it when no other migration is running.`,
+	RunE: runUnlock,
+}
+
+func init() {
+	rootCmd.AddCommand(unlockCmd)
+}
+
+func runUnlock(cmd *cobra.Command, args []string) error {
+	// Load config
+	loader := newLoader()
+	cfg, err := loader.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Get driver
+	drv, err := driver.Get(cfg.Driver)
+	if err != nil {
+		return fmt.Errorf("failed to get driver: %w", err)
+	}
+
+	// Connect to database
+	if err := connectDatabase(drv, cfg); err != nil {
+		return err
+	}
+	defer drv.Close()
+
+	m := migrator.NewMigrator(drv, cfg.Migrations.Path, cfg.Migrations.Table)
+	if err := m.Unlock(context.Background()); err != nil {
+		return err
+	}
+
+	fmt.Println("Migration lock released.")
+	return nil
+}
+
+// configureLock applies the lock settings of the config and the --no-lock flag
+func configureLock(m *migrator.Migrator, cfg *config.Config, noLock bool) {
+	m.SetLocking(!noLock)
+	if cfg.Migrations.LockTimeout != 0 {
+		m.SetLockTimeout(cfg.Migrations.LockTimeout)
+	}
+}
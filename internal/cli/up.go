@@ -2,21 +2,27 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
-	"github.com/flyits/migro/internal/config"
-	"github.com/flyits/migro/internal/migrator"
 	"github.com/flyits/migro/pkg/driver"
 	_ "github.com/flyits/migro/pkg/driver/mysql"
 	_ "github.com/flyits/migro/pkg/driver/postgres"
 	_ "github.com/flyits/migro/pkg/driver/sqlite"
+	"github.com/flyits/migro/pkg/migration"
+	"github.com/flyits/migro/pkg/migrator"
 	"github.com/spf13/cobra"
 )
 
 var (
-	upStep   int
-	upDryRun bool
-	upForce  bool
+	upStep          int
+	upDryRun        bool
+	upForce         bool
+	upNoLock        bool
+	upBatchTx       bool
+	upNote          string
+	upAllowMismatch bool
+	upOutput        string
 )
 
 var upCmd = &cobra.Command{
@@ -29,16 +35,24 @@ var upCmd = &cobra.Command{
 func init() {
 	upCmd.Flags().IntVar(&upStep, "step", 0, "number of migrations to run")
 	upCmd.Flags().BoolVar(&upDryRun, "dry-run", false, "show SQL without executing")
+	upCmd.Flags().StringVarP(&upOutput, "output", "o", "", "write the dry run SQL to a file")
 	upCmd.Flags().BoolVar(&upForce, "force", false, "force execution without confirmation")
+	upCmd.Flags().BoolVar(&upNoLock, "no-lock", false, "do not acquire the migration lock")
+	upCmd.Flags().BoolVar(&upBatchTx, "batch-transaction", false, "run all migrations in a single transaction")
+	upCmd.Flags().StringVar(&upNote, "note", "", "note recorded with the applied migrations")
+	upCmd.Flags().BoolVar(&upAllowMismatch, "allow-checksum-mismatch", false, "run even if executed migrations have been modified")
 	rootCmd.AddCommand(upCmd)
 }
 
 func runUp(cmd *cobra.Command, args []string) error {
-	// Load config
-	loader := config.NewLoader(cfgFile)
-	cfg, err := loader.Load()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+	// Load config, or compile and run the Go migrations of the project if needed
+	cfg, delegated, err := loadConfig(cmd)
+	if delegated || err != nil {
+		return err
+	}
+
+	if err := confirmProduction(cfg, upForce || upDryRun, "migrate"); err != nil {
+		return err
 	}
 
 	// Get driver
@@ -48,40 +62,59 @@ func runUp(cmd *cobra.Command, args []string) error {
 	}
 
 	// Connect to database
-	if err := drv.Connect(cfg.ToDriverConfig()); err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+	if err := connectDatabase(drv, cfg); err != nil {
+		return err
 	}
 	defer drv.Close()
 
 	// Create migrator
 	m := migrator.NewMigrator(drv, cfg.Migrations.Path, cfg.Migrations.Table)
+	migration.Load(m)
+	configureLock(m, cfg, upNoLock)
+	m.SetBatchTransaction(upBatchTx || cfg.Migrations.BatchTransaction)
+	m.SetRunInfo(runInfo(upNote))
+	if err := configureLogging(m); err != nil {
+		return err
+	}
 	m.SetDryRun(upDryRun)
-
-	// Note: In a real implementation, migrations would be loaded from files
-	// For now, we'll show a message about how to register migrations
+	m.SetAllowChecksumMismatch(upAllowMismatch)
+	m.SetSchemaDump(migrator.SchemaDumpFile(cfg.Migrations.SchemaPath, cfg.Driver))
 
 	ctx := context.Background()
 
 	// Run migrations
 	executed, err := m.Up(ctx, upStep)
+	if errors.Is(err, migrator.ErrChecksumMismatch) {
+		return fmt.Errorf("migration failed: %w (run 'migro verify' for details or use --allow-checksum-mismatch)", err)
+	}
 	if err != nil {
 		return fmt.Errorf("migration failed: %w", err)
 	}
 
+	if upDryRun && len(m.DryRunResults()) > 0 {
+		return printDryRun(m.DryRunResults(), cfg.Driver, upOutput)
+	}
+
+	if m.SchemaDumpLoaded() {
+		fmt.Printf("Loaded schema dump: %s\n", migrator.SchemaDumpFile(cfg.Migrations.SchemaPath, cfg.Driver))
+	}
+
 	if len(executed) == 0 {
 		fmt.Println("Nothing to migrate.")
 		return nil
 	}
 
-	if upDryRun {
-		fmt.Println("Dry run - SQL statements that would be executed:")
-	} else {
-		fmt.Println("Migrations executed:")
-	}
-
+	fmt.Println("Migrations executed:")
 	for _, name := range executed {
 		fmt.Printf("  - %s\n", name)
 	}
 
 	return nil
 }
+
+// runInfo returns the run information recorded with the applied migrations
+func runInfo(note string) migrator.RunInfo {
+	info := migrator.NewRunInfo(Version)
+	info.Note = note
+	return info
+}
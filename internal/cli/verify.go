@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flyits/migro/pkg/driver"
+	_ "github.com/flyits/migro/pkg/driver/mysql"
+	_ "github.com/flyits/migro/pkg/driver/postgres"
+	_ "github.com/flyits/migro/pkg/driver/sqlite"
+	"github.com/flyits/migro/pkg/migration"
+	"github.com/flyits/migro/pkg/migrator"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify checksums of executed migrations",
+	Long: `Compares the checksum recorded for each executed migration with its current
+content and reports the migrations that were modified after they ran.
+Exits with a non-zero status when a mismatch is found.`,
+	RunE: runVerify,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	// Load config, or compile and run the Go migrations of the project if needed
+	cfg, delegated, err := loadConfig(cmd)
+	if delegated || err != nil {
+		return err
+	}
+
+	// Get driver
+	drv, err := driver.Get(cfg.Driver)
+	if err != nil {
+		return fmt.Errorf("failed to get driver: %w", err)
+	}
+
+	// Connect to database
+	if err := connectDatabase(drv, cfg); err != nil {
+		return err
+	}
+	defer drv.Close()
+
+	// Create migrator
+	m := migrator.NewMigrator(drv, cfg.Migrations.Path, cfg.Migrations.Table)
+	migration.Load(m)
+
+	mismatches, err := m.Verify(context.Background())
+	if err != nil {
+		return fmt.Errorf("verify failed: %w", err)
+	}
+
+	if len(mismatches) == 0 {
+		fmt.Println("All executed migrations match their recorded checksums.")
+		return nil
+	}
+
+	fmt.Println("Modified migrations:")
+	for _, mismatch := range mismatches {
+		fmt.Printf("  - %s\n", mismatch.Name)
+		fmt.Printf("      recorded: %s\n", mismatch.Recorded)
+		fmt.Printf("      current:  %s\n", mismatch.Current)
+	}
+
+	return fmt.Errorf("%w: %d migration(s)", migrator.ErrChecksumMismatch, len(mismatches))
+}
@@ -0,0 +1,342 @@
+// Package diff compares desired table definitions with the current schema of
+// the database and generates the migration that turns one into the other.
+//
+// Columns are compared through the grammar of the target driver, so two
+// definitions that compile to the same column are equal even when the
+// database reports them differently. Columns are only renamed when the
+// desired column carries a schema.Column.RenamedFrom hint; the diff never
+// guesses renames. Primary keys are not compared.
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/flyits/migro/pkg/driver"
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// ColumnChange pairs the current definition of a column with the desired one
+type ColumnChange struct {
+	From *schema.Column // current definition
+	To   *schema.Column // desired definition
+}
+
+// TableDiff holds the changes that turn the current definition of a table
+// into the desired one
+type TableDiff struct {
+	Table  string
+	Create *schema.Table // desired definition when the table does not exist
+
+	AddColumns      []*schema.Column
+	ChangeColumns   []ColumnChange
+	RenameColumns   []ColumnChange // From holds the old name, To the new one
+	DropColumns     []*schema.Column
+	AddIndexes      []*schema.Index
+	DropIndexes     []*schema.Index
+	AddForeignKeys  []*schema.ForeignKey
+	DropForeignKeys []*schema.ForeignKey
+	AddChecks       []*schema.Check
+	DropChecks      []*schema.Check
+}
+
+// Empty reports whether the table needs no changes
+func (d *TableDiff) Empty() bool {
+	return d.Create == nil &&
+		len(d.AddColumns) == 0 && len(d.ChangeColumns) == 0 && len(d.RenameColumns) == 0 &&
+		len(d.DropColumns) == 0 && len(d.AddIndexes) == 0 && len(d.DropIndexes) == 0 &&
+		len(d.AddForeignKeys) == 0 && len(d.DropForeignKeys) == 0 &&
+		len(d.AddChecks) == 0 && len(d.DropChecks) == 0
+}
+
+// Compare compares the desired tables with the current ones, keyed by table
+// name, and returns the tables that need changes in the order of desired.
+// Tables missing from current are created. Current tables that are not
+// desired are left alone.
+func Compare(g driver.Grammar, desired []*schema.Table, current map[string]*schema.Table) []*TableDiff {
+	var diffs []*TableDiff
+	for _, table := range desired {
+		cur, ok := current[table.Name]
+		if !ok {
+			diffs = append(diffs, &TableDiff{Table: table.Name, Create: table})
+			continue
+		}
+		if d := compareTable(g, table, cur); !d.Empty() {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+// compareTable compares an existing table with its desired definition
+func compareTable(g driver.Grammar, desired, current *schema.Table) *TableDiff {
+	d := &TableDiff{Table: desired.Name}
+
+	currentColumns := make(map[string]*schema.Column, len(current.Columns))
+	for _, col := range current.Columns {
+		currentColumns[strings.ToLower(col.Name)] = col
+	}
+	desiredNames := make(map[string]bool, len(desired.Columns))
+	for _, col := range desired.Columns {
+		desiredNames[strings.ToLower(col.Name)] = true
+	}
+
+	// renames maps the current name of a renamed column to its desired name
+	renames := make(map[string]string)
+	matched := make(map[string]bool)
+	for _, col := range desired.Columns {
+		name := strings.ToLower(col.Name)
+		if cur, ok := currentColumns[name]; ok {
+			matched[name] = true
+			if !columnsEqual(g, cur, col) {
+				d.ChangeColumns = append(d.ChangeColumns, ColumnChange{From: cur, To: col})
+			}
+			continue
+		}
+
+		previous := strings.ToLower(col.PreviousName)
+		if cur, ok := currentColumns[previous]; ok && previous != "" && !desiredNames[previous] && !matched[previous] {
+			matched[previous] = true
+			renames[previous] = strings.ToLower(col.Name)
+			d.RenameColumns = append(d.RenameColumns, ColumnChange{From: cur, To: col})
+			if !columnsEqual(g, cur, col) {
+				d.ChangeColumns = append(d.ChangeColumns, ColumnChange{From: cur, To: col})
+			}
+			continue
+		}
+
+		d.AddColumns = append(d.AddColumns, col)
+	}
+	for _, col := range current.Columns {
+		if !matched[strings.ToLower(col.Name)] {
+			d.DropColumns = append(d.DropColumns, col)
+		}
+	}
+
+	compareIndexes(d, desired, current, renames)
+	compareForeignKeys(d, desired, current, renames)
+	compareChecks(d, desired, current)
+	return d
+}
+
+// compareIndexes compares indexes by type, keys, predicate and method, so an
+// index whose options changed is dropped and added again. Unique columns count
+// as unique indexes, and the indexes MySQL creates for foreign keys are
+// ignored.
+func compareIndexes(d *TableDiff, desired, current *schema.Table, renames map[string]string) {
+	foreignKeyNames := make(map[string]bool, len(current.ForeignKeys))
+	for _, fk := range current.ForeignKeys {
+		foreignKeyNames[strings.ToLower(fk.Name)] = true
+	}
+
+	currentKeys := make(map[string]bool, len(current.Indexes))
+	for _, idx := range current.Indexes {
+		if idx.Type == schema.IndexTypePrimary {
+			continue
+		}
+		currentKeys[indexKey(idx, renames)] = true
+	}
+
+	desiredKeys := make(map[string]bool)
+	for _, idx := range desiredIndexes(desired) {
+		key := indexKey(idx, nil)
+		desiredKeys[key] = true
+		if !currentKeys[key] {
+			d.AddIndexes = append(d.AddIndexes, idx)
+		}
+	}
+
+	for _, idx := range current.Indexes {
+		if idx.Type == schema.IndexTypePrimary || idx.Name == "" {
+			continue
+		}
+		if idx.Type == schema.IndexTypeIndex && foreignKeyNames[strings.ToLower(idx.Name)] {
+			continue
+		}
+		if !desiredKeys[indexKey(idx, renames)] {
+			d.DropIndexes = append(d.DropIndexes, idx)
+		}
+	}
+}
+
+// desiredIndexes returns the indexes of a desired table including the unique
+// indexes of its unique columns. Unnamed indexes get the name the grammars
+// generate for them.
+func desiredIndexes(table *schema.Table) []*schema.Index {
+	var list []*schema.Index
+	add := func(idx *schema.Index) {
+		named := *idx
+		if named.Name == "" {
+			named.Name = IndexName(table.Name, named.Columns, named.Type)
+		}
+		list = append(list, &named)
+	}
+
+	for _, col := range table.Columns {
+		if col.IsUnique {
+			add(schema.NewIndex(col.Name).Unique())
+		}
+	}
+	for _, idx := range table.Indexes {
+		if idx.Type != schema.IndexTypePrimary {
+			add(idx)
+		}
+	}
+	return list
+}
+
+// compareForeignKeys compares foreign keys by columns, referenced columns and actions
+func compareForeignKeys(d *TableDiff, desired, current *schema.Table, renames map[string]string) {
+	currentKeys := make(map[string]bool, len(current.ForeignKeys))
+	for _, fk := range current.ForeignKeys {
+		currentKeys[foreignKeyKey(fk, renames)] = true
+	}
+
+	desiredKeys := make(map[string]bool, len(desired.ForeignKeys))
+	for _, fk := range desired.ForeignKeys {
+		key := foreignKeyKey(fk, nil)
+		desiredKeys[key] = true
+		if !currentKeys[key] {
+			named := *fk
+			if named.Name == "" {
+				named.Name = ForeignKeyName(desired.Name, named.Columns)
+			}
+			d.AddForeignKeys = append(d.AddForeignKeys, &named)
+		}
+	}
+
+	for _, fk := range current.ForeignKeys {
+		if fk.Name != "" && !desiredKeys[foreignKeyKey(fk, renames)] {
+			d.DropForeignKeys = append(d.DropForeignKeys, fk)
+		}
+	}
+}
+
+// compareChecks compares CHECK constraints by name and expression, so a
+// constraint whose expression changed is dropped and added again
+func compareChecks(d *TableDiff, desired, current *schema.Table) {
+	currentKeys := make(map[string]bool, len(current.Checks))
+	for _, check := range current.Checks {
+		currentKeys[checkKey(check)] = true
+	}
+
+	desiredKeys := make(map[string]bool, len(desired.Checks))
+	for _, check := range desired.Checks {
+		key := checkKey(check)
+		desiredKeys[key] = true
+		if !currentKeys[key] {
+			d.AddChecks = append(d.AddChecks, check)
+		}
+	}
+
+	for _, check := range current.Checks {
+		if !desiredKeys[checkKey(check)] {
+			d.DropChecks = append(d.DropChecks, check)
+		}
+	}
+}
+
+// columnsEqual reports whether two column definitions compile to the same
+// column. Uniqueness and primary keys are compared separately.
+func columnsEqual(g driver.Grammar, current, desired *schema.Column) bool {
+	normalize := func(col *schema.Column) *schema.Column {
+		c := *col
+		c.Name = desired.Name
+		c.IsUnique = false
+		c.IsPrimary = false
+		c.After = ""
+		c.Change = false
+		c.PreviousName = ""
+		c.DatabaseType = ""
+		return &c
+	}
+	return strings.EqualFold(g.CompileColumn(normalize(current)), g.CompileColumn(normalize(desired)))
+}
+
+func indexKey(idx *schema.Index, renames map[string]string) string {
+	keys := idx.Keys()
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		part := "(" + normalizeExpression(key.Expression) + ")"
+		if key.Column != "" {
+			part = columnsKey([]string{key.Column}, renames)
+		}
+		if key.Length > 0 {
+			part += fmt.Sprintf("(%d)", key.Length)
+		}
+		if strings.EqualFold(key.Order, schema.OrderDesc) {
+			part += " desc"
+		}
+		parts[i] = part
+	}
+
+	// btree is the default method of every database
+	method := strings.ToLower(idx.Method)
+	if method == "btree" {
+		method = ""
+	}
+	return fmt.Sprintf("%d:%s|%s|%s", idx.Type, strings.Join(parts, ","), method, normalizeExpression(idx.Predicate))
+}
+
+// checkKey identifies a CHECK constraint by name and expression. Databases
+// report the expression with enclosing parentheses and quoted identifiers.
+func checkKey(check *schema.Check) string {
+	expr := strings.NewReplacer("`", "", `"`, "").Replace(driver.TrimParens(check.Expression))
+	return strings.ToLower(check.Name) + "|" + normalizeExpression(expr)
+}
+
+// normalizeExpression lower-cases an SQL expression and collapses its white space
+func normalizeExpression(expr string) string {
+	return strings.ToLower(strings.Join(strings.Fields(expr), " "))
+}
+
+func foreignKeyKey(fk *schema.ForeignKey, renames map[string]string) string {
+	return strings.Join([]string{
+		columnsKey(fk.Columns, renames),
+		strings.ToLower(fk.ReferenceTable),
+		strings.ToLower(strings.ReplaceAll(fk.ReferenceColumn, " ", "")),
+		string(normalizeAction(fk.OnDelete)),
+		string(normalizeAction(fk.OnUpdate)),
+	}, "|")
+}
+
+func columnsKey(columns []string, renames map[string]string) string {
+	keys := make([]string, len(columns))
+	for i, col := range columns {
+		key := strings.ToLower(col)
+		if renamed, ok := renames[key]; ok {
+			key = renamed
+		}
+		keys[i] = key
+	}
+	return strings.Join(keys, ",")
+}
+
+// normalizeAction treats NO ACTION as RESTRICT, which only differ in when
+// the constraint is checked
+func normalizeAction(action schema.ForeignKeyAction) schema.ForeignKeyAction {
+	switch strings.ToUpper(string(action)) {
+	case "", string(schema.ActionNoAction), string(schema.ActionRestrict):
+		return schema.ActionRestrict
+	default:
+		return schema.ForeignKeyAction(strings.ToUpper(string(action)))
+	}
+}
+
+// IndexName returns the name the grammars generate for an unnamed index. The
+// schema of a schema-qualified table is left out, as in the grammars.
+func IndexName(table string, columns []string, typ schema.IndexType) string {
+	_, table = schema.SplitTableName(table)
+	suffix := "idx"
+	if typ == schema.IndexTypeUnique {
+		suffix = "unique"
+	}
+	return fmt.Sprintf("%s_%s_%s", table, strings.Join(columns, "_"), suffix)
+}
+
+// ForeignKeyName returns the name the grammars generate for an unnamed
+// foreign key, leaving out the schema of the table
+func ForeignKeyName(table string, columns []string) string {
+	_, table = schema.SplitTableName(table)
+	return fmt.Sprintf("%s_%s_fk", table, strings.Join(columns, "_"))
+}
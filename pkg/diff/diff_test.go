@@ -0,0 +1,334 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/flyits/migro/pkg/driver/mysql"
+	"github.com/flyits/migro/pkg/driver/postgres"
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// 测试目标需求: 期望表结构与数据库当前结构的差异计算
+// 覆盖: 新建表、增删改列、仅在提示时重命名、索引与外键差异
+
+// currentUsers 模拟从数据库读取的 users 表结构
+func currentUsers() *schema.Table {
+	return &schema.Table{
+		Name: "users",
+		Columns: []*schema.Column{
+			{Name: "id", Type: schema.TypeBigInteger, IsUnsigned: true, IsAutoIncrement: true, IsPrimary: true},
+			{Name: "name", Type: schema.TypeString, Length: 100},
+			{Name: "email", Type: schema.TypeString, Length: 255},
+			{Name: "legacy", Type: schema.TypeText, IsNullable: true},
+		},
+		Indexes: []*schema.Index{
+			schema.NewIndex("email").Named("users_email_unique").Unique(),
+			schema.NewIndex("name").Named("users_name_idx"),
+		},
+		PrimaryKey: []string{"id"},
+	}
+}
+
+func TestCompare_CreateTable(t *testing.T) {
+	posts := schema.NewTable("posts")
+	posts.ID()
+
+	diffs := Compare(mysql.NewGrammar(), []*schema.Table{posts}, map[string]*schema.Table{})
+	if len(diffs) != 1 || diffs[0].Create != posts {
+		t.Fatalf("expected a create diff for posts, got %+v", diffs)
+	}
+}
+
+func TestCompare_UpToDate(t *testing.T) {
+	users := schema.NewTable("users")
+	users.ID()
+	users.String("name", 100)
+	users.String("email", 255).Unique()
+	users.Text("legacy").Nullable()
+	users.Index("name")
+
+	diffs := Compare(mysql.NewGrammar(), []*schema.Table{users}, map[string]*schema.Table{"users": currentUsers()})
+	if len(diffs) != 0 {
+		t.Errorf("expected no diff, got %+v", diffs[0])
+	}
+}
+
+func TestCompare_Columns(t *testing.T) {
+	users := schema.NewTable("users")
+	users.ID()
+	users.String("name", 200)
+	users.String("email_address", 255).RenamedFrom("email")
+	users.Boolean("active").Default(true)
+	users.Unique("email_address")
+
+	diffs := Compare(mysql.NewGrammar(), []*schema.Table{users}, map[string]*schema.Table{"users": currentUsers()})
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 diff, got %d", len(diffs))
+	}
+	d := diffs[0]
+
+	if len(d.AddColumns) != 1 || d.AddColumns[0].Name != "active" {
+		t.Errorf("expected active to be added, got %v", d.AddColumns)
+	}
+	if len(d.ChangeColumns) != 1 || d.ChangeColumns[0].From.Length != 100 || d.ChangeColumns[0].To.Length != 200 {
+		t.Errorf("expected name to change from 100 to 200, got %v", d.ChangeColumns)
+	}
+	if len(d.RenameColumns) != 1 || d.RenameColumns[0].From.Name != "email" || d.RenameColumns[0].To.Name != "email_address" {
+		t.Errorf("expected email to be renamed to email_address, got %v", d.RenameColumns)
+	}
+	if len(d.DropColumns) != 1 || d.DropColumns[0].Name != "legacy" {
+		t.Errorf("expected legacy to be dropped, got %v", d.DropColumns)
+	}
+
+	// The unique index follows the renamed column, the name index is no longer wanted
+	if len(d.AddIndexes) != 0 {
+		t.Errorf("expected no index to be added, got %v", d.AddIndexes)
+	}
+	if len(d.DropIndexes) != 1 || d.DropIndexes[0].Name != "users_name_idx" {
+		t.Errorf("expected users_name_idx to be dropped, got %v", d.DropIndexes)
+	}
+}
+
+func TestCompare_RenameRequiresHint(t *testing.T) {
+	users := schema.NewTable("users")
+	users.ID()
+	users.String("name", 100)
+	users.String("email_address", 255).Unique()
+	users.Text("legacy").Nullable()
+	users.Index("name")
+
+	diffs := Compare(mysql.NewGrammar(), []*schema.Table{users}, map[string]*schema.Table{"users": currentUsers()})
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 diff, got %d", len(diffs))
+	}
+	d := diffs[0]
+	if len(d.RenameColumns) != 0 {
+		t.Errorf("expected no rename without a hint, got %v", d.RenameColumns)
+	}
+	if len(d.AddColumns) != 1 || len(d.DropColumns) != 1 || d.DropColumns[0].Name != "email" {
+		t.Errorf("expected email_address to be added and email dropped, got +%v -%v", d.AddColumns, d.DropColumns)
+	}
+	if len(d.AddIndexes) != 1 || d.AddIndexes[0].Name != "users_email_address_unique" {
+		t.Errorf("expected a unique index on email_address, got %v", d.AddIndexes)
+	}
+}
+
+func TestCompare_ForeignKeys(t *testing.T) {
+	current := &schema.Table{
+		Name: "posts",
+		Columns: []*schema.Column{
+			{Name: "user_id", Type: schema.TypeBigInteger},
+			{Name: "team_id", Type: schema.TypeBigInteger},
+		},
+		// MySQL creates an index named after each foreign key
+		Indexes: []*schema.Index{schema.NewIndex("team_id").Named("posts_team_id_fk")},
+		ForeignKeys: []*schema.ForeignKey{
+			schema.NewForeignKey("team_id").Named("posts_team_id_fk").References("teams", "id"),
+		},
+	}
+
+	posts := schema.NewTable("posts")
+	posts.BigInteger("user_id")
+	posts.BigInteger("team_id")
+	posts.Foreign("user_id").References("users", "id").OnDeleteCascade()
+
+	diffs := Compare(mysql.NewGrammar(), []*schema.Table{posts}, map[string]*schema.Table{"posts": current})
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 diff, got %d", len(diffs))
+	}
+	d := diffs[0]
+	if len(d.AddForeignKeys) != 1 || d.AddForeignKeys[0].Name != "posts_user_id_fk" {
+		t.Errorf("expected posts_user_id_fk to be added, got %v", d.AddForeignKeys)
+	}
+	if len(d.DropForeignKeys) != 1 || d.DropForeignKeys[0].Name != "posts_team_id_fk" {
+		t.Errorf("expected posts_team_id_fk to be dropped, got %v", d.DropForeignKeys)
+	}
+	if len(d.DropIndexes) != 0 {
+		t.Errorf("expected the index of the foreign key to be ignored, got %v", d.DropIndexes)
+	}
+
+	t.Run("NO ACTION 等同于 RESTRICT", func(t *testing.T) {
+		current.ForeignKeys[0].OnDelete = schema.ActionNoAction
+		posts := schema.NewTable("posts")
+		posts.BigInteger("user_id")
+		posts.BigInteger("team_id")
+		posts.Foreign("team_id").References("teams", "id")
+
+		diffs := Compare(mysql.NewGrammar(), []*schema.Table{posts}, map[string]*schema.Table{"posts": current})
+		if len(diffs) != 0 {
+			t.Errorf("expected no diff, got %+v", diffs[0])
+		}
+	})
+}
+
+func TestCompare_Defaults(t *testing.T) {
+	current := &schema.Table{
+		Name: "posts",
+		Columns: []*schema.Column{
+			{Name: "status", Type: schema.TypeString, Length: 20, DefaultValue: "draft"},
+			{Name: "views", Type: schema.TypeInteger, DefaultValue: schema.Expression("0")},
+			{Name: "created_at", Type: schema.TypeTimestamp, DefaultValue: schema.Expression("CURRENT_TIMESTAMP")},
+		},
+	}
+
+	posts := schema.NewTable("posts")
+	posts.String("status", 20).Default("draft")
+	posts.Integer("views").Default(0)
+	posts.Timestamp("created_at").Default(schema.Expression("current_timestamp"))
+
+	diffs := Compare(mysql.NewGrammar(), []*schema.Table{posts}, map[string]*schema.Table{"posts": current})
+	if len(diffs) != 0 {
+		t.Errorf("expected inspected defaults to match the definitions, got %+v", diffs[0].ChangeColumns)
+	}
+}
+
+func TestCompare_IndexOptions(t *testing.T) {
+	// current 返回只有 email 列和给定索引的 users 表
+	current := func(indexes ...*schema.Index) map[string]*schema.Table {
+		return map[string]*schema.Table{"users": {
+			Name:    "users",
+			Columns: []*schema.Column{{Name: "email", Type: schema.TypeString, Length: 255}},
+			Indexes: indexes,
+		}}
+	}
+
+	tests := []struct {
+		name    string
+		current *schema.Index
+		desired func(*schema.Table)
+	}{
+		{"部分索引条件变化", schema.NewIndex("email").Named("users_email").Where("deleted_at IS NULL"),
+			func(t *schema.Table) { t.Index("email").Named("users_email") }},
+		{"索引方法变化", schema.NewIndex("email").Named("users_email"),
+			func(t *schema.Table) { t.Index("email").Named("users_email").Using("hash") }},
+		{"排序变化", schema.NewIndex("email").Named("users_email"),
+			func(t *schema.Table) { t.Index("email").Named("users_email").Desc("email") }},
+		{"前缀长度变化", schema.NewIndex("email").Named("users_email").Length("email", 10),
+			func(t *schema.Table) { t.Index("email").Named("users_email").Length("email", 20) }},
+		{"表达式变化", schema.NewIndex().Named("users_email_lower").Expression("lower(email)"),
+			func(t *schema.Table) { t.Index().Named("users_email_lower").Expression("upper(email)") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := schema.NewTable("users")
+			users.String("email", 255)
+			tt.desired(users)
+
+			diffs := Compare(mysql.NewGrammar(), []*schema.Table{users}, current(tt.current))
+			if len(diffs) != 1 {
+				t.Fatalf("expected 1 diff, got %d", len(diffs))
+			}
+			d := diffs[0]
+			if len(d.DropIndexes) != 1 || d.DropIndexes[0] != tt.current {
+				t.Errorf("expected the current index to be dropped, got %v", d.DropIndexes)
+			}
+			if len(d.AddIndexes) != 1 {
+				t.Errorf("expected the desired index to be added, got %v", d.AddIndexes)
+			}
+		})
+	}
+
+	t.Run("相同选项无差异", func(t *testing.T) {
+		users := schema.NewTable("users")
+		users.String("email", 255)
+		users.Index("email").Named("users_email").Desc("email").Where("deleted_at  IS NULL").Using("BTREE")
+		users.Index().Named("users_email_lower").Expression("LOWER(email)")
+
+		diffs := Compare(mysql.NewGrammar(), []*schema.Table{users}, current(
+			schema.NewIndex("email").Named("users_email").Desc("email").Where("deleted_at IS NULL"),
+			schema.NewIndex().Named("users_email_lower").Expression("lower(email)"),
+		))
+		if len(diffs) != 0 {
+			t.Errorf("expected no diff, got +%v -%v", diffs[0].AddIndexes, diffs[0].DropIndexes)
+		}
+	})
+}
+
+func TestCompare_Checks(t *testing.T) {
+	current := map[string]*schema.Table{"products": {
+		Name:    "products",
+		Columns: []*schema.Column{{Name: "price", Type: schema.TypeInteger}},
+		// 数据库返回的表达式带有括号和引号
+		Checks: []*schema.Check{
+			{Name: "products_price_check", Expression: "(`price` > 0)"},
+			{Name: "products_legacy_check", Expression: "price < 1000"},
+		},
+	}}
+
+	products := schema.NewTable("products")
+	products.Integer("price")
+	products.Check("products_price_check", "price > 0")
+	products.Check("products_stock_check", "price >= 0")
+
+	diffs := Compare(mysql.NewGrammar(), []*schema.Table{products}, current)
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 diff, got %d", len(diffs))
+	}
+	d := diffs[0]
+	if len(d.AddChecks) != 1 || d.AddChecks[0].Name != "products_stock_check" {
+		t.Errorf("expected products_stock_check to be added, got %v", d.AddChecks)
+	}
+	if len(d.DropChecks) != 1 || d.DropChecks[0].Name != "products_legacy_check" {
+		t.Errorf("expected products_legacy_check to be dropped, got %v", d.DropChecks)
+	}
+
+	t.Run("表达式变化时重建约束", func(t *testing.T) {
+		products := schema.NewTable("products")
+		products.Integer("price")
+		products.Check("products_price_check", "price > 10")
+		products.Check("products_legacy_check", "price < 1000")
+
+		diffs := Compare(mysql.NewGrammar(), []*schema.Table{products}, current)
+		if len(diffs) != 1 {
+			t.Fatalf("expected 1 diff, got %d", len(diffs))
+		}
+		d := diffs[0]
+		if len(d.DropChecks) != 1 || len(d.AddChecks) != 1 ||
+			d.DropChecks[0].Name != "products_price_check" || d.AddChecks[0].Expression != "price > 10" {
+			t.Errorf("expected products_price_check to be replaced, got +%v -%v", d.AddChecks, d.DropChecks)
+		}
+	})
+}
+
+func TestCompare_SchemaQualifiedTable(t *testing.T) {
+	// 数据库中的索引和外键名称不包含 schema
+	current := map[string]*schema.Table{"billing.invoices": {
+		Name: "billing.invoices",
+		Columns: []*schema.Column{
+			{Name: "email", Type: schema.TypeString, Length: 255},
+			{Name: "customer_id", Type: schema.TypeBigInteger},
+			{Name: "number", Type: schema.TypeString, Length: 50},
+		},
+		Indexes: []*schema.Index{schema.NewIndex("email").Named("invoices_email_unique").Unique()},
+	}}
+
+	invoices := schema.NewTable("billing.invoices")
+	invoices.String("email", 255).Unique()
+	invoices.BigInteger("customer_id")
+	invoices.String("number", 50)
+	invoices.Index("number")
+	invoices.Foreign("customer_id").References("billing.customers", "id")
+
+	diffs := Compare(postgres.NewGrammar(), []*schema.Table{invoices}, current)
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 diff, got %d", len(diffs))
+	}
+	d := diffs[0]
+	if len(d.AddIndexes) != 1 || d.AddIndexes[0].Name != "invoices_number_idx" {
+		t.Errorf("expected invoices_number_idx to be added, got %v", d.AddIndexes)
+	}
+	if len(d.AddForeignKeys) != 1 || d.AddForeignKeys[0].Name != "invoices_customer_id_fk" {
+		t.Errorf("expected invoices_customer_id_fk to be added, got %v", d.AddForeignKeys)
+	}
+	if len(d.DropIndexes) != 0 {
+		t.Errorf("expected no index to be dropped, got %v", d.DropIndexes)
+	}
+
+	if got := IndexName("billing.invoices", []string{"email"}, schema.IndexTypeUnique); got != "invoices_email_unique" {
+		t.Errorf("expected invoices_email_unique, got %s", got)
+	}
+	if got := ForeignKeyName("billing.invoices", []string{"customer_id"}); got != "invoices_customer_id_fk" {
+		t.Errorf("expected invoices_customer_id_fk, got %s", got)
+	}
+}
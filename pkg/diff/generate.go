@@ -0,0 +1,352 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// typeMethods maps column types to the name of their schema.Table methods
+var typeMethods = map[schema.ColumnType]string{
+	schema.TypeString:       "String",
+	schema.TypeText:         "Text",
+	schema.TypeInteger:      "Integer",
+	schema.TypeBigInteger:   "BigInteger",
+	schema.TypeSmallInteger: "SmallInteger",
+	schema.TypeTinyInteger:  "TinyInteger",
+	schema.TypeFloat:        "Float",
+	schema.TypeDouble:       "Double",
+	schema.TypeDecimal:      "Decimal",
+	schema.TypeBoolean:      "Boolean",
+	schema.TypeDate:         "Date",
+	schema.TypeDateTime:     "DateTime",
+	schema.TypeTimestamp:    "Timestamp",
+	schema.TypeTime:         "Time",
+	schema.TypeJSON:         "JSON",
+	schema.TypeBinary:       "Binary",
+	schema.TypeUUID:         "UUID",
+}
+
+// Generate returns the bodies of the Up and Down methods of a migration that
+// applies the diffs. Down undoes the tables in reverse order. Both bodies
+// end with "return nil".
+func Generate(diffs []*TableDiff) (up, down string) {
+	var upCode, downCode strings.Builder
+	for _, d := range diffs {
+		writeUp(&upCode, d)
+	}
+	for i := len(diffs) - 1; i >= 0; i-- {
+		writeDown(&downCode, diffs[i])
+	}
+	upCode.WriteString("\treturn nil\n")
+	downCode.WriteString("\treturn nil\n")
+	return upCode.String(), downCode.String()
+}
+
+func writeUp(w *strings.Builder, d *TableDiff) {
+	if d.Create != nil {
+		var lines []string
+		for _, col := range d.Create.Columns {
+			lines = append(lines, columnCall(col, false, true))
+		}
+		for _, idx := range d.Create.Indexes {
+			lines = append(lines, indexCall(d.Table, idx))
+		}
+		for _, fk := range d.Create.ForeignKeys {
+			lines = append(lines, foreignKeyCall(d.Table, fk))
+		}
+		for _, check := range d.Create.Checks {
+			lines = append(lines, checkCall(check))
+		}
+		writeTableCall(w, "CreateTable", d.Table, lines)
+		return
+	}
+
+	var lines []string
+	for _, rename := range d.RenameColumns {
+		lines = append(lines, fmt.Sprintf("t.RenameColumn(%q, %q)", rename.From.Name, rename.To.Name))
+	}
+	for _, check := range d.DropChecks {
+		lines = append(lines, fmt.Sprintf("t.DropCheck(%q)", check.Name))
+	}
+	for _, fk := range d.DropForeignKeys {
+		lines = append(lines, fmt.Sprintf("t.DropForeign(%q)", fk.Name))
+	}
+	for _, idx := range d.DropIndexes {
+		lines = append(lines, fmt.Sprintf("t.DropIndex(%q)", idx.Name))
+	}
+	for _, col := range d.DropColumns {
+		lines = append(lines, fmt.Sprintf("t.DropColumn(%q)", col.Name))
+	}
+	for _, col := range d.AddColumns {
+		lines = append(lines, columnCall(col, false, false))
+	}
+	for _, change := range d.ChangeColumns {
+		lines = append(lines, columnCall(change.To, true, false))
+	}
+	for _, idx := range d.AddIndexes {
+		lines = append(lines, indexCall(d.Table, idx))
+	}
+	for _, fk := range d.AddForeignKeys {
+		lines = append(lines, foreignKeyCall(d.Table, fk))
+	}
+	for _, check := range d.AddChecks {
+		lines = append(lines, checkCall(check))
+	}
+	writeTableCall(w, "AlterTable", d.Table, lines)
+}
+
+func writeDown(w *strings.Builder, d *TableDiff) {
+	if d.Create != nil {
+		fmt.Fprintf(w, "\tif err := e.DropTableIfExists(ctx, %q); err != nil {\n\t\treturn err\n\t}\n", d.Table)
+		return
+	}
+
+	var lines []string
+	for _, check := range d.AddChecks {
+		lines = append(lines, fmt.Sprintf("t.DropCheck(%q)", check.Name))
+	}
+	for _, fk := range d.AddForeignKeys {
+		lines = append(lines, fmt.Sprintf("t.DropForeign(%q)", fk.Name))
+	}
+	for _, idx := range d.AddIndexes {
+		lines = append(lines, fmt.Sprintf("t.DropIndex(%q)", idx.Name))
+	}
+	for _, col := range d.AddColumns {
+		lines = append(lines, fmt.Sprintf("t.DropColumn(%q)", col.Name))
+	}
+	for _, rename := range d.RenameColumns {
+		lines = append(lines, fmt.Sprintf("t.RenameColumn(%q, %q)", rename.To.Name, rename.From.Name))
+	}
+	for _, change := range d.ChangeColumns {
+		lines = append(lines, columnCall(change.From, true, false))
+	}
+	for _, col := range d.DropColumns {
+		lines = append(lines, columnCall(col, false, false))
+	}
+	for _, idx := range d.DropIndexes {
+		lines = append(lines, indexCall(d.Table, idx))
+	}
+	for _, fk := range d.DropForeignKeys {
+		lines = append(lines, foreignKeyCall(d.Table, fk))
+	}
+	for _, check := range d.DropChecks {
+		lines = append(lines, checkCall(check))
+	}
+	writeTableCall(w, "AlterTable", d.Table, lines)
+}
+
+// writeTableCall writes a CreateTable or AlterTable call that returns on error
+func writeTableCall(w *strings.Builder, method, table string, lines []string) {
+	fmt.Fprintf(w, "\tif err := e.%s(ctx, %q, func(t *schema.Table) {\n", method, table)
+	for _, line := range lines {
+		fmt.Fprintf(w, "\t\t%s\n", line)
+	}
+	w.WriteString("\t}); err != nil {\n\t\treturn err\n\t}\n")
+}
+
+// columnCall returns the schema.Table call that declares a column. Primary
+// and unique modifiers are only written when creating a table; when altering
+// one, unique columns are diffed as indexes.
+func columnCall(col *schema.Column, change, create bool) string {
+	if !change && isID(col) {
+		return "t.ID()"
+	}
+
+	prefix := ""
+	if change {
+		prefix = "Change"
+	}
+
+	var sb strings.Builder
+	switch col.Type {
+	case schema.TypeString:
+		length := col.Length
+		if length <= 0 {
+			length = 255
+		}
+		fmt.Fprintf(&sb, "t.%sString(%q, %d)", prefix, col.Name, length)
+	case schema.TypeDecimal:
+		fmt.Fprintf(&sb, "t.%sDecimal(%q, %d, %d)", prefix, col.Name, col.Precision, col.Scale)
+	case schema.TypeEnum:
+		fmt.Fprintf(&sb, "t.%sEnum(%q", prefix, col.Name)
+		for _, value := range col.Values {
+			fmt.Fprintf(&sb, ", %q", value)
+		}
+		sb.WriteString(")")
+	default:
+		fmt.Fprintf(&sb, "t.%s%s(%q)", prefix, typeMethods[col.Type], col.Name)
+	}
+
+	if col.IsUnsigned {
+		sb.WriteString(".Unsigned()")
+	}
+	if col.IsAutoIncrement {
+		sb.WriteString(".AutoIncrement()")
+	}
+	if create && col.IsPrimary {
+		sb.WriteString(".Primary()")
+	}
+	if create && col.IsUnique {
+		sb.WriteString(".Unique()")
+	}
+	if col.IsNullable {
+		sb.WriteString(".Nullable()")
+	}
+	if col.DefaultValue != nil {
+		fmt.Fprintf(&sb, ".Default(%s)", valueCode(col.DefaultValue))
+	}
+	if col.ColumnComment != "" {
+		fmt.Fprintf(&sb, ".Comment(%q)", col.ColumnComment)
+	}
+	switch {
+	case col.GeneratedAs != "" && col.IsStored:
+		fmt.Fprintf(&sb, ".StoredAs(%q)", col.GeneratedAs)
+	case col.GeneratedAs != "":
+		fmt.Fprintf(&sb, ".VirtualAs(%q)", col.GeneratedAs)
+	}
+	return sb.String()
+}
+
+// isID reports whether a column is the one declared by schema.Table.ID
+func isID(col *schema.Column) bool {
+	return col.Name == "id" && col.Type == schema.TypeBigInteger && col.IsAutoIncrement &&
+		col.IsPrimary && col.IsUnsigned && !col.IsNullable && col.DefaultValue == nil && col.ColumnComment == ""
+}
+
+// indexCall returns the schema.Table call that declares an index. The name
+// is only written when it differs from the generated one. Indexes whose keys
+// the fluent API cannot express are appended as a struct literal.
+func indexCall(table string, idx *schema.Index) string {
+	if !fluentKeys(idx) {
+		return fmt.Sprintf("t.Indexes = append(t.Indexes, &schema.Index{Name: %q, Type: %s, Columns: []string{%s}, "+
+			"Parts: %#v, Predicate: %q, Method: %q})",
+			idx.Name, indexTypeNames[idx.Type], quoteList(idx.Columns), idx.Parts, idx.Predicate, idx.Method)
+	}
+
+	cols := quoteList(idx.Columns)
+
+	var call string
+	switch idx.Type {
+	case schema.IndexTypeUnique:
+		call = fmt.Sprintf("t.Unique(%s)", cols)
+	case schema.IndexTypePrimary:
+		call = fmt.Sprintf("t.Primary(%s)", cols)
+	case schema.IndexTypeFulltext:
+		call = fmt.Sprintf("t.Index(%s).Fulltext()", cols)
+	default:
+		call = fmt.Sprintf("t.Index(%s)", cols)
+	}
+
+	if idx.Name != "" && idx.Name != IndexName(table, idx.Columns, idx.Type) {
+		call += fmt.Sprintf(".Named(%q)", idx.Name)
+	}
+	for _, part := range idx.Parts {
+		switch {
+		case part.Expression != "":
+			call += fmt.Sprintf(".Expression(%q)", part.Expression)
+		case part.Order == schema.OrderDesc:
+			call += fmt.Sprintf(".Desc(%q)", part.Column)
+		case part.Order == schema.OrderAsc:
+			call += fmt.Sprintf(".Asc(%q)", part.Column)
+		}
+		if part.Length > 0 {
+			call += fmt.Sprintf(".Length(%q, %d)", part.Column, part.Length)
+		}
+	}
+	if idx.Predicate != "" {
+		call += fmt.Sprintf(".Where(%q)", idx.Predicate)
+	}
+	if idx.Method != "" {
+		call += fmt.Sprintf(".Using(%q)", idx.Method)
+	}
+	return call
+}
+
+// indexTypeNames maps index types to their Go constant
+var indexTypeNames = map[schema.IndexType]string{
+	schema.IndexTypeIndex:    "schema.IndexTypeIndex",
+	schema.IndexTypeUnique:   "schema.IndexTypeUnique",
+	schema.IndexTypePrimary:  "schema.IndexTypePrimary",
+	schema.IndexTypeFulltext: "schema.IndexTypeFulltext",
+}
+
+// fluentKeys reports whether the fluent API can declare the keys of an
+// index: expressions follow the columns, which they cannot sort or prefix
+func fluentKeys(idx *schema.Index) bool {
+	expressions := false
+	for _, part := range idx.Parts {
+		if part.Expression == "" {
+			if expressions {
+				return false
+			}
+			continue
+		}
+		if part.Order != "" || part.Length > 0 {
+			return false
+		}
+		expressions = true
+	}
+	return true
+}
+
+// checkCall returns the schema.Table call that declares a CHECK constraint
+func checkCall(check *schema.Check) string {
+	return fmt.Sprintf("t.Check(%q, %q)", check.Name, check.Expression)
+}
+
+// foreignKeyCall returns the schema.Table call that declares a foreign key.
+// Keys that the fluent API cannot express are appended as a struct literal.
+func foreignKeyCall(table string, fk *schema.ForeignKey) string {
+	onDelete, deleteOK := actionMethod("OnDelete", fk.OnDelete)
+	onUpdate, updateOK := actionMethod("OnUpdate", fk.OnUpdate)
+	if len(fk.Columns) != 1 || !deleteOK || !updateOK {
+		return fmt.Sprintf("t.ForeignKeys = append(t.ForeignKeys, &schema.ForeignKey{Name: %q, Columns: []string{%s}, "+
+			"ReferenceTable: %q, ReferenceColumn: %q, OnDelete: %q, OnUpdate: %q})",
+			fk.Name, quoteList(fk.Columns), fk.ReferenceTable, fk.ReferenceColumn, fk.OnDelete, fk.OnUpdate)
+	}
+
+	call := fmt.Sprintf("t.Foreign(%q)", fk.Columns[0])
+	if fk.Name != "" && fk.Name != ForeignKeyName(table, fk.Columns) {
+		call += fmt.Sprintf(".Named(%q)", fk.Name)
+	}
+	call += fmt.Sprintf(".References(%q, %q)", fk.ReferenceTable, fk.ReferenceColumn)
+	return call + onDelete + onUpdate
+}
+
+// actionMethod returns the modifier setting a foreign key action, or false
+// when there is none
+func actionMethod(prefix string, action schema.ForeignKeyAction) (string, bool) {
+	switch normalizeAction(action) {
+	case schema.ActionRestrict:
+		return "", true
+	case schema.ActionCascade:
+		return "." + prefix + "Cascade()", true
+	case schema.ActionSetNull:
+		return "." + prefix + "SetNull()", true
+	default:
+		return "", false
+	}
+}
+
+// valueCode returns the Go literal of a default value
+func valueCode(value interface{}) string {
+	switch v := value.(type) {
+	case schema.Expression:
+		return fmt.Sprintf("schema.Expression(%q)", string(v))
+	case string:
+		return strconv.Quote(v)
+	default:
+		return fmt.Sprintf("%#v", v)
+	}
+}
+
+func quoteList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return strings.Join(quoted, ", ")
+}
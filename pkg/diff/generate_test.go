@@ -0,0 +1,215 @@
+package diff
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/flyits/migro/pkg/driver/postgres"
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// 测试目标：验证生成的 Up/Down 代码为合法 Go 代码，且 Down 为 Up 的逆操作
+
+// parseBody 将生成的方法体包装为函数并解析
+func parseBody(t *testing.T, body string) {
+	t.Helper()
+	src := "package migrations\n\nfunc f() error {\n" + body + "}\n"
+	if _, err := parser.ParseFile(token.NewFileSet(), "migration.go", src, 0); err != nil {
+		t.Fatalf("generated code is not valid Go: %v\n%s", err, body)
+	}
+}
+
+func assertContains(t *testing.T, code string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(code, w) {
+			t.Errorf("expected generated code to contain %q\n%s", w, code)
+		}
+	}
+}
+
+func TestGenerate_CreateTable(t *testing.T) {
+	posts := schema.NewTable("posts")
+	posts.ID()
+	posts.BigInteger("user_id").Unsigned()
+	posts.String("title", 200).Comment("Post title")
+	posts.Decimal("price", 10, 2).Default(0)
+	posts.Timestamp("published_at").Nullable().Default(schema.Expression("CURRENT_TIMESTAMP"))
+	posts.Enum("status", "draft", "published").Default("draft")
+	posts.Decimal("total", 10, 2).StoredAs("price * 2")
+	posts.Index("title")
+	posts.Unique("user_id", "title").Named("posts_user_title")
+	posts.Foreign("user_id").References("users", "id").OnDeleteCascade()
+
+	up, down := Generate([]*TableDiff{{Table: "posts", Create: posts}})
+	parseBody(t, up)
+	parseBody(t, down)
+
+	assertContains(t, up,
+		`e.CreateTable(ctx, "posts", func(t *schema.Table) {`,
+		`t.ID()`,
+		`t.BigInteger("user_id").Unsigned()`,
+		`t.String("title", 200).Comment("Post title")`,
+		`t.Decimal("price", 10, 2).Default(0)`,
+		`t.Timestamp("published_at").Nullable().Default(schema.Expression("CURRENT_TIMESTAMP"))`,
+		`t.Enum("status", "draft", "published").Default("draft")`,
+		`t.Decimal("total", 10, 2).StoredAs("price * 2")`,
+		`t.Index("title")`,
+		`t.Unique("user_id", "title").Named("posts_user_title")`,
+		`t.Foreign("user_id").References("users", "id").OnDeleteCascade()`,
+	)
+	if strings.Contains(up, `t.Index("title").Named`) {
+		t.Error("expected the generated index name to be omitted")
+	}
+	assertContains(t, down, `e.DropTableIfExists(ctx, "posts")`)
+}
+
+func TestGenerate_AlterTable(t *testing.T) {
+	d := &TableDiff{
+		Table:      "users",
+		AddColumns: []*schema.Column{{Name: "active", Type: schema.TypeBoolean, DefaultValue: true}},
+		ChangeColumns: []ColumnChange{{
+			From: &schema.Column{Name: "name", Type: schema.TypeString, Length: 100},
+			To:   &schema.Column{Name: "name", Type: schema.TypeString, Length: 200, IsNullable: true},
+		}},
+		RenameColumns: []ColumnChange{{
+			From: &schema.Column{Name: "email", Type: schema.TypeString, Length: 255},
+			To:   &schema.Column{Name: "email_address", Type: schema.TypeString, Length: 255},
+		}},
+		DropColumns:     []*schema.Column{{Name: "legacy", Type: schema.TypeText, IsNullable: true}},
+		AddIndexes:      []*schema.Index{schema.NewIndex("active").Named("users_active_idx")},
+		DropIndexes:     []*schema.Index{schema.NewIndex("legacy").Named("users_legacy_lookup")},
+		AddForeignKeys:  []*schema.ForeignKey{schema.NewForeignKey("team_id").Named("users_team_id_fk").References("teams", "id")},
+		DropForeignKeys: []*schema.ForeignKey{{Name: "users_org_fk", Columns: []string{"org_id", "team_id"}, ReferenceTable: "orgs", ReferenceColumn: "id, team_id", OnDelete: schema.ActionRestrict, OnUpdate: schema.ActionRestrict}},
+	}
+
+	up, down := Generate([]*TableDiff{d})
+	parseBody(t, up)
+	parseBody(t, down)
+
+	assertContains(t, up,
+		`e.AlterTable(ctx, "users", func(t *schema.Table) {`,
+		`t.RenameColumn("email", "email_address")`,
+		`t.DropForeign("users_org_fk")`,
+		`t.DropIndex("users_legacy_lookup")`,
+		`t.DropColumn("legacy")`,
+		`t.Boolean("active").Default(true)`,
+		`t.ChangeString("name", 200).Nullable()`,
+		`t.Index("active")`,
+		`t.Foreign("team_id").References("teams", "id")`,
+	)
+	assertContains(t, down,
+		`t.DropForeign("users_team_id_fk")`,
+		`t.DropIndex("users_active_idx")`,
+		`t.DropColumn("active")`,
+		`t.RenameColumn("email_address", "email")`,
+		`t.ChangeString("name", 100)`,
+		`t.Text("legacy").Nullable()`,
+		`t.Index("legacy").Named("users_legacy_lookup")`,
+		`t.ForeignKeys = append(t.ForeignKeys, &schema.ForeignKey{Name: "users_org_fk"`,
+	)
+}
+
+func TestGenerate_DownReversesTableOrder(t *testing.T) {
+	users := schema.NewTable("users")
+	users.ID()
+	posts := schema.NewTable("posts")
+	posts.ID()
+
+	_, down := Generate([]*TableDiff{{Table: "users", Create: users}, {Table: "posts", Create: posts}})
+	if strings.Index(down, `"posts"`) > strings.Index(down, `"users"`) {
+		t.Errorf("expected posts to be dropped before users\n%s", down)
+	}
+}
+
+func TestGenerate_IndexOptions(t *testing.T) {
+	d := &TableDiff{
+		Table: "users",
+		AddIndexes: []*schema.Index{
+			schema.NewIndex("email").Named("users_email_active").Unique().Where("deleted_at IS NULL"),
+			schema.NewIndex("name").Named("users_name_desc").Desc("name").Length("name", 10).Using("btree"),
+			schema.NewIndex("tenant_id").Named("users_tenant_email").Expression("lower(email)"),
+			// 表达式位于列之前，流式 API 无法表达
+			{Name: "users_email_tenant", Type: schema.IndexTypeIndex, Columns: []string{"tenant_id"},
+				Parts: []schema.IndexPart{{Expression: "lower(email)"}, {Column: "tenant_id"}}},
+		},
+	}
+
+	up, _ := Generate([]*TableDiff{d})
+	parseBody(t, up)
+
+	assertContains(t, up,
+		`t.Unique("email").Named("users_email_active").Where("deleted_at IS NULL")`,
+		`t.Index("name").Named("users_name_desc").Desc("name").Length("name", 10).Using("btree")`,
+		`t.Index("tenant_id").Named("users_tenant_email").Expression("lower(email)")`,
+		`t.Indexes = append(t.Indexes, &schema.Index{Name: "users_email_tenant", Type: schema.IndexTypeIndex`,
+	)
+}
+
+func TestGenerate_Checks(t *testing.T) {
+	d := &TableDiff{
+		Table:      "products",
+		AddChecks:  []*schema.Check{{Name: "products_price_check", Expression: "price > 0"}},
+		DropChecks: []*schema.Check{{Name: "products_legacy_check", Expression: "price < 1000"}},
+	}
+
+	up, down := Generate([]*TableDiff{d})
+	parseBody(t, up)
+	parseBody(t, down)
+
+	assertContains(t, up,
+		`t.DropCheck("products_legacy_check")`,
+		`t.Check("products_price_check", "price > 0")`,
+	)
+	assertContains(t, down,
+		`t.DropCheck("products_price_check")`,
+		`t.Check("products_legacy_check", "price < 1000")`,
+	)
+
+	t.Run("新建表", func(t *testing.T) {
+		products := schema.NewTable("products")
+		products.Integer("price")
+		products.Check("products_price_check", "price > 0")
+
+		up, _ := Generate([]*TableDiff{{Table: "products", Create: products}})
+		parseBody(t, up)
+		assertContains(t, up, `t.Check("products_price_check", "price > 0")`)
+	})
+}
+
+// 测试目标：schema 限定的表上已有的索引和外键不会被删除，生成的名称不包含 schema
+func TestGenerate_SchemaQualifiedTable(t *testing.T) {
+	current := map[string]*schema.Table{"billing.invoices": {
+		Name: "billing.invoices",
+		Columns: []*schema.Column{
+			{Name: "customer_id", Type: schema.TypeBigInteger},
+			{Name: "number", Type: schema.TypeString, Length: 50},
+		},
+		Indexes: []*schema.Index{schema.NewIndex("customer_id").Named("invoices_customer_id_idx")},
+		ForeignKeys: []*schema.ForeignKey{
+			schema.NewForeignKey("customer_id").Named("invoices_customer_id_fk").References("billing.customers", "id"),
+		},
+	}}
+
+	invoices := schema.NewTable("billing.invoices")
+	invoices.BigInteger("customer_id")
+	invoices.String("number", 50)
+	invoices.Index("customer_id")
+	invoices.Index("number")
+	invoices.Foreign("customer_id").References("billing.customers", "id")
+
+	up, down := Generate(Compare(postgres.NewGrammar(), []*schema.Table{invoices}, current))
+	parseBody(t, up)
+	parseBody(t, down)
+
+	if strings.Contains(up, "Drop") {
+		t.Errorf("expected no drop in up\n%s", up)
+	}
+	if strings.Contains(up+down, "billing.invoices_") {
+		t.Errorf("expected names without the schema\n%s\n%s", up, down)
+	}
+	assertContains(t, up, `t.Index("number")`)
+	assertContains(t, down, `t.DropIndex("invoices_number_idx")`)
+}
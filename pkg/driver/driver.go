@@ -8,12 +8,29 @@ import (
 	"github.com/flyits/migro/pkg/schema"
 )
 
-// MigrationRecord represents a record in the migrations table
+// MigrationRecord represents a record in the migrations table.
+// The metadata fields are empty for records created before they were added.
 type MigrationRecord struct {
 	ID         int64
 	Migration  string
 	Batch      int
 	ExecutedAt time.Time
+	Checksum   string        // SHA-256 of the migration SQL, empty for records created before checksums
+	Duration   time.Duration // how long the migration took, stored in milliseconds
+	Host       string        // host name of the machine that ran the migration
+	Version    string        // migro version that ran the migration
+	User       string        // operating system user that ran the migration
+	Note       string        // optional note of the operator
+}
+
+// InsertArgs returns the arguments of the statement compiled by
+// Grammar.CompileInsertMigration, in the order of its columns:
+// migration, batch, checksum, duration_ms, host, migro_version, os_user, note
+func (r MigrationRecord) InsertArgs() []interface{} {
+	return []interface{}{
+		r.Migration, r.Batch, r.Checksum, r.Duration.Milliseconds(),
+		r.Host, r.Version, r.User, r.Note,
+	}
 }
 
 // Config holds database connection configuration
@@ -25,7 +42,9 @@ type Config struct {
 	Username string
 	Password string
 	Charset  string
+	Schema   string // PostgreSQL search_path, e.g. "billing" or "billing, public"
 	Options  map[string]string
+	TLS      TLSConfig
 }
 
 // Transaction represents a database transaction
@@ -36,6 +55,57 @@ type Transaction interface {
 	Rollback() error
 }
 
+// Queryer runs read queries. Both Driver and Transaction implement it, so
+// schema reads can run on the transaction a migration executes in.
+type Queryer interface {
+	Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// AlterCompiler is implemented by drivers whose ALTER TABLE statements depend
+// on the current table definition, such as SQLite which rebuilds the table for
+// operations it cannot perform in place. The definition is read through q.
+type AlterCompiler interface {
+	CompileAlterTable(ctx context.Context, q Queryer, table *schema.Table) ([]string, error)
+}
+
+// SchemaCompiler is implemented by the grammars of databases with schemas
+// (namespaces), such as PostgreSQL. Tables of a schema are named
+// schema.table, e.g. billing.invoices.
+type SchemaCompiler interface {
+	CompileCreateSchema(name string) string
+	CompileDropSchema(name string) string
+}
+
+// TypeCompiler is implemented by the grammars of databases that keep the
+// type of some columns as a separate object, such as the enum types of
+// PostgreSQL
+type TypeCompiler interface {
+	// CompileCreateTypes returns the statements creating the types of the
+	// columns of a new table, run before CompileCreate
+	CompileCreateTypes(table *schema.Table) []string
+
+	// CompileAlterTypes returns the statements updating the types of the
+	// changed columns of a table, run before CompileAlter. The migrator runs
+	// them on the connection before the transaction of the migration begins,
+	// so that the transaction can use the new values.
+	CompileAlterTypes(table *schema.Table) []string
+
+	// CompileDropTypes returns the statements dropping the types created for
+	// a table, run after CompileDrop and CompileDropIfExists
+	CompileDropTypes(table string) []string
+
+	// CompileRenameTypes returns the statements renaming the types created
+	// for a table, run after CompileRename
+	CompileRenameTypes(from, to string) []string
+}
+
+// TableValidator is implemented by grammars that cannot compile some table
+// definitions, such as virtual generated columns on PostgreSQL. The executor
+// validates tables before compiling CreateTable and AlterTable.
+type TableValidator interface {
+	ValidateTable(table *schema.Table) error
+}
+
 // Grammar defines the interface for SQL dialect generation
 type Grammar interface {
 	// Table operations
@@ -110,7 +180,7 @@ type Driver interface {
 	// Migration history
 	CreateMigrationsTable(ctx context.Context, tableName string) error
 	GetExecutedMigrations(ctx context.Context, tableName string) ([]MigrationRecord, error)
-	RecordMigration(ctx context.Context, tableName, migration string, batch int) error
+	RecordMigration(ctx context.Context, tableName string, record MigrationRecord) error
 	DeleteMigration(ctx context.Context, tableName, migration string) error
 	GetLastBatch(ctx context.Context, tableName string) (int, error)
 
@@ -0,0 +1,72 @@
+package driver
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// SchemaDumper is implemented by drivers that can dump the schema of the
+// database as the DDL statements recreating it. The excluded tables, such as
+// the migrations table, are left out. Statements carry no trailing semicolon.
+type SchemaDumper interface {
+	DumpSchema(ctx context.Context, q Queryer, exclude ...string) ([]string, error)
+}
+
+// SortByDependencies orders tables so that the tables referenced by foreign
+// keys come before the tables referencing them. Tables keep their relative
+// order otherwise. Tables in a reference cycle are appended in their
+// original order.
+func SortByDependencies(tables []*schema.Table) []*schema.Table {
+	index := make(map[string]int, len(tables))
+	for i, table := range tables {
+		index[strings.ToLower(table.Name)] = i
+	}
+
+	// dependents[i] lists the tables referencing table i
+	dependents := make([][]int, len(tables))
+	pending := make([]int, len(tables))
+	for i, table := range tables {
+		seen := make(map[int]bool)
+		for _, fk := range table.ForeignKeys {
+			j, ok := index[strings.ToLower(fk.ReferenceTable)]
+			if !ok || j == i || seen[j] {
+				continue
+			}
+			seen[j] = true
+			dependents[j] = append(dependents[j], i)
+			pending[i]++
+		}
+	}
+
+	var ready []int
+	for i := range tables {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	sorted := make([]*schema.Table, 0, len(tables))
+	placed := make([]bool, len(tables))
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		i := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, tables[i])
+		placed[i] = true
+		for _, j := range dependents[i] {
+			if pending[j]--; pending[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+
+	for i, table := range tables {
+		if !placed[i] {
+			sorted = append(sorted, table)
+		}
+	}
+	return sorted
+}
@@ -0,0 +1,68 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// 测试目标：验证按外键依赖排序表，被引用的表在前，循环引用保持原顺序
+
+func tableNames(tables []*schema.Table) []string {
+	names := make([]string, len(tables))
+	for i, table := range tables {
+		names[i] = table.Name
+	}
+	return names
+}
+
+func TestSortByDependencies(t *testing.T) {
+	table := func(name string, references ...string) *schema.Table {
+		tbl := &schema.Table{Name: name}
+		for _, ref := range references {
+			tbl.ForeignKeys = append(tbl.ForeignKeys, schema.NewForeignKey(ref+"_id").References(ref, "id"))
+		}
+		return tbl
+	}
+
+	tests := []struct {
+		name   string
+		tables []*schema.Table
+		want   []string
+	}{
+		{
+			name:   "被引用的表在前",
+			tables: []*schema.Table{table("comments", "posts", "users"), table("posts", "users"), table("users")},
+			want:   []string{"users", "posts", "comments"},
+		},
+		{
+			name:   "无依赖保持原顺序",
+			tables: []*schema.Table{table("b"), table("a"), table("c")},
+			want:   []string{"b", "a", "c"},
+		},
+		{
+			name:   "自引用与外部表",
+			tables: []*schema.Table{table("categories", "categories", "external")},
+			want:   []string{"categories"},
+		},
+		{
+			name:   "循环引用追加在后",
+			tables: []*schema.Table{table("a", "b"), table("b", "a"), table("c")},
+			want:   []string{"c", "a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tableNames(SortByDependencies(tt.tables))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
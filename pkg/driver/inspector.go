@@ -0,0 +1,148 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// ErrTableNotFound is returned by InspectTable when the table does not exist
+var ErrTableNotFound = errors.New("driver: table not found")
+
+// Inspector is implemented by drivers that can read the current schema of the
+// database. Reads go through q, so they can run on the transaction a
+// migration executes in.
+type Inspector interface {
+	// GetTables returns the names of the tables in the database, sorted by name
+	GetTables(ctx context.Context, q Queryer) ([]string, error)
+
+	// GetColumns returns the columns of a table in declaration order, with
+	// their type, nullability, default and comment. Defaults are returned as
+	// a string for string literals and as a schema.Expression otherwise.
+	// It returns no columns when the table does not exist.
+	GetColumns(ctx context.Context, q Queryer, table string) ([]*schema.Column, error)
+
+	// GetIndexes returns the indexes of a table, including the primary key
+	// as an index of type schema.IndexTypePrimary
+	GetIndexes(ctx context.Context, q Queryer, table string) ([]*schema.Index, error)
+
+	// GetForeignKeys returns the foreign keys of a table
+	GetForeignKeys(ctx context.Context, q Queryer, table string) ([]*schema.ForeignKey, error)
+}
+
+// CheckInspector is implemented by inspectors that can read the CHECK
+// constraints of a table. InspectTable fills schema.Table.Checks with them.
+type CheckInspector interface {
+	// GetChecks returns the named CHECK constraints of a table, sorted by name
+	GetChecks(ctx context.Context, q Queryer, table string) ([]*schema.Check, error)
+}
+
+// InspectTable reads the definition of a table. The primary key is returned
+// in PrimaryKey and by marking its columns as primary; Indexes holds the
+// other indexes. Checks are read from inspectors implementing CheckInspector.
+func InspectTable(ctx context.Context, ins Inspector, q Queryer, name string) (*schema.Table, error) {
+	columns, err := ins.GetColumns(ctx, q, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, name)
+	}
+
+	indexes, err := ins.GetIndexes(ctx, q, name)
+	if err != nil {
+		return nil, err
+	}
+	foreignKeys, err := ins.GetForeignKeys(ctx, q, name)
+	if err != nil {
+		return nil, err
+	}
+
+	table := schema.NewTable(name)
+	table.Columns = columns
+	table.ForeignKeys = foreignKeys
+	if checker, ok := ins.(CheckInspector); ok {
+		if table.Checks, err = checker.GetChecks(ctx, q, name); err != nil {
+			return nil, err
+		}
+	}
+	for _, idx := range indexes {
+		if idx.Type != schema.IndexTypePrimary {
+			table.Indexes = append(table.Indexes, idx)
+			continue
+		}
+		table.PrimaryKey = idx.Columns
+		for _, col := range columns {
+			for _, primary := range idx.Columns {
+				if col.Name == primary {
+					col.IsPrimary = true
+				}
+			}
+		}
+	}
+	return table, nil
+}
+
+// InspectSchema reads the definitions of all tables in the database except
+// the excluded ones, sorted by name
+func InspectSchema(ctx context.Context, ins Inspector, q Queryer, exclude ...string) ([]*schema.Table, error) {
+	names, err := ins.GetTables(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	skip := make(map[string]bool, len(exclude))
+	for _, name := range exclude {
+		skip[name] = true
+	}
+
+	tables := make([]*schema.Table, 0, len(names))
+	for _, name := range names {
+		if skip[name] {
+			continue
+		}
+		table, err := InspectTable(ctx, ins, q, name)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// TrimParens removes the parentheses enclosing a whole expression, as
+// databases report CHECK expressions such as ((price > 0))
+func TrimParens(expr string) string {
+	expr = strings.TrimSpace(expr)
+	for len(expr) >= 2 && expr[0] == '(' && matchingParen(expr) == len(expr)-1 {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	return expr
+}
+
+// matchingParen returns the index of the parenthesis closing the one that
+// starts expr, skipping quoted strings and identifiers, or -1
+func matchingParen(expr string) int {
+	depth := 0
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; c {
+		case '\'', '"', '`':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return -1
+			}
+			i += end + 1
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
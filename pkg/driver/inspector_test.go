@@ -0,0 +1,153 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// 测试目标：验证 InspectTable/InspectSchema 将 Inspector 的结果组装为 schema.Table
+
+// fakeInspector 返回预置表结构的 Inspector
+type fakeInspector struct {
+	columns     map[string][]*schema.Column
+	indexes     map[string][]*schema.Index
+	foreignKeys map[string][]*schema.ForeignKey
+}
+
+func (f *fakeInspector) GetTables(ctx context.Context, q Queryer) ([]string, error) {
+	return []string{"posts", "users"}, nil
+}
+
+func (f *fakeInspector) GetColumns(ctx context.Context, q Queryer, table string) ([]*schema.Column, error) {
+	return f.columns[table], nil
+}
+
+func (f *fakeInspector) GetIndexes(ctx context.Context, q Queryer, table string) ([]*schema.Index, error) {
+	return f.indexes[table], nil
+}
+
+func (f *fakeInspector) GetForeignKeys(ctx context.Context, q Queryer, table string) ([]*schema.ForeignKey, error) {
+	return f.foreignKeys[table], nil
+}
+
+func newFakeInspector() *fakeInspector {
+	return &fakeInspector{
+		columns: map[string][]*schema.Column{
+			"users": {
+				{Name: "id", Type: schema.TypeBigInteger, IsAutoIncrement: true},
+				{Name: "email", Type: schema.TypeString, Length: 255},
+			},
+			"posts": {
+				{Name: "id", Type: schema.TypeBigInteger},
+				{Name: "user_id", Type: schema.TypeBigInteger},
+			},
+		},
+		indexes: map[string][]*schema.Index{
+			"users": {
+				schema.NewIndex("id").Named("PRIMARY").Primary(),
+				schema.NewIndex("email").Named("users_email_unique").Unique(),
+			},
+		},
+		foreignKeys: map[string][]*schema.ForeignKey{
+			"posts": {schema.NewForeignKey("user_id").Named("posts_user_id_fk").References("users", "id")},
+		},
+	}
+}
+
+func TestInspectTable(t *testing.T) {
+	ctx := context.Background()
+	ins := newFakeInspector()
+
+	table, err := InspectTable(ctx, ins, nil, "users")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if table.Name != "users" || len(table.Columns) != 2 {
+		t.Fatalf("unexpected table %q with %d columns", table.Name, len(table.Columns))
+	}
+	if len(table.PrimaryKey) != 1 || table.PrimaryKey[0] != "id" {
+		t.Errorf("expected primary key [id], got %v", table.PrimaryKey)
+	}
+	if !table.Columns[0].IsPrimary || table.Columns[1].IsPrimary {
+		t.Error("expected only the id column to be marked as primary")
+	}
+	if len(table.Indexes) != 1 || table.Indexes[0].Name != "users_email_unique" {
+		t.Errorf("expected the primary key to be left out of the indexes, got %v", table.Indexes)
+	}
+
+	t.Run("外键", func(t *testing.T) {
+		table, err := InspectTable(ctx, ins, nil, "posts")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(table.ForeignKeys) != 1 || table.ForeignKeys[0].ReferenceTable != "users" {
+			t.Errorf("unexpected foreign keys %v", table.ForeignKeys)
+		}
+	})
+
+	t.Run("表不存在", func(t *testing.T) {
+		_, err := InspectTable(ctx, ins, nil, "missing")
+		if !errors.Is(err, ErrTableNotFound) {
+			t.Errorf("expected ErrTableNotFound, got %v", err)
+		}
+	})
+}
+
+func TestInspectSchema(t *testing.T) {
+	tables, err := InspectSchema(context.Background(), newFakeInspector(), nil, "posts")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tables) != 1 || tables[0].Name != "users" {
+		t.Errorf("expected only users to be inspected, got %d tables", len(tables))
+	}
+}
+
+// checkInspector 在 fakeInspector 基础上实现 CheckInspector
+type checkInspector struct {
+	*fakeInspector
+	checks map[string][]*schema.Check
+}
+
+func (c *checkInspector) GetChecks(ctx context.Context, q Queryer, table string) ([]*schema.Check, error) {
+	return c.checks[table], nil
+}
+
+func TestInspectTable_Checks(t *testing.T) {
+	ins := &checkInspector{
+		fakeInspector: newFakeInspector(),
+		checks:        map[string][]*schema.Check{"users": {{Name: "users_email_check", Expression: "email <> ''"}}},
+	}
+
+	table, err := InspectTable(context.Background(), ins, nil, "users")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(table.Checks) != 1 || table.Checks[0].Name != "users_email_check" {
+		t.Errorf("expected the checks of the inspector, got %v", table.Checks)
+	}
+}
+
+func TestTrimParens(t *testing.T) {
+	tests := []struct {
+		expr, expected string
+	}{
+		{"price > 0", "price > 0"},
+		{"(price > 0)", "price > 0"},
+		{"((price > 0))", "price > 0"},
+		{"(a > 0) AND (b > 0)", "(a > 0) AND (b > 0)"},
+		{"(status <> ')')", "status <> ')'"},
+		{"(`a)` > 0)", "`a)` > 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := TrimParens(tt.expr); got != tt.expected {
+				t.Errorf("TrimParens(%q) = %q, want %q", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
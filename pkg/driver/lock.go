@@ -0,0 +1,56 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLockTimeout is returned by Locker.Lock when the lock could not be
+// acquired before the timeout expired
+var ErrLockTimeout = errors.New("driver: timed out waiting for migration lock")
+
+// Locker is implemented by drivers that can serialize migration runs across
+// processes with a database-level lock. The name identifies the lock and is
+// derived from the migrations table name.
+type Locker interface {
+	// Lock acquires the lock, waiting at most timeout. A negative timeout waits forever.
+	Lock(ctx context.Context, name string, timeout time.Duration) error
+
+	// Unlock releases a lock acquired by Lock
+	Unlock(ctx context.Context, name string) error
+
+	// ForceUnlock releases the lock regardless of which session holds it
+	ForceUnlock(ctx context.Context, name string) error
+}
+
+// lockPollInterval is the delay between two attempts of PollLock
+const lockPollInterval = 500 * time.Millisecond
+
+// PollLock calls try until it reports that the lock was acquired, the
+// timeout expires or the context is cancelled. A negative timeout waits forever.
+func PollLock(ctx context.Context, timeout time.Duration, try func() (bool, error)) error {
+	var deadline time.Time
+	if timeout >= 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	for {
+		acquired, err := try()
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			return ErrLockTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
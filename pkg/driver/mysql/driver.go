@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"net"
+	"strconv"
+	"time"
 
 	"github.com/flyits/migro/pkg/driver"
 	"github.com/flyits/migro/pkg/schema"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
 func init() {
@@ -21,6 +24,7 @@ type Driver struct {
 	db             *sql.DB
 	grammar        *Grammar
 	ownsConnection bool
+	lockConn       *sql.Conn // connection holding the migration lock
 }
 
 // NewDriver creates a new MySQL driver instance
@@ -32,18 +36,9 @@ func NewDriver() *Driver {
 
 // Connect establishes a connection to the MySQL database
 func (d *Driver) Connect(config *driver.Config) error {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=true",
-		config.Username,
-		config.Password,
-		config.Host,
-		config.Port,
-		config.Database,
-		config.Charset,
-	)
-
-	// Add additional options
-	for key, value := range config.Options {
-		dsn += "&" + key + "=" + value
+	dsn, err := buildDSN(config)
+	if err != nil {
+		return fmt.Errorf("mysql: %w", err)
 	}
 
 	db, err := sql.Open("mysql", dsn)
@@ -61,6 +56,58 @@ func (d *Driver) Connect(config *driver.Config) error {
 	return nil
 }
 
+// buildDSN returns the data source name of config, registering its TLS
+// configuration with the MySQL driver. The options take precedence over the
+// charset, parseTime and tls parameters.
+func buildDSN(config *driver.Config) (string, error) {
+	params := map[string]string{"parseTime": "true"}
+	if config.Charset != "" {
+		params["charset"] = config.Charset
+	}
+	tlsParam, err := registerTLS(config.TLS)
+	if err != nil {
+		return "", err
+	}
+	if tlsParam != "" {
+		params["tls"] = tlsParam
+	}
+	for key, value := range config.Options {
+		params[key] = value
+	}
+
+	// FormatDSN escapes the database name and the parameter values, the
+	// password is found by the driver before the last '@' and '/'
+	cfg := mysql.NewConfig()
+	cfg.User = config.Username
+	cfg.Passwd = config.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	cfg.DBName = config.Database
+	cfg.Params = params
+	return cfg.FormatDSN(), nil
+}
+
+// registerTLS registers the TLS settings with the MySQL driver and returns
+// the value of the tls parameter, empty when TLS is not configured
+func registerTLS(settings driver.TLSConfig) (string, error) {
+	tlsConfig, err := settings.ClientConfig()
+	if err != nil {
+		return "", err
+	}
+	if tlsConfig == nil {
+		if settings.EffectiveMode() == driver.TLSDisable {
+			return "false", nil
+		}
+		return "", nil
+	}
+
+	name := settings.Key()
+	if err := mysql.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return "", fmt.Errorf("failed to register TLS config: %w", err)
+	}
+	return name, nil
+}
+
 // Close closes the database connection
 func (d *Driver) Close() error {
 	if d.db != nil && d.ownsConnection {
@@ -186,6 +233,24 @@ func (d *Driver) CreateMigrationsTable(ctx context.Context, tableName string) er
 	if err != nil {
 		return fmt.Errorf("mysql: failed to create migrations table: %w", err)
 	}
+	return d.upgradeMigrationsTable(ctx, tableName)
+}
+
+// upgradeMigrationsTable adds the columns missing from a migrations table
+// created by an older version
+func (d *Driver) upgradeMigrationsTable(ctx context.Context, tableName string) error {
+	for _, col := range d.grammar.migrationsTableUpgrades() {
+		var count int
+		if err := d.db.QueryRowContext(ctx, d.grammar.compileHasColumn(), tableName, col.name).Scan(&count); err != nil {
+			return fmt.Errorf("mysql: failed to inspect migrations table: %w", err)
+		}
+		if count > 0 {
+			continue
+		}
+		if _, err := d.db.ExecContext(ctx, d.grammar.compileAddMigrationsColumn(tableName, col)); err != nil {
+			return fmt.Errorf("mysql: failed to add column %s to migrations table: %w", col.name, err)
+		}
+	}
 	return nil
 }
 
@@ -201,9 +266,12 @@ func (d *Driver) GetExecutedMigrations(ctx context.Context, tableName string) ([
 	var records []driver.MigrationRecord
 	for rows.Next() {
 		var r driver.MigrationRecord
-		if err := rows.Scan(&r.ID, &r.Migration, &r.Batch, &r.ExecutedAt); err != nil {
+		var durationMS int64
+		if err := rows.Scan(&r.ID, &r.Migration, &r.Batch, &r.ExecutedAt, &r.Checksum,
+			&durationMS, &r.Host, &r.Version, &r.User, &r.Note); err != nil {
 			return nil, fmt.Errorf("mysql: failed to scan migration record: %w", err)
 		}
+		r.Duration = time.Duration(durationMS) * time.Millisecond
 		records = append(records, r)
 	}
 
@@ -215,9 +283,9 @@ func (d *Driver) GetExecutedMigrations(ctx context.Context, tableName string) ([
 }
 
 // RecordMigration records a migration execution
-func (d *Driver) RecordMigration(ctx context.Context, tableName, migration string, batch int) error {
+func (d *Driver) RecordMigration(ctx context.Context, tableName string, record driver.MigrationRecord) error {
 	sql := d.grammar.CompileInsertMigration(tableName)
-	_, err := d.db.ExecContext(ctx, sql, migration, batch)
+	_, err := d.db.ExecContext(ctx, sql, record.InsertArgs()...)
 	if err != nil {
 		return fmt.Errorf("mysql: failed to record migration: %w", err)
 	}
@@ -0,0 +1,84 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/flyits/migro/pkg/driver"
+	"github.com/go-sql-driver/mysql"
+)
+
+// 测试目标：验证连接选项覆盖 Connect 的默认参数，以及 TLS 配置的注册
+func TestBuildDSN(t *testing.T) {
+	config := &driver.Config{
+		Host:     "db",
+		Port:     3306,
+		Database: "shop",
+		Username: "root",
+		Password: "secret",
+		Charset:  "utf8mb4",
+	}
+	assertDSN := func(want string) {
+		t.Helper()
+		got, err := buildDSN(config)
+		if err != nil {
+			t.Fatalf("buildDSN failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	}
+
+	assertDSN("root:secret@tcp(db:3306)/shop?charset=utf8mb4&parseTime=true")
+
+	config.Options = map[string]string{"parseTime": "false", "tls": "true", "charset": "latin1"}
+	assertDSN("root:secret@tcp(db:3306)/shop?charset=latin1&parseTime=false&tls=true")
+
+	config.Options = nil
+	config.TLS = driver.TLSConfig{Mode: driver.TLSDisable}
+	assertDSN("root:secret@tcp(db:3306)/shop?charset=utf8mb4&parseTime=true&tls=false")
+
+	config.TLS = driver.TLSConfig{Mode: driver.TLSRequire, ServerName: "db.internal"}
+	assertDSN("root:secret@tcp(db:3306)/shop?charset=utf8mb4&parseTime=true&tls=" + config.TLS.Key())
+
+	config.TLS = driver.TLSConfig{Mode: "prefer"}
+	if _, err := buildDSN(config); err == nil {
+		t.Error("expected an error for an unsupported TLS mode")
+	}
+}
+
+// 测试目标：验证包含特殊字符的密码和选项值在 DSN 中被正确解析
+func TestBuildDSN_SpecialCharacters(t *testing.T) {
+	passwords := []string{"p@ss", "a/b", "what?", "p@ss/w:rd?x=1&y", "100%+sure"}
+	for _, password := range passwords {
+		t.Run(password, func(t *testing.T) {
+			config := &driver.Config{
+				Host:     "db",
+				Port:     3306,
+				Database: "shop",
+				Username: "app",
+				Password: password,
+				Options:  map[string]string{"sql_mode": "'ANSI_QUOTES,TRADITIONAL'", "init": "a&b=c%d+e"},
+			}
+			dsn, err := buildDSN(config)
+			if err != nil {
+				t.Fatalf("buildDSN failed: %v", err)
+			}
+
+			parsed, err := mysql.ParseDSN(dsn)
+			if err != nil {
+				t.Fatalf("ParseDSN(%q) failed: %v", dsn, err)
+			}
+			if parsed.User != "app" || parsed.Passwd != password {
+				t.Errorf("expected app/%q, got %q/%q", password, parsed.User, parsed.Passwd)
+			}
+			if parsed.Addr != "db:3306" || parsed.DBName != "shop" {
+				t.Errorf("unexpected address %q or database %q", parsed.Addr, parsed.DBName)
+			}
+			for key, value := range config.Options {
+				if parsed.Params[key] != value {
+					t.Errorf("expected option %s=%q, got %q", key, value, parsed.Params[key])
+				}
+			}
+		})
+	}
+}
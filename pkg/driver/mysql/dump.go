@@ -0,0 +1,63 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+
+	"github.com/flyits/migro/pkg/driver"
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// Ensure Driver implements driver.SchemaDumper
+var _ driver.SchemaDumper = (*Driver)(nil)
+
+// autoIncrementOption matches the AUTO_INCREMENT counter of SHOW CREATE TABLE
+var autoIncrementOption = regexp.MustCompile(`\s+AUTO_INCREMENT=\d+`)
+
+// DumpSchema returns the SHOW CREATE TABLE statements of the tables, with
+// referenced tables first and without their AUTO_INCREMENT counters
+func (d *Driver) DumpSchema(ctx context.Context, q driver.Queryer, exclude ...string) ([]string, error) {
+	names, err := d.GetTables(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	skip := make(map[string]bool, len(exclude))
+	for _, name := range exclude {
+		skip[name] = true
+	}
+
+	var tables []*schema.Table
+	for _, name := range names {
+		if skip[name] {
+			continue
+		}
+		fks, err := d.GetForeignKeys(ctx, q, name)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, &schema.Table{Name: name, ForeignKeys: fks})
+	}
+
+	statements := make([]string, 0, len(tables))
+	for _, table := range driver.SortByDependencies(tables) {
+		var stmt string
+		err := queryEach(ctx, q, func(rows *sql.Rows) error {
+			var name string
+			return rows.Scan(&name, &stmt)
+		}, d.grammar.compileShowCreateTable(table.Name))
+		if err != nil {
+			return nil, fmt.Errorf("mysql: failed to dump table %s: %w", table.Name, err)
+		}
+		statements = append(statements, stripAutoIncrement(stmt))
+	}
+	return statements, nil
+}
+
+// stripAutoIncrement removes the AUTO_INCREMENT counter from a CREATE TABLE
+// statement so that the dump does not change with the data
+func stripAutoIncrement(stmt string) string {
+	return autoIncrementOption.ReplaceAllString(stmt, "")
+}
@@ -0,0 +1,12 @@
+package mysql
+
+import "testing"
+
+// 测试目标：验证导出的建表语句去掉 AUTO_INCREMENT 计数
+func TestStripAutoIncrement(t *testing.T) {
+	stmt := "CREATE TABLE `users` (\n  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=utf8mb4"
+	want := "CREATE TABLE `users` (\n  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+	if got := stripAutoIncrement(stmt); got != want {
+		t.Errorf("stripAutoIncrement() = %q, want %q", got, want)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/flyits/migro/pkg/driver"
 	"github.com/flyits/migro/pkg/schema"
 )
 
@@ -26,6 +27,9 @@ func validateIdentifier(name string) error {
 	return nil
 }
 
+// Ensure Grammar implements driver.TableValidator
+var _ driver.TableValidator = (*Grammar)(nil)
+
 // Grammar implements the MySQL SQL dialect
 type Grammar struct{}
 
@@ -74,6 +78,11 @@ func (g *Grammar) CompileCreate(table *schema.Table) string {
 		columns = append(columns, "  "+g.compileForeignKeyInline(fk))
 	}
 
+	// Check constraints
+	for _, check := range table.Checks {
+		columns = append(columns, "  "+g.compileCheckInline(check))
+	}
+
 	sb.WriteString(strings.Join(columns, ",\n"))
 	sb.WriteString("\n)")
 
@@ -104,6 +113,11 @@ func (g *Grammar) CompileAlter(table *schema.Table) []string {
 		statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s", tableName, g.wrap(fkName)))
 	}
 
+	// Drop check constraints
+	for _, checkName := range table.DropChecks {
+		statements = append(statements, g.CompileDropCheck(table.Name, checkName))
+	}
+
 	// Drop indexes
 	for _, idxName := range table.DropIndexes {
 		statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", tableName, g.wrap(idxName)))
@@ -142,6 +156,11 @@ func (g *Grammar) CompileAlter(table *schema.Table) []string {
 		statements = append(statements, g.CompileForeignKey(table.Name, fk))
 	}
 
+	// Add check constraints
+	for _, check := range table.Checks {
+		statements = append(statements, g.CompileCheck(table.Name, check))
+	}
+
 	return statements
 }
 
@@ -169,6 +188,55 @@ func (g *Grammar) CompileHasTable(name string) (string, error) {
 	return fmt.Sprintf("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = '%s'", name), nil
 }
 
+// compileGetTables returns a query listing the tables of the current database
+func (g *Grammar) compileGetTables() string {
+	return "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name"
+}
+
+// compileShowCreateTable returns a query reading the CREATE TABLE statement of a table
+func (g *Grammar) compileShowCreateTable(name string) string {
+	return "SHOW CREATE TABLE " + g.wrapTable(name)
+}
+
+// compileGetColumns returns a query reading the columns of a table and the
+// expression of generated columns
+func (g *Grammar) compileGetColumns() string {
+	return `SELECT column_name, column_type, is_nullable, column_default, extra, column_comment, generation_expression
+FROM information_schema.columns
+WHERE table_schema = DATABASE() AND table_name = ?
+ORDER BY ordinal_position`
+}
+
+// compileGetIndexes returns a query reading the index keys of a table. All
+// columns are selected because the expression column only exists on MySQL
+// 8.0.13 and later.
+func (g *Grammar) compileGetIndexes() string {
+	return `SELECT *
+FROM information_schema.statistics
+WHERE table_schema = DATABASE() AND table_name = ?
+ORDER BY index_name, seq_in_index`
+}
+
+// compileGetChecks returns a query reading the CHECK constraints of a table
+func (g *Grammar) compileGetChecks() string {
+	return `SELECT c.constraint_name, c.check_clause
+FROM information_schema.table_constraints t
+JOIN information_schema.check_constraints c
+  ON c.constraint_schema = t.constraint_schema AND c.constraint_name = t.constraint_name
+WHERE t.table_schema = DATABASE() AND t.table_name = ? AND t.constraint_type = 'CHECK'
+ORDER BY c.constraint_name`
+}
+
+// compileGetForeignKeys returns a query reading the foreign key columns of a table
+func (g *Grammar) compileGetForeignKeys() string {
+	return `SELECT k.constraint_name, k.column_name, k.referenced_table_name, k.referenced_column_name, r.update_rule, r.delete_rule
+FROM information_schema.key_column_usage k
+JOIN information_schema.referential_constraints r
+  ON r.constraint_schema = k.constraint_schema AND r.constraint_name = k.constraint_name AND r.table_name = k.table_name
+WHERE k.table_schema = DATABASE() AND k.table_name = ? AND k.referenced_table_name IS NOT NULL
+ORDER BY k.constraint_name, k.ordinal_position`
+}
+
 // Type mappings
 
 func (g *Grammar) TypeString(length int) string {
@@ -254,6 +322,15 @@ func (g *Grammar) CompileColumn(col *schema.Column) string {
 		sb.WriteString(" UNSIGNED")
 	}
 
+	if col.GeneratedAs != "" {
+		fmt.Fprintf(&sb, " GENERATED ALWAYS AS (%s)", col.GeneratedAs)
+		if col.IsStored {
+			sb.WriteString(" STORED")
+		} else {
+			sb.WriteString(" VIRTUAL")
+		}
+	}
+
 	if !col.IsNullable {
 		sb.WriteString(" NOT NULL")
 	} else {
@@ -276,6 +353,49 @@ func (g *Grammar) CompileColumn(col *schema.Column) string {
 	return sb.String()
 }
 
+// ValidateTable returns an error for generated columns with a default value
+// or AUTO_INCREMENT and for indexes MySQL cannot create
+func (g *Grammar) ValidateTable(table *schema.Table) error {
+	for _, idx := range table.Indexes {
+		if err := g.validateIndex(table.Name, idx); err != nil {
+			return err
+		}
+	}
+	for _, col := range table.Columns {
+		if col.GeneratedAs == "" {
+			continue
+		}
+		if col.DefaultValue != nil {
+			return fmt.Errorf("generated column %s cannot have a default value", col.Name)
+		}
+		if col.IsAutoIncrement {
+			return fmt.Errorf("generated column %s cannot be auto-incrementing", col.Name)
+		}
+	}
+	return nil
+}
+
+// validateIndex returns an error for partial indexes, unnamed indexes on
+// expressions and index methods other than BTREE and HASH
+func (g *Grammar) validateIndex(tableName string, idx *schema.Index) error {
+	if idx.HasExpressions() && idx.Name == "" {
+		return fmt.Errorf("index on expressions of table %s must be named", tableName)
+	}
+	name := idx.Name
+	if name == "" {
+		name = g.generateIndexName(tableName, idx.Columns, idx.Type)
+	}
+	if idx.Predicate != "" {
+		return fmt.Errorf("index %s: MySQL does not support partial indexes", name)
+	}
+	switch strings.ToLower(idx.Method) {
+	case "", "btree", "hash":
+	default:
+		return fmt.Errorf("index %s: MySQL does not support the %s index method (supported: btree, hash)", name, idx.Method)
+	}
+	return nil
+}
+
 func (g *Grammar) getColumnType(col *schema.Column) string {
 	switch col.Type {
 	case schema.TypeString:
@@ -312,6 +432,8 @@ func (g *Grammar) getColumnType(col *schema.Column) string {
 		return g.TypeBinary()
 	case schema.TypeUUID:
 		return g.TypeUUID()
+	case schema.TypeEnum:
+		return fmt.Sprintf("ENUM(%s)", compileValues(col.Values))
 	default:
 		return "VARCHAR(255)"
 	}
@@ -324,19 +446,19 @@ func (g *Grammar) CompileIndex(tableName string, idx *schema.Index) string {
 		indexName = g.generateIndexName(tableName, idx.Columns, idx.Type)
 	}
 
-	cols := make([]string, len(idx.Columns))
-	for i, col := range idx.Columns {
-		cols[i] = g.wrap(col)
-	}
-
+	kind := ""
 	switch idx.Type {
 	case schema.IndexTypeUnique:
-		return fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s)", g.wrap(indexName), g.wrapTable(tableName), strings.Join(cols, ", "))
+		kind = "UNIQUE "
 	case schema.IndexTypeFulltext:
-		return fmt.Sprintf("CREATE FULLTEXT INDEX %s ON %s (%s)", g.wrap(indexName), g.wrapTable(tableName), strings.Join(cols, ", "))
-	default:
-		return fmt.Sprintf("CREATE INDEX %s ON %s (%s)", g.wrap(indexName), g.wrapTable(tableName), strings.Join(cols, ", "))
+		kind = "FULLTEXT "
+	}
+
+	sql := fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", kind, g.wrap(indexName), g.wrapTable(tableName), g.compileIndexKeys(idx))
+	if idx.Method != "" {
+		sql += " USING " + strings.ToUpper(idx.Method)
 	}
+	return sql
 }
 
 // CompileDropIndex generates DROP INDEX SQL
@@ -378,6 +500,16 @@ func (g *Grammar) CompileDropForeignKey(tableName, fkName string) string {
 	return fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s", g.wrapTable(tableName), g.wrap(fkName))
 }
 
+// CompileCheck generates ADD CONSTRAINT ... CHECK SQL
+func (g *Grammar) CompileCheck(tableName string, check *schema.Check) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD %s", g.wrapTable(tableName), g.compileCheckInline(check))
+}
+
+// CompileDropCheck generates DROP CHECK SQL
+func (g *Grammar) CompileDropCheck(tableName, checkName string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP CHECK %s", g.wrapTable(tableName), g.wrap(checkName))
+}
+
 // Migrations table operations
 
 func (g *Grammar) CompileCreateMigrationsTable(tableName string) string {
@@ -385,16 +517,22 @@ func (g *Grammar) CompileCreateMigrationsTable(tableName string) string {
   id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
   migration VARCHAR(255) NOT NULL,
   batch INT NOT NULL,
-  executed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+  executed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  checksum VARCHAR(64) NULL,
+  duration_ms BIGINT NULL,
+  host VARCHAR(255) NULL,
+  migro_version VARCHAR(64) NULL,
+  os_user VARCHAR(255) NULL,
+  note TEXT NULL
 )`, g.wrapTable(tableName))
 }
 
 func (g *Grammar) CompileGetMigrations(tableName string) string {
-	return fmt.Sprintf("SELECT id, migration, batch, executed_at FROM %s ORDER BY batch, migration", g.wrapTable(tableName))
+	return fmt.Sprintf("SELECT id, migration, batch, executed_at, COALESCE(checksum, ''), COALESCE(duration_ms, 0), COALESCE(host, ''), COALESCE(migro_version, ''), COALESCE(os_user, ''), COALESCE(note, '') FROM %s ORDER BY batch, migration", g.wrapTable(tableName))
 }
 
 func (g *Grammar) CompileInsertMigration(tableName string) string {
-	return fmt.Sprintf("INSERT INTO %s (migration, batch) VALUES (?, ?)", g.wrapTable(tableName))
+	return fmt.Sprintf("INSERT INTO %s (migration, batch, checksum, duration_ms, host, migro_version, os_user, note) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", g.wrapTable(tableName))
 }
 
 func (g *Grammar) CompileDeleteMigration(tableName string) string {
@@ -405,6 +543,33 @@ func (g *Grammar) CompileGetLastBatch(tableName string) string {
 	return fmt.Sprintf("SELECT COALESCE(MAX(batch), 0) FROM %s", g.wrapTable(tableName))
 }
 
+// migrationsColumn is a column added to the migrations table after its first release
+type migrationsColumn struct {
+	name       string
+	definition string
+}
+
+// migrationsTableUpgrades returns the columns that existing migrations tables may lack
+func (g *Grammar) migrationsTableUpgrades() []migrationsColumn {
+	return []migrationsColumn{
+		{name: "checksum", definition: "VARCHAR(64) NULL"},
+		{name: "duration_ms", definition: "BIGINT NULL"},
+		{name: "host", definition: "VARCHAR(255) NULL"},
+		{name: "migro_version", definition: "VARCHAR(64) NULL"},
+		{name: "os_user", definition: "VARCHAR(255) NULL"},
+		{name: "note", definition: "TEXT NULL"},
+	}
+}
+
+// compileHasColumn returns a query counting the columns of a table with the given name
+func (g *Grammar) compileHasColumn() string {
+	return "SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?"
+}
+
+func (g *Grammar) compileAddMigrationsColumn(tableName string, col migrationsColumn) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", g.wrapTable(tableName), g.wrap(col.name), col.definition)
+}
+
 // Helper methods
 
 func (g *Grammar) wrap(name string) string {
@@ -421,19 +586,44 @@ func (g *Grammar) compileIndexInline(idx *schema.Index) string {
 		indexName = strings.Join(idx.Columns, "_") + "_idx"
 	}
 
-	cols := make([]string, len(idx.Columns))
-	for i, col := range idx.Columns {
-		cols[i] = g.wrap(col)
-	}
-
+	kind := ""
 	switch idx.Type {
 	case schema.IndexTypeUnique:
-		return fmt.Sprintf("UNIQUE KEY %s (%s)", g.wrap(indexName), strings.Join(cols, ", "))
+		kind = "UNIQUE "
 	case schema.IndexTypeFulltext:
-		return fmt.Sprintf("FULLTEXT KEY %s (%s)", g.wrap(indexName), strings.Join(cols, ", "))
-	default:
-		return fmt.Sprintf("KEY %s (%s)", g.wrap(indexName), strings.Join(cols, ", "))
+		kind = "FULLTEXT "
+	}
+
+	sql := fmt.Sprintf("%sKEY %s (%s)", kind, g.wrap(indexName), g.compileIndexKeys(idx))
+	if idx.Method != "" {
+		sql += " USING " + strings.ToUpper(idx.Method)
+	}
+	return sql
+}
+
+// compileIndexKeys generates the key parts of an index. Expressions are
+// functional key parts, which MySQL requires in parentheses.
+func (g *Grammar) compileIndexKeys(idx *schema.Index) string {
+	keys := idx.Keys()
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		part := "(" + key.Expression + ")"
+		if key.Column != "" {
+			part = g.wrap(key.Column)
+			if key.Length > 0 {
+				part += fmt.Sprintf("(%d)", key.Length)
+			}
+		}
+		if key.Order != "" {
+			part += " " + key.Order
+		}
+		parts[i] = part
 	}
+	return strings.Join(parts, ", ")
+}
+
+func (g *Grammar) compileCheckInline(check *schema.Check) string {
+	return fmt.Sprintf("CONSTRAINT %s CHECK (%s)", g.wrap(check.Name), check.Expression)
 }
 
 func (g *Grammar) compileForeignKeyInline(fk *schema.ForeignKey) string {
@@ -501,6 +691,15 @@ func isNumericType(t schema.ColumnType) bool {
 	}
 }
 
+// compileValues returns the quoted values of an enum column
+func compileValues(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = "'" + escapeString(value) + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
 func escapeString(s string) string {
 	return strings.ReplaceAll(s, "'", "''")
 }
@@ -376,6 +376,14 @@ func TestGrammar_CompileCreateMigrationsTable(t *testing.T) {
 	if !strings.Contains(sql, "migration VARCHAR(255)") {
 		t.Error("expected migration column")
 	}
+	if !strings.Contains(sql, "checksum VARCHAR(64)") {
+		t.Error("expected checksum column")
+	}
+	for _, col := range []string{"duration_ms BIGINT", "host VARCHAR(255)", "migro_version VARCHAR(64)", "os_user VARCHAR(255)", "note TEXT"} {
+		if !strings.Contains(sql, col) {
+			t.Errorf("expected %s column", col)
+		}
+	}
 	if !strings.Contains(sql, "batch INT") {
 		t.Error("expected batch column")
 	}
@@ -591,6 +599,9 @@ func TestGrammar_MigrationTableOperations(t *testing.T) {
 		if !strings.Contains(sql, "INSERT INTO") {
 			t.Error("expected INSERT INTO statement")
 		}
+		if !strings.Contains(sql, "checksum") {
+			t.Error("expected checksum column")
+		}
 	})
 
 	t.Run("CompileDeleteMigration", func(t *testing.T) {
@@ -716,6 +727,11 @@ func TestGrammar_CompileColumn_AllTypes(t *testing.T) {
 			col:      &schema.Column{Name: "count", Type: schema.TypeInteger, DefaultValue: 0},
 			contains: []string{"DEFAULT 0"},
 		},
+		{
+			name:     "column with default expression",
+			col:      &schema.Column{Name: "created_at", Type: schema.TypeTimestamp, DefaultValue: schema.Expression("CURRENT_TIMESTAMP")},
+			contains: []string{"DEFAULT CURRENT_TIMESTAMP"},
+		},
 		{
 			name:     "unsigned bigint",
 			col:      &schema.Column{Name: "id", Type: schema.TypeBigInteger, IsUnsigned: true},
@@ -894,3 +910,120 @@ func TestGrammar_EscapeString(t *testing.T) {
 		t.Error("expected escaped single quotes")
 	}
 }
+
+// 测试目标：验证 Enum 和 ChangeEnum 编译为 ENUM(...)
+func TestGrammar_Enum(t *testing.T) {
+	g := NewGrammar()
+
+	table := schema.NewTable("posts")
+	table.Enum("status", "draft", "it's").Default("draft")
+	sql := g.CompileCreate(table)
+	if !strings.Contains(sql, "`status` ENUM('draft', 'it''s') NOT NULL DEFAULT 'draft'") {
+		t.Errorf("unexpected enum column in %s", sql)
+	}
+
+	alter := schema.NewTable("posts")
+	alter.ChangeEnum("status", "draft", "published").Nullable()
+	statements := g.CompileAlter(alter)
+	expected := "ALTER TABLE `posts` MODIFY COLUMN `status` ENUM('draft', 'published') NULL"
+	if len(statements) != 1 || statements[0] != expected {
+		t.Errorf("expected %s, got %v", expected, statements)
+	}
+}
+
+func TestGrammar_Checks(t *testing.T) {
+	g := NewGrammar()
+
+	table := schema.NewTable("products")
+	table.Decimal("price", 10, 2)
+	table.Check("products_price_positive", "price >= 0")
+	sql := g.CompileCreate(table)
+	if !strings.Contains(sql, "  CONSTRAINT `products_price_positive` CHECK (price >= 0)\n)") {
+		t.Errorf("expected an inline check in %s", sql)
+	}
+
+	alter := schema.NewTable("products")
+	alter.DropCheck("products_price_positive")
+	alter.Check("products_price_range", "price BETWEEN 0 AND 1000")
+	expected := []string{
+		"ALTER TABLE `products` DROP CHECK `products_price_positive`",
+		"ALTER TABLE `products` ADD CONSTRAINT `products_price_range` CHECK (price BETWEEN 0 AND 1000)",
+	}
+	if got := g.CompileAlter(alter); strings.Join(got, ";") != strings.Join(expected, ";") {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestGrammar_GeneratedColumns(t *testing.T) {
+	g := NewGrammar()
+
+	table := schema.NewTable("users")
+	table.JSON("profile")
+	table.String("city", 100).Nullable().VirtualAs("profile->>'$.city'")
+	table.Decimal("total", 10, 2).Unsigned().StoredAs("price * quantity")
+	sql := g.CompileCreate(table)
+	for _, want := range []string{
+		"`city` VARCHAR(100) GENERATED ALWAYS AS (profile->>'$.city') VIRTUAL NULL",
+		"`total` DECIMAL(10,2) UNSIGNED GENERATED ALWAYS AS (price * quantity) STORED NOT NULL",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("expected %s in %s", want, sql)
+		}
+	}
+	if err := g.ValidateTable(table); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalid := schema.NewTable("users")
+	invalid.Integer("total").StoredAs("price * quantity").Default(0)
+	if err := g.ValidateTable(invalid); err == nil {
+		t.Error("expected an error for a generated column with a default value")
+	}
+}
+
+func TestGrammar_IndexOptions(t *testing.T) {
+	g := NewGrammar()
+
+	t.Run("CompileIndex", func(t *testing.T) {
+		idx := schema.NewIndex("name", "created_at").Named("users_name_idx").Length("name", 10).Desc("created_at").Using("btree")
+		expected := "CREATE INDEX `users_name_idx` ON `users` (`name`(10), `created_at` DESC) USING BTREE"
+		if got := g.CompileIndex("users", idx); got != expected {
+			t.Errorf("expected %s, got %s", expected, got)
+		}
+
+		idx = schema.NewIndex().Named("users_email_lower_unique").Unique().Expression("lower(email)")
+		expected = "CREATE UNIQUE INDEX `users_email_lower_unique` ON `users` ((lower(email)))"
+		if got := g.CompileIndex("users", idx); got != expected {
+			t.Errorf("expected %s, got %s", expected, got)
+		}
+	})
+
+	t.Run("CREATE TABLE 内联索引", func(t *testing.T) {
+		table := schema.NewTable("users")
+		table.String("name", 255)
+		table.Index("name").Named("users_name_idx").Length("name", 20).Using("hash")
+		if sql := g.CompileCreate(table); !strings.Contains(sql, "KEY `users_name_idx` (`name`(20)) USING HASH") {
+			t.Errorf("unexpected inline index in %s", sql)
+		}
+	})
+
+	tests := []struct {
+		name  string
+		index func(t *schema.Table)
+		want  string
+	}{
+		{"部分索引", func(t *schema.Table) { t.Unique("email").Where("deleted_at IS NULL") }, "partial indexes"},
+		{"不支持的索引方法", func(t *schema.Table) { t.Index("tags").Using("gin") }, "gin index method"},
+		{"未命名的表达式索引", func(t *schema.Table) { t.Index().Expression("lower(email)") }, "must be named"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := schema.NewTable("users")
+			tt.index(table)
+			err := g.ValidateTable(table)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
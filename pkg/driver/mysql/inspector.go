@@ -0,0 +1,391 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/flyits/migro/pkg/driver"
+	"github.com/flyits/migro/pkg/schema"
+	"github.com/go-sql-driver/mysql"
+)
+
+// Ensure Driver implements driver.Inspector and driver.CheckInspector
+var (
+	_ driver.Inspector      = (*Driver)(nil)
+	_ driver.CheckInspector = (*Driver)(nil)
+)
+
+// errUnknownTable is the error number of a missing information_schema table
+const errUnknownTable = 1109
+
+// GetTables returns the names of the tables in the current database
+func (d *Driver) GetTables(ctx context.Context, q driver.Queryer) ([]string, error) {
+	var tables []string
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		tables = append(tables, name)
+		return nil
+	}, d.grammar.compileGetTables())
+	if err != nil {
+		return nil, fmt.Errorf("mysql: failed to read tables: %w", err)
+	}
+	return tables, nil
+}
+
+// GetColumns returns the columns of a table read from information_schema,
+// generated columns with their expression
+func (d *Driver) GetColumns(ctx context.Context, q driver.Queryer, table string) ([]*schema.Column, error) {
+	var columns []*schema.Column
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		var (
+			name, columnType, nullable, extra, comment string
+			defaultValue, generation                   sql.NullString
+		)
+		if err := rows.Scan(&name, &columnType, &nullable, &defaultValue, &extra, &comment, &generation); err != nil {
+			return err
+		}
+		col := &schema.Column{
+			Name:            name,
+			IsNullable:      nullable == "YES",
+			IsAutoIncrement: strings.Contains(strings.ToLower(extra), "auto_increment"),
+			ColumnComment:   comment,
+			DatabaseType:    columnType,
+		}
+		setColumnType(col, columnType)
+		setGenerated(col, extra, generation.String)
+		if defaultValue.Valid {
+			col.DefaultValue = parseDefault(col, defaultValue.String, extra)
+		}
+		columns = append(columns, col)
+		return nil
+	}, d.grammar.compileGetColumns(), table)
+	if err != nil {
+		return nil, fmt.Errorf("mysql: failed to read columns of %s: %w", table, err)
+	}
+	return columns, nil
+}
+
+// GetIndexes returns the indexes of a table with their functional parts,
+// prefix lengths and sort orders. The default BTREE method is left empty.
+func (d *Driver) GetIndexes(ctx context.Context, q driver.Queryer, table string) ([]*schema.Index, error) {
+	var indexes []*schema.Index
+	byName := make(map[string]*schema.Index)
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		row, err := scanRow(rows)
+		if err != nil {
+			return err
+		}
+		name, indexType := row["index_name"].String, row["index_type"].String
+		idx, ok := byName[name]
+		if !ok {
+			idx = schema.NewIndex().Named(name)
+			switch {
+			case name == "PRIMARY":
+				idx.Primary()
+			case strings.EqualFold(indexType, "FULLTEXT"):
+				idx.Fulltext()
+			case row["non_unique"].String == "0":
+				idx.Unique()
+			}
+			if strings.EqualFold(indexType, "HASH") {
+				idx.Using("hash")
+			}
+			byName[name] = idx
+			indexes = append(indexes, idx)
+		}
+		addIndexPart(idx, indexPart(row))
+		return nil
+	}, d.grammar.compileGetIndexes(), table)
+	if err != nil {
+		return nil, fmt.Errorf("mysql: failed to read indexes of %s: %w", table, err)
+	}
+	return indexes, nil
+}
+
+// GetChecks returns the CHECK constraints of a table. Servers without
+// information_schema.check_constraints, before MySQL 8.0.16, report none.
+func (d *Driver) GetChecks(ctx context.Context, q driver.Queryer, table string) ([]*schema.Check, error) {
+	var checks []*schema.Check
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		var name, clause string
+		if err := rows.Scan(&name, &clause); err != nil {
+			return err
+		}
+		checks = append(checks, &schema.Check{Name: name, Expression: driver.TrimParens(clause)})
+		return nil
+	}, d.grammar.compileGetChecks(), table)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errUnknownTable {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mysql: failed to read checks of %s: %w", table, err)
+	}
+	return checks, nil
+}
+
+// indexPart returns the key of an information_schema.statistics row.
+// Functional parts have no column name and their expression in expression.
+func indexPart(row map[string]sql.NullString) schema.IndexPart {
+	part := schema.IndexPart{Column: row["column_name"].String}
+	if !row["column_name"].Valid {
+		part.Expression = row["expression"].String
+	}
+	if row["collation"].String == "D" {
+		part.Order = schema.OrderDesc
+	}
+	if length, err := strconv.Atoi(row["sub_part"].String); err == nil {
+		part.Length = length
+	}
+	return part
+}
+
+// addIndexPart appends a key read from the database to an index. Indexes of
+// plain columns only list their columns, Parts is filled once a key is an
+// expression or has a sort order or prefix length.
+func addIndexPart(idx *schema.Index, part schema.IndexPart) {
+	if len(idx.Parts) > 0 || part.Expression != "" || part.Order != "" || part.Length > 0 {
+		idx.Parts = append(idx.Keys(), part)
+	}
+	if part.Column != "" {
+		idx.Columns = append(idx.Columns, part.Column)
+	}
+}
+
+// GetForeignKeys returns the foreign keys of a table
+func (d *Driver) GetForeignKeys(ctx context.Context, q driver.Queryer, table string) ([]*schema.ForeignKey, error) {
+	var foreignKeys []*schema.ForeignKey
+	byName := make(map[string]*schema.ForeignKey)
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		var name, column, referenceTable, referenceColumn, onUpdate, onDelete string
+		if err := rows.Scan(&name, &column, &referenceTable, &referenceColumn, &onUpdate, &onDelete); err != nil {
+			return err
+		}
+		fk, ok := byName[name]
+		if !ok {
+			fk = &schema.ForeignKey{
+				Name:           name,
+				ReferenceTable: referenceTable,
+				OnDelete:       schema.ForeignKeyAction(onDelete),
+				OnUpdate:       schema.ForeignKeyAction(onUpdate),
+			}
+			byName[name] = fk
+			foreignKeys = append(foreignKeys, fk)
+		}
+		fk.Columns = append(fk.Columns, column)
+		if fk.ReferenceColumn != "" {
+			fk.ReferenceColumn += ", "
+		}
+		fk.ReferenceColumn += referenceColumn
+		return nil
+	}, d.grammar.compileGetForeignKeys(), table)
+	if err != nil {
+		return nil, fmt.Errorf("mysql: failed to read foreign keys of %s: %w", table, err)
+	}
+	return foreignKeys, nil
+}
+
+// setColumnType maps a MySQL column type such as "int unsigned" or
+// "varchar(255)" to a schema column type
+func setColumnType(col *schema.Column, columnType string) {
+	typ := strings.ToLower(columnType)
+	col.IsUnsigned = strings.Contains(typ, "unsigned")
+
+	name, args := splitType(typ)
+	switch name {
+	case "varchar":
+		col.Type = schema.TypeString
+		if len(args) > 0 {
+			col.Length = args[0]
+		}
+	case "char":
+		if len(args) > 0 && args[0] == 36 {
+			col.Type = schema.TypeUUID
+			return
+		}
+		col.Type = schema.TypeString
+		if len(args) > 0 {
+			col.Length = args[0]
+		}
+	case "tinyint":
+		if len(args) > 0 && args[0] == 1 {
+			col.Type = schema.TypeBoolean
+			return
+		}
+		col.Type = schema.TypeTinyInteger
+	case "bool", "boolean":
+		col.Type = schema.TypeBoolean
+	case "smallint":
+		col.Type = schema.TypeSmallInteger
+	case "mediumint", "int", "integer":
+		col.Type = schema.TypeInteger
+	case "bigint":
+		col.Type = schema.TypeBigInteger
+	case "float":
+		col.Type = schema.TypeFloat
+	case "double", "real":
+		col.Type = schema.TypeDouble
+	case "decimal", "numeric":
+		col.Type = schema.TypeDecimal
+		if len(args) > 0 {
+			col.Precision = args[0]
+		}
+		if len(args) > 1 {
+			col.Scale = args[1]
+		}
+	case "date":
+		col.Type = schema.TypeDate
+	case "datetime":
+		col.Type = schema.TypeDateTime
+	case "timestamp":
+		col.Type = schema.TypeTimestamp
+	case "time":
+		col.Type = schema.TypeTime
+	case "json":
+		col.Type = schema.TypeJSON
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		col.Type = schema.TypeBinary
+	case "enum":
+		col.Type = schema.TypeEnum
+		col.Values = parseEnumValues(columnType)
+	default:
+		col.Type = schema.TypeText
+	}
+}
+
+// parseEnumValues returns the values of a column type such as
+// enum('draft','published'), where a quote inside a value is doubled
+func parseEnumValues(columnType string) []string {
+	start := strings.Index(columnType, "(")
+	end := strings.LastIndex(columnType, ")")
+	if start < 0 || end < start {
+		return nil
+	}
+
+	var values []string
+	inner := columnType[start+1 : end]
+	for i := 0; i < len(inner); i++ {
+		if inner[i] != '\'' {
+			continue
+		}
+		var sb strings.Builder
+		for i++; i < len(inner); i++ {
+			if inner[i] == '\'' {
+				if i+1 < len(inner) && inner[i+1] == '\'' {
+					sb.WriteByte('\'')
+					i++
+					continue
+				}
+				break
+			}
+			sb.WriteByte(inner[i])
+		}
+		values = append(values, sb.String())
+	}
+	return values
+}
+
+// splitType splits a column type such as "decimal(10,2) unsigned" into its
+// name and its numeric arguments
+func splitType(typ string) (string, []int) {
+	typ = strings.TrimSpace(typ)
+	end := strings.IndexAny(typ, "( ")
+	if end < 0 {
+		return typ, nil
+	}
+
+	name := typ[:end]
+	var args []int
+	if typ[end] == '(' {
+		inner := typ[end+1:]
+		if close := strings.Index(inner, ")"); close >= 0 {
+			inner = inner[:close]
+		}
+		for _, arg := range strings.Split(inner, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(arg))
+			if err != nil {
+				break
+			}
+			args = append(args, n)
+		}
+	}
+	return name, args
+}
+
+// setGenerated makes a column generated when extra reports it, e.g.
+// "STORED GENERATED" or "VIRTUAL GENERATED". MariaDB reports stored columns
+// as PERSISTENT.
+func setGenerated(col *schema.Column, extra, expression string) {
+	extra = strings.ToUpper(extra)
+	switch {
+	case strings.Contains(extra, "STORED GENERATED"), strings.Contains(extra, "PERSISTENT"):
+		col.StoredAs(driver.TrimParens(expression))
+	case strings.Contains(extra, "VIRTUAL"):
+		col.VirtualAs(driver.TrimParens(expression))
+	}
+}
+
+// parseDefault converts a column_default value to a column default. MySQL
+// reports string literals unquoted and MariaDB quotes them; expressions are
+// flagged with DEFAULT_GENERATED in extra on MySQL 8.
+func parseDefault(col *schema.Column, value, extra string) interface{} {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	if value == "NULL" && col.IsNullable {
+		return nil
+	}
+	upper := strings.ToUpper(value)
+	if strings.Contains(strings.ToUpper(extra), "DEFAULT_GENERATED") ||
+		strings.HasPrefix(upper, "CURRENT_TIMESTAMP") || strings.HasPrefix(upper, "NOW(") ||
+		isNumericType(col.Type) || col.Type == schema.TypeBoolean {
+		return schema.Expression(value)
+	}
+	return value
+}
+
+// scanRow scans a row into its values by lower case column name, for
+// queries whose columns depend on the server version
+func scanRow(rows *sql.Rows) (map[string]sql.NullString, error) {
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]sql.NullString, len(names))
+	dest := make([]interface{}, len(names))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	row := make(map[string]sql.NullString, len(names))
+	for i, name := range names {
+		row[strings.ToLower(name)] = values[i]
+	}
+	return row, nil
+}
+
+// queryEach runs a query and calls scan for each row. All rows are consumed
+// before it returns, so it is safe to use on a transaction.
+func queryEach(ctx context.Context, q driver.Queryer, scan func(*sql.Rows) error, query string, args ...interface{}) error {
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,135 @@
+package mysql
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// 测试目标：验证 information_schema 中的列类型与默认值被正确映射为 schema.Column
+func TestSetColumnType(t *testing.T) {
+	tests := []struct {
+		columnType string
+		expected   schema.Column
+	}{
+		{"varchar(100)", schema.Column{Type: schema.TypeString, Length: 100}},
+		{"char(36)", schema.Column{Type: schema.TypeUUID}},
+		{"char(2)", schema.Column{Type: schema.TypeString, Length: 2}},
+		{"tinyint(1)", schema.Column{Type: schema.TypeBoolean}},
+		{"tinyint unsigned", schema.Column{Type: schema.TypeTinyInteger, IsUnsigned: true}},
+		{"int", schema.Column{Type: schema.TypeInteger}},
+		{"bigint(20) unsigned", schema.Column{Type: schema.TypeBigInteger, IsUnsigned: true}},
+		{"decimal(10,2)", schema.Column{Type: schema.TypeDecimal, Precision: 10, Scale: 2}},
+		{"double", schema.Column{Type: schema.TypeDouble}},
+		{"datetime", schema.Column{Type: schema.TypeDateTime}},
+		{"timestamp", schema.Column{Type: schema.TypeTimestamp}},
+		{"json", schema.Column{Type: schema.TypeJSON}},
+		{"longblob", schema.Column{Type: schema.TypeBinary}},
+		{"mediumtext", schema.Column{Type: schema.TypeText}},
+		{"enum('Draft','it''s')", schema.Column{Type: schema.TypeEnum, Values: []string{"Draft", "it's"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.columnType, func(t *testing.T) {
+			col := &schema.Column{}
+			setColumnType(col, tt.columnType)
+			if col.Type != tt.expected.Type || col.Length != tt.expected.Length ||
+				col.Precision != tt.expected.Precision || col.Scale != tt.expected.Scale ||
+				col.IsUnsigned != tt.expected.IsUnsigned ||
+				strings.Join(col.Values, "|") != strings.Join(tt.expected.Values, "|") {
+				t.Errorf("unexpected column %+v, want %+v", *col, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseDefault(t *testing.T) {
+	tests := []struct {
+		name     string
+		col      *schema.Column
+		value    string
+		extra    string
+		expected interface{}
+	}{
+		{"字符串", &schema.Column{Type: schema.TypeString}, "draft", "", "draft"},
+		{"MariaDB 带引号字符串", &schema.Column{Type: schema.TypeString}, "'it''s'", "", "it's"},
+		{"数字", &schema.Column{Type: schema.TypeInteger}, "0", "", schema.Expression("0")},
+		{"CURRENT_TIMESTAMP", &schema.Column{Type: schema.TypeTimestamp}, "CURRENT_TIMESTAMP", "DEFAULT_GENERATED", schema.Expression("CURRENT_TIMESTAMP")},
+		{"MariaDB NULL", &schema.Column{Type: schema.TypeString, IsNullable: true}, "NULL", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseDefault(tt.col, tt.value, tt.extra); got != tt.expected {
+				t.Errorf("parseDefault() = %#v, want %#v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// 测试目标：验证 information_schema.statistics 中的函数索引、前缀长度和降序被还原为索引键
+func TestIndexParts(t *testing.T) {
+	valid := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+
+	idx := schema.NewIndex().Named("users_lookup")
+	for _, row := range []map[string]sql.NullString{
+		{"column_name": valid("name"), "collation": valid("A"), "sub_part": valid("10")},
+		{"expression": valid("lower(`email`)"), "collation": valid("A")},
+		{"column_name": valid("created_at"), "collation": valid("D")},
+	} {
+		addIndexPart(idx, indexPart(row))
+	}
+
+	expected := []schema.IndexPart{
+		{Column: "name", Length: 10},
+		{Expression: "lower(`email`)"},
+		{Column: "created_at", Order: schema.OrderDesc},
+	}
+	if len(idx.Parts) != len(expected) {
+		t.Fatalf("expected %d parts, got %+v", len(expected), idx.Parts)
+	}
+	for i, part := range expected {
+		if idx.Parts[i] != part {
+			t.Errorf("part %d: expected %+v, got %+v", i, part, idx.Parts[i])
+		}
+	}
+	if strings.Join(idx.Columns, ",") != "name,created_at" {
+		t.Errorf("expected columns name,created_at, got %v", idx.Columns)
+	}
+
+	t.Run("普通列索引不设置 Parts", func(t *testing.T) {
+		idx := schema.NewIndex().Named("users_name")
+		addIndexPart(idx, indexPart(map[string]sql.NullString{"column_name": valid("name"), "collation": valid("A")}))
+		addIndexPart(idx, indexPart(map[string]sql.NullString{"column_name": valid("email"), "collation": valid("A")}))
+		if len(idx.Parts) != 0 || strings.Join(idx.Columns, ",") != "name,email" {
+			t.Errorf("unexpected index %+v", idx)
+		}
+	})
+}
+
+// 测试目标：验证 extra 和 generation_expression 被还原为生成列
+func TestSetGenerated(t *testing.T) {
+	tests := []struct {
+		name, extra, expression string
+		expectedAs              string
+		expectedStored          bool
+	}{
+		{"STORED", "STORED GENERATED", "(`price` * `quantity`)", "`price` * `quantity`", true},
+		{"VIRTUAL", "VIRTUAL GENERATED", "concat(`first`,' ',`last`)", "concat(`first`,' ',`last`)", false},
+		{"MariaDB PERSISTENT", "PERSISTENT GENERATED", "`price` * 2", "`price` * 2", true},
+		{"默认值表达式不是生成列", "DEFAULT_GENERATED", "", "", false},
+		{"普通列", "", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			col := &schema.Column{}
+			setGenerated(col, tt.extra, tt.expression)
+			if col.GeneratedAs != tt.expectedAs || col.IsStored != tt.expectedStored {
+				t.Errorf("got GeneratedAs %q IsStored %v, want %q %v", col.GeneratedAs, col.IsStored, tt.expectedAs, tt.expectedStored)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package mysql
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/flyits/migro/pkg/driver"
+)
+
+// maxLockNameLength is the maximum length of a GET_LOCK name
+const maxLockNameLength = 64
+
+// lockNameHashLength is the number of hex digits of the SHA-256 hash that
+// replaces the end of a long lock name
+const lockNameHashLength = 32
+
+// Ensure Driver implements driver.Locker
+var _ driver.Locker = (*Driver)(nil)
+
+// Lock acquires a named lock with GET_LOCK. The lock belongs to a dedicated
+// connection that is kept open until Unlock is called.
+func (d *Driver) Lock(ctx context.Context, name string, timeout time.Duration) error {
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("mysql: failed to get connection for lock: %w", err)
+	}
+
+	lockName, err := d.lockName(ctx, conn, name)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, lockTimeoutSeconds(timeout)).Scan(&acquired); err != nil {
+		conn.Close()
+		return fmt.Errorf("mysql: failed to acquire lock %s: %w", lockName, err)
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		conn.Close()
+		return driver.ErrLockTimeout
+	}
+
+	d.lockConn = conn
+	return nil
+}
+
+// Unlock releases the lock acquired by Lock and closes its connection
+func (d *Driver) Unlock(ctx context.Context, name string) error {
+	if d.lockConn == nil {
+		return nil
+	}
+	conn := d.lockConn
+	d.lockConn = nil
+	defer conn.Close()
+
+	lockName, err := d.lockName(ctx, conn, name)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName); err != nil {
+		return fmt.Errorf("mysql: failed to release lock %s: %w", lockName, err)
+	}
+	return nil
+}
+
+// ForceUnlock kills the connection holding the lock. MySQL only allows the
+// owning session to release a named lock, so ending it is the only way to
+// free a lock left behind by a stuck process.
+func (d *Driver) ForceUnlock(ctx context.Context, name string) error {
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("mysql: failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	lockName, err := d.lockName(ctx, conn, name)
+	if err != nil {
+		return err
+	}
+
+	var holder sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT IS_USED_LOCK(?)", lockName).Scan(&holder); err != nil {
+		return fmt.Errorf("mysql: failed to check lock %s: %w", lockName, err)
+	}
+	if !holder.Valid {
+		return nil
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("KILL %d", holder.Int64)); err != nil {
+		return fmt.Errorf("mysql: failed to kill connection %d holding lock %s: %w", holder.Int64, lockName, err)
+	}
+	return nil
+}
+
+// lockTimeoutSeconds converts a lock timeout to the whole seconds GET_LOCK
+// waits. A fraction of a second is rounded up, so that a short timeout still
+// waits instead of failing at once; a negative timeout waits forever.
+func lockTimeoutSeconds(timeout time.Duration) int {
+	if timeout < 0 {
+		return -1
+	}
+	return int((timeout + time.Second - 1) / time.Second)
+}
+
+// lockName scopes the lock name to the current database
+func (d *Driver) lockName(ctx context.Context, conn *sql.Conn, name string) (string, error) {
+	var database sql.NullString
+	if err := conn.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&database); err != nil {
+		return "", fmt.Errorf("mysql: failed to get current database: %w", err)
+	}
+	return buildLockName(database.String, name), nil
+}
+
+// buildLockName builds a GET_LOCK name from a database and a lock name. Names
+// longer than GET_LOCK allows keep their beginning followed by a hash of the
+// whole name, so that long names sharing a prefix do not collide.
+func buildLockName(database, name string) string {
+	lockName := fmt.Sprintf("migro:%s:%s", database, name)
+	if len(lockName) <= maxLockNameLength {
+		return lockName
+	}
+	sum := sha256.Sum256([]byte(lockName))
+	prefix := lockName[:maxLockNameLength-lockNameHashLength-1]
+	return prefix + ":" + hex.EncodeToString(sum[:])[:lockNameHashLength]
+}
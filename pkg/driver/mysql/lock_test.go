@@ -0,0 +1,52 @@
+package mysql
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试目标：验证迁移锁名称的生成与长度限制
+func TestBuildLockName(t *testing.T) {
+	if got := buildLockName("myapp", "migrations"); got != "migro:myapp:migrations" {
+		t.Errorf("unexpected lock name %q", got)
+	}
+
+	long := buildLockName(strings.Repeat("d", 64), "migrations")
+	if len(long) != maxLockNameLength {
+		t.Errorf("expected lock name to be shortened to %d chars, got %d", maxLockNameLength, len(long))
+	}
+	if !strings.HasPrefix(long, "migro:ddd") {
+		t.Errorf("expected lock name to keep its beginning, got %q", long)
+	}
+	if buildLockName(strings.Repeat("d", 64), "migrations") != long {
+		t.Error("expected lock name to be stable")
+	}
+
+	// 前缀相同的长名称不能得到相同的锁
+	if other := buildLockName(strings.Repeat("d", 64), "migrations_other"); other == long {
+		t.Errorf("expected long lock names with a shared prefix to differ, both are %q", long)
+	}
+}
+
+// 测试目标：验证锁等待时间换算为 GET_LOCK 的秒数，不足一秒向上取整
+func TestLockTimeoutSeconds(t *testing.T) {
+	tests := []struct {
+		timeout  time.Duration
+		expected int
+	}{
+		{-time.Second, -1},
+		{0, 0},
+		{time.Millisecond, 1},
+		{500 * time.Millisecond, 1},
+		{time.Second, 1},
+		{1500 * time.Millisecond, 2},
+		{time.Minute, 60},
+	}
+
+	for _, tt := range tests {
+		if got := lockTimeoutSeconds(tt.timeout); got != tt.expected {
+			t.Errorf("lockTimeoutSeconds(%v) = %d, want %d", tt.timeout, got, tt.expected)
+		}
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/flyits/migro/pkg/driver"
 	"github.com/flyits/migro/pkg/schema"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 func init() {
@@ -21,6 +24,7 @@ type Driver struct {
 	db             *sql.DB
 	grammar        *Grammar
 	ownsConnection bool
+	lockConn       *sql.Conn // connection holding the migration lock
 }
 
 // NewDriver creates a new PostgreSQL driver instance
@@ -32,17 +36,9 @@ func NewDriver() *Driver {
 
 // Connect establishes a connection to the PostgreSQL database
 func (d *Driver) Connect(config *driver.Config) error {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		config.Host,
-		config.Port,
-		config.Username,
-		config.Password,
-		config.Database,
-	)
-
-	// Add additional options
-	for key, value := range config.Options {
-		dsn += " " + key + "=" + value
+	dsn, err := buildDSN(config)
+	if err != nil {
+		return fmt.Errorf("postgres: %w", err)
 	}
 
 	db, err := sql.Open("postgres", dsn)
@@ -60,6 +56,90 @@ func (d *Driver) Connect(config *driver.Config) error {
 	return nil
 }
 
+// buildDSN returns the keyword/value connection string of config. The
+// options take precedence over the sslmode=disable default, the TLS
+// settings and the search_path set by the schema.
+func buildDSN(config *driver.Config) (string, error) {
+	params := map[string]string{"sslmode": "disable"}
+	if config.Schema != "" {
+		params["search_path"] = config.Schema
+	}
+	sslParams, err := tlsParams(config.TLS)
+	if err != nil {
+		return "", err
+	}
+	for key, value := range sslParams {
+		params[key] = value
+	}
+	for key, value := range config.Options {
+		params[key] = value
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{
+		"host=" + quoteDSNValue(config.Host),
+		fmt.Sprintf("port=%d", config.Port),
+		"user=" + quoteDSNValue(config.Username),
+		"password=" + quoteDSNValue(config.Password),
+		"dbname=" + quoteDSNValue(config.Database),
+	}
+	for _, key := range keys {
+		parts = append(parts, key+"="+quoteDSNValue(params[key]))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// tlsParams maps the TLS settings to the sslmode, sslrootcert, sslcert and
+// sslkey parameters. lib/pq verifies the connection host, so a server name
+// requires a TLS configuration registered with lib/pq.
+func tlsParams(settings driver.TLSConfig) (map[string]string, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	mode := settings.EffectiveMode()
+	if mode == "" {
+		return nil, nil
+	}
+
+	if settings.ServerName != "" && mode != driver.TLSDisable {
+		tlsConfig, err := settings.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		name := settings.Key()
+		if err := pq.RegisterTLSConfig(name, tlsConfig); err != nil {
+			return nil, fmt.Errorf("failed to register TLS config: %w", err)
+		}
+		return map[string]string{"sslmode": "pqgo-" + name}, nil
+	}
+
+	params := map[string]string{"sslmode": mode}
+	if settings.CAFile != "" {
+		params["sslrootcert"] = settings.CAFile
+	}
+	if settings.CertFile != "" {
+		params["sslcert"] = settings.CertFile
+		params["sslkey"] = settings.KeyFile
+	}
+	return params, nil
+}
+
+// quoteDSNValue quotes a connection string value that is empty or contains
+// spaces, quotes or backslashes
+func quoteDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
 // Close closes the database connection
 func (d *Driver) Close() error {
 	if d.db != nil && d.ownsConnection {
@@ -113,8 +193,14 @@ func (d *Driver) QueryRow(ctx context.Context, query string, args ...interface{}
 	return d.db.QueryRowContext(ctx, query, args...)
 }
 
-// CreateTable creates a new table
+// CreateTable creates the enum types of a new table and the table
 func (d *Driver) CreateTable(ctx context.Context, table *schema.Table) error {
+	for _, stmt := range d.grammar.CompileCreateTypes(table) {
+		if _, err := d.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("postgres: failed to create type of table %s: %w", table.Name, err)
+		}
+	}
+
 	sql := d.grammar.CompileCreate(table)
 	_, err := d.db.ExecContext(ctx, sql)
 	if err != nil {
@@ -136,7 +222,7 @@ func (d *Driver) CreateTable(ctx context.Context, table *schema.Table) error {
 
 // AlterTable modifies an existing table
 func (d *Driver) AlterTable(ctx context.Context, table *schema.Table) error {
-	statements := d.grammar.CompileAlter(table)
+	statements := append(d.grammar.CompileAlterTypes(table), d.grammar.CompileAlter(table)...)
 	for _, stmt := range statements {
 		if _, err := d.db.ExecContext(ctx, stmt); err != nil {
 			return fmt.Errorf("postgres: failed to alter table %s: %w", table.Name, err)
@@ -152,7 +238,7 @@ func (d *Driver) DropTable(ctx context.Context, name string) error {
 	if err != nil {
 		return fmt.Errorf("postgres: failed to drop table %s: %w", name, err)
 	}
-	return nil
+	return d.dropTypes(ctx, name)
 }
 
 // DropTableIfExists drops a table if it exists
@@ -162,6 +248,16 @@ func (d *Driver) DropTableIfExists(ctx context.Context, name string) error {
 	if err != nil {
 		return fmt.Errorf("postgres: failed to drop table %s: %w", name, err)
 	}
+	return d.dropTypes(ctx, name)
+}
+
+// dropTypes drops the enum types created for a dropped table
+func (d *Driver) dropTypes(ctx context.Context, name string) error {
+	for _, stmt := range d.grammar.CompileDropTypes(name) {
+		if _, err := d.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("postgres: failed to drop types of table %s: %w", name, err)
+		}
+	}
 	return nil
 }
 
@@ -186,16 +282,46 @@ func (d *Driver) RenameTable(ctx context.Context, from, to string) error {
 	if err != nil {
 		return fmt.Errorf("postgres: failed to rename table %s to %s: %w", from, to, err)
 	}
+	for _, stmt := range d.grammar.CompileRenameTypes(from, to) {
+		if _, err := d.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("postgres: failed to rename types of table %s: %w", from, err)
+		}
+	}
 	return nil
 }
 
-// CreateMigrationsTable creates the migrations tracking table
+// CreateMigrationsTable creates the migrations tracking table, and the schema
+// of a schema-qualified table name
 func (d *Driver) CreateMigrationsTable(ctx context.Context, tableName string) error {
+	if schemaName, _ := schema.SplitTableName(tableName); schemaName != "" {
+		if _, err := d.db.ExecContext(ctx, d.grammar.compileCreateSchemaIfNotExists(schemaName)); err != nil {
+			return fmt.Errorf("postgres: failed to create schema %s: %w", schemaName, err)
+		}
+	}
+
 	sql := d.grammar.CompileCreateMigrationsTable(tableName)
 	_, err := d.db.ExecContext(ctx, sql)
 	if err != nil {
 		return fmt.Errorf("postgres: failed to create migrations table: %w", err)
 	}
+	return d.upgradeMigrationsTable(ctx, tableName)
+}
+
+// upgradeMigrationsTable adds the columns missing from a migrations table
+// created by an older version
+func (d *Driver) upgradeMigrationsTable(ctx context.Context, tableName string) error {
+	for _, col := range d.grammar.migrationsTableUpgrades() {
+		var count int
+		if err := d.db.QueryRowContext(ctx, d.grammar.compileHasColumn(), append(tableArgs(tableName), col.name)...).Scan(&count); err != nil {
+			return fmt.Errorf("postgres: failed to inspect migrations table: %w", err)
+		}
+		if count > 0 {
+			continue
+		}
+		if _, err := d.db.ExecContext(ctx, d.grammar.compileAddMigrationsColumn(tableName, col)); err != nil {
+			return fmt.Errorf("postgres: failed to add column %s to migrations table: %w", col.name, err)
+		}
+	}
 	return nil
 }
 
@@ -211,9 +337,12 @@ func (d *Driver) GetExecutedMigrations(ctx context.Context, tableName string) ([
 	var records []driver.MigrationRecord
 	for rows.Next() {
 		var r driver.MigrationRecord
-		if err := rows.Scan(&r.ID, &r.Migration, &r.Batch, &r.ExecutedAt); err != nil {
+		var durationMS int64
+		if err := rows.Scan(&r.ID, &r.Migration, &r.Batch, &r.ExecutedAt, &r.Checksum,
+			&durationMS, &r.Host, &r.Version, &r.User, &r.Note); err != nil {
 			return nil, fmt.Errorf("postgres: failed to scan migration record: %w", err)
 		}
+		r.Duration = time.Duration(durationMS) * time.Millisecond
 		records = append(records, r)
 	}
 
@@ -225,9 +354,9 @@ func (d *Driver) GetExecutedMigrations(ctx context.Context, tableName string) ([
 }
 
 // RecordMigration records a migration execution
-func (d *Driver) RecordMigration(ctx context.Context, tableName, migration string, batch int) error {
+func (d *Driver) RecordMigration(ctx context.Context, tableName string, record driver.MigrationRecord) error {
 	sql := d.grammar.CompileInsertMigration(tableName)
-	_, err := d.db.ExecContext(ctx, sql, migration, batch)
+	_, err := d.db.ExecContext(ctx, sql, record.InsertArgs()...)
 	if err != nil {
 		return fmt.Errorf("postgres: failed to record migration: %w", err)
 	}
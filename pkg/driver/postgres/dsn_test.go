@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/flyits/migro/pkg/driver"
+)
+
+// 测试目标：验证连接选项覆盖 Connect 的默认参数、TLS 配置的映射以及特殊字符的引用
+func TestBuildDSN(t *testing.T) {
+	config := &driver.Config{
+		Host:     "db",
+		Port:     5432,
+		Database: "app",
+		Username: "app",
+		Password: "secret",
+	}
+	assertDSN := func(want string) {
+		t.Helper()
+		got, err := buildDSN(config)
+		if err != nil {
+			t.Fatalf("buildDSN failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	}
+
+	assertDSN("host=db port=5432 user=app password=secret dbname=app sslmode=disable")
+
+	config.Password = `it's a \secret`
+	config.Options = map[string]string{"sslmode": "require", "connect_timeout": "5"}
+	assertDSN(`host=db port=5432 user=app password='it\'s a \\secret' dbname=app connect_timeout=5 sslmode=require`)
+
+	config.Password = ""
+	config.Options = nil
+	assertDSN("host=db port=5432 user=app password='' dbname=app sslmode=disable")
+
+	config.TLS = driver.TLSConfig{CAFile: "/etc/ssl/ca.pem", CertFile: "/etc/ssl/client.pem", KeyFile: "/etc/ssl/client.key"}
+	assertDSN("host=db port=5432 user=app password='' dbname=app sslcert=/etc/ssl/client.pem sslkey=/etc/ssl/client.key sslmode=verify-full sslrootcert=/etc/ssl/ca.pem")
+
+	// 连接选项优先于 TLS 配置
+	config.Options = map[string]string{"sslmode": "verify-ca"}
+	assertDSN("host=db port=5432 user=app password='' dbname=app sslcert=/etc/ssl/client.pem sslkey=/etc/ssl/client.key sslmode=verify-ca sslrootcert=/etc/ssl/ca.pem")
+
+	// server_name 需要注册到 lib/pq 的自定义 TLS 配置
+	config.Options = nil
+	config.TLS = driver.TLSConfig{Mode: driver.TLSRequire, ServerName: "db.internal"}
+	assertDSN("host=db port=5432 user=app password='' dbname=app sslmode=pqgo-" + config.TLS.Key())
+
+	// schema 设置 search_path，连接选项优先
+	config.TLS = driver.TLSConfig{}
+	config.Schema = "billing, public"
+	assertDSN("host=db port=5432 user=app password='' dbname=app search_path='billing, public' sslmode=disable")
+	config.Options = map[string]string{"search_path": "reporting"}
+	assertDSN("host=db port=5432 user=app password='' dbname=app search_path=reporting sslmode=disable")
+
+	config.TLS = driver.TLSConfig{Mode: "verify-full", CertFile: "/etc/ssl/client.pem"}
+	if _, err := buildDSN(config); err == nil {
+		t.Error("expected an error for a certificate without key")
+	}
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/flyits/migro/pkg/driver"
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// Ensure Driver implements driver.SchemaDumper
+var _ driver.SchemaDumper = (*Driver)(nil)
+
+// DumpSchema compiles the inspected tables of every schema back to
+// CREATE SCHEMA, CREATE TYPE, CREATE TABLE with CHECK constraints, CREATE
+// INDEX and COMMENT ON COLUMN statements, with referenced tables first.
+// Tables outside the current schema are schema-qualified. Enum types are
+// named after their table and column. Objects the inspector does not read,
+// such as views, functions and triggers, are not part of the dump.
+func (d *Driver) DumpSchema(ctx context.Context, q driver.Queryer, exclude ...string) ([]string, error) {
+	names, schemas, err := d.dumpTables(ctx, q, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]*schema.Table, 0, len(names))
+	for _, name := range names {
+		table, err := driver.InspectTable(ctx, d, q, name)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	var statements []string
+	for _, name := range schemas {
+		statements = append(statements, d.grammar.compileCreateSchemaIfNotExists(name))
+	}
+	for _, table := range driver.SortByDependencies(tables) {
+		statements = append(statements, d.grammar.CompileCreateTypes(table)...)
+		statements = append(statements, d.grammar.CompileCreate(table))
+		for _, idx := range table.Indexes {
+			if idx.Type != schema.IndexTypePrimary {
+				statements = append(statements, d.grammar.CompileIndex(table.Name, idx))
+			}
+		}
+		for _, col := range table.Columns {
+			if col.ColumnComment != "" {
+				statements = append(statements, d.grammar.compileColumnComment(table.Name, col))
+			}
+		}
+	}
+	return statements, nil
+}
+
+// dumpTables returns the names of the tables to dump, qualified outside the
+// current schema, and the other schemas they are in. Excluded names match
+// with or without their schema.
+func (d *Driver) dumpTables(ctx context.Context, q driver.Queryer, exclude []string) ([]string, []string, error) {
+	skip := make(map[string]bool, len(exclude))
+	for _, name := range exclude {
+		skip[name] = true
+	}
+
+	var names, schemas []string
+	seen := make(map[string]bool)
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		var schemaName, table string
+		var current bool
+		if err := rows.Scan(&schemaName, &table, &current); err != nil {
+			return err
+		}
+		qualified := schema.JoinTableName(schemaName, table)
+		if skip[qualified] || (current && skip[table]) {
+			return nil
+		}
+		if current {
+			names = append(names, table)
+			return nil
+		}
+		names = append(names, qualified)
+		if !seen[schemaName] {
+			seen[schemaName] = true
+			schemas = append(schemas, schemaName)
+		}
+		return nil
+	}, d.grammar.compileGetDumpTables())
+	if err != nil {
+		return nil, nil, fmt.Errorf("postgres: failed to read tables: %w", err)
+	}
+	return names, schemas, nil
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/flyits/migro/pkg/driver"
 	"github.com/flyits/migro/pkg/schema"
 )
 
@@ -26,6 +27,14 @@ func validateIdentifier(name string) error {
 	return nil
 }
 
+// Ensure Grammar implements driver.SchemaCompiler, driver.TypeCompiler and
+// driver.TableValidator
+var (
+	_ driver.SchemaCompiler = (*Grammar)(nil)
+	_ driver.TypeCompiler   = (*Grammar)(nil)
+	_ driver.TableValidator = (*Grammar)(nil)
+)
+
 // Grammar implements the PostgreSQL SQL dialect
 type Grammar struct{}
 
@@ -48,7 +57,7 @@ func (g *Grammar) CompileCreate(table *schema.Table) string {
 	// Columns
 	columns := make([]string, 0, len(table.Columns))
 	for _, col := range table.Columns {
-		columns = append(columns, "  "+g.CompileColumn(col))
+		columns = append(columns, "  "+g.compileColumn(table.Name, col))
 	}
 
 	// Primary key from columns marked as primary
@@ -67,6 +76,11 @@ func (g *Grammar) CompileCreate(table *schema.Table) string {
 		columns = append(columns, "  "+g.compileForeignKeyInline(fk))
 	}
 
+	// Check constraints
+	for _, check := range table.Checks {
+		columns = append(columns, "  "+g.compileCheckInline(check))
+	}
+
 	sb.WriteString(strings.Join(columns, ",\n"))
 	sb.WriteString("\n)")
 
@@ -83,9 +97,14 @@ func (g *Grammar) CompileAlter(table *schema.Table) []string {
 		statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", tableName, g.wrap(fkName)))
 	}
 
+	// Drop check constraints
+	for _, checkName := range table.DropChecks {
+		statements = append(statements, g.CompileDropCheck(table.Name, checkName))
+	}
+
 	// Drop indexes
 	for _, idxName := range table.DropIndexes {
-		statements = append(statements, fmt.Sprintf("DROP INDEX %s", g.wrap(idxName)))
+		statements = append(statements, g.CompileDropIndex(table.Name, idxName))
 	}
 
 	// Drop columns
@@ -100,7 +119,21 @@ func (g *Grammar) CompileAlter(table *schema.Table) []string {
 
 	// Add/modify columns
 	for _, col := range table.Columns {
-		if col.Change {
+		if col.Change && col.Type == schema.TypeEnum {
+			// The current default may not cast to the enum type
+			enumType := g.enumType(table.Name, col.Name)
+			statements = append(statements,
+				fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT", tableName, g.wrap(col.Name)),
+				fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::text::%s", tableName, g.wrap(col.Name), enumType, g.wrap(col.Name), enumType))
+			if col.IsNullable {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", tableName, g.wrap(col.Name)))
+			} else {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL", tableName, g.wrap(col.Name)))
+			}
+			if col.DefaultValue != nil {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s", tableName, g.wrap(col.Name), g.formatDefault(col.DefaultValue)))
+			}
+		} else if col.Change {
 			// PostgreSQL requires separate statements for each change
 			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s", tableName, g.wrap(col.Name), g.getColumnType(col)))
 			if col.IsNullable {
@@ -112,7 +145,10 @@ func (g *Grammar) CompileAlter(table *schema.Table) []string {
 				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s", tableName, g.wrap(col.Name), g.formatDefault(col.DefaultValue)))
 			}
 		} else {
-			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", tableName, g.CompileColumn(col)))
+			if col.Type == schema.TypeEnum {
+				statements = append(statements, g.compileCreateEnum(table.Name, col)...)
+			}
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", tableName, g.compileColumn(table.Name, col)))
 		}
 	}
 
@@ -126,6 +162,11 @@ func (g *Grammar) CompileAlter(table *schema.Table) []string {
 		statements = append(statements, g.CompileForeignKey(table.Name, fk))
 	}
 
+	// Add check constraints
+	for _, check := range table.Checks {
+		statements = append(statements, g.CompileCheck(table.Name, check))
+	}
+
 	return statements
 }
 
@@ -139,18 +180,257 @@ func (g *Grammar) CompileDropIfExists(name string) string {
 	return fmt.Sprintf("DROP TABLE IF EXISTS %s", g.wrapTable(name))
 }
 
-// CompileRename generates ALTER TABLE RENAME SQL
+// CompileRename generates ALTER TABLE RENAME SQL. The table stays in its
+// schema, the schema of to is ignored.
 func (g *Grammar) CompileRename(from, to string) string {
-	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s", g.wrapTable(from), g.wrapTable(to))
+	_, table := schema.SplitTableName(to)
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s", g.wrapTable(from), g.wrap(table))
 }
 
-// CompileHasTable generates SQL to check if table exists
+// CompileHasTable generates SQL to check if table exists in its schema, or in
+// the current schema for unqualified names.
 // The table name is validated to prevent SQL injection
 func (g *Grammar) CompileHasTable(name string) (string, error) {
-	if err := validateIdentifier(name); err != nil {
+	schemaName, table := schema.SplitTableName(name)
+	if err := validateIdentifier(table); err != nil {
 		return "", fmt.Errorf("invalid table name: %w", err)
 	}
-	return fmt.Sprintf("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public' AND table_name = '%s'", name), nil
+	if schemaName == "" {
+		return fmt.Sprintf("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = '%s'", table), nil
+	}
+	if err := validateIdentifier(schemaName); err != nil {
+		return "", fmt.Errorf("invalid schema name: %w", err)
+	}
+	return fmt.Sprintf("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = '%s' AND table_name = '%s'", schemaName, table), nil
+}
+
+// CompileCreateSchema generates CREATE SCHEMA SQL
+func (g *Grammar) CompileCreateSchema(name string) string {
+	return fmt.Sprintf("CREATE SCHEMA %s", g.wrap(name))
+}
+
+// CompileDropSchema generates DROP SCHEMA SQL
+func (g *Grammar) CompileDropSchema(name string) string {
+	return fmt.Sprintf("DROP SCHEMA %s", g.wrap(name))
+}
+
+// compileCreateSchemaIfNotExists generates SQL creating the schema of the
+// migrations table or of dumped tables
+func (g *Grammar) compileCreateSchemaIfNotExists(name string) string {
+	return fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", g.wrap(name))
+}
+
+// CompileCreateTypes generates the CREATE TYPE statements of the enum
+// columns of a new table. The types are named after the table and the column,
+// e.g. posts_status, and marked with a comment so that CompileDropTypes finds
+// them.
+func (g *Grammar) CompileCreateTypes(table *schema.Table) []string {
+	var statements []string
+	for _, col := range table.Columns {
+		if col.Type == schema.TypeEnum {
+			statements = append(statements, g.compileCreateEnum(table.Name, col)...)
+		}
+	}
+	return statements
+}
+
+// CompileAlterTypes generates the statements updating the enum types of the
+// changed columns of a table. The type is created if the column was not an
+// enum before, and the values it lacks are added after the value preceding
+// them. Values are never removed, PostgreSQL cannot drop enum values.
+func (g *Grammar) CompileAlterTypes(table *schema.Table) []string {
+	var statements []string
+	for _, col := range table.Columns {
+		if !col.Change || col.Type != schema.TypeEnum {
+			continue
+		}
+		create := g.compileCreateEnum(table.Name, col)
+		statements = append(statements, fmt.Sprintf("DO $$\nBEGIN\n  %s;\n  %s;\nEXCEPTION WHEN duplicate_object THEN NULL;\nEND $$",
+			create[0], create[1]))
+
+		enumType := g.enumType(table.Name, col.Name)
+		for i, value := range col.Values {
+			stmt := fmt.Sprintf("ALTER TYPE %s ADD VALUE IF NOT EXISTS %s", enumType, g.formatDefault(value))
+			if i > 0 {
+				stmt += " AFTER " + g.formatDefault(col.Values[i-1])
+			}
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
+
+// CompileDropTypes generates a statement dropping the enum types created
+// for a table, run after the table is dropped
+func (g *Grammar) CompileDropTypes(table string) []string {
+	schemaName, name := schema.SplitTableName(table)
+	namespace := "current_schema()"
+	if schemaName != "" {
+		namespace = g.formatDefault(schemaName)
+	}
+	return []string{fmt.Sprintf(`DO $$
+DECLARE
+  t regtype;
+BEGIN
+  FOR t IN SELECT p.oid::regtype FROM pg_catalog.pg_type p
+    JOIN pg_catalog.pg_namespace n ON n.oid = p.typnamespace
+    JOIN pg_catalog.pg_description d ON d.objoid = p.oid AND d.classoid = 'pg_catalog.pg_type'::regclass
+    WHERE p.typtype = 'e' AND n.nspname = %s AND d.description = %s
+  LOOP
+    EXECUTE 'DROP TYPE ' || t;
+  END LOOP;
+END $$`, namespace, g.formatDefault(enumComment(name)))}
+}
+
+// CompileRenameTypes generates a statement renaming the enum types created
+// for a renamed table after it and marking them as created for it
+func (g *Grammar) CompileRenameTypes(from, to string) []string {
+	schemaName, fromName := schema.SplitTableName(from)
+	_, toName := schema.SplitTableName(to)
+	namespace := "current_schema()"
+	if schemaName != "" {
+		namespace = g.formatDefault(schemaName)
+	}
+	return []string{fmt.Sprintf(`DO $$
+DECLARE
+  t record;
+BEGIN
+  FOR t IN SELECT p.oid::regtype AS oid, p.typname FROM pg_catalog.pg_type p
+    JOIN pg_catalog.pg_namespace n ON n.oid = p.typnamespace
+    JOIN pg_catalog.pg_description d ON d.objoid = p.oid AND d.classoid = 'pg_catalog.pg_type'::regclass
+    WHERE p.typtype = 'e' AND n.nspname = %s AND d.description = %s
+  LOOP
+    EXECUTE format('COMMENT ON TYPE %%s IS %%L', t.oid, %s);
+    IF starts_with(t.typname, %[4]s) THEN
+      EXECUTE format('ALTER TYPE %%s RENAME TO %%I', t.oid, %[5]s || substr(t.typname, length(%[4]s) + 1));
+    END IF;
+  END LOOP;
+END $$`, namespace, g.formatDefault(enumComment(fromName)), g.formatDefault(enumComment(toName)),
+		g.formatDefault(fromName+"_"), g.formatDefault(toName+"_"))}
+}
+
+// compileCreateEnum generates the statements creating the enum type of a
+// column and marking it as created for the table
+func (g *Grammar) compileCreateEnum(table string, col *schema.Column) []string {
+	_, name := schema.SplitTableName(table)
+	enumType := g.enumType(table, col.Name)
+	values := make([]string, len(col.Values))
+	for i, value := range col.Values {
+		values[i] = g.formatDefault(value)
+	}
+	return []string{
+		fmt.Sprintf("CREATE TYPE %s AS ENUM (%s)", enumType, strings.Join(values, ", ")),
+		fmt.Sprintf("COMMENT ON TYPE %s IS %s", enumType, g.formatDefault(enumComment(name))),
+	}
+}
+
+// enumType returns the quoted name of the enum type of a column, created in
+// the schema of the table
+func (g *Grammar) enumType(table, column string) string {
+	schemaName, name := schema.SplitTableName(table)
+	if name == "" {
+		return g.wrap(column)
+	}
+	return g.wrapTable(schema.JoinTableName(schemaName, name+"_"+column))
+}
+
+// enumComment returns the comment marking the enum types created for a table
+func enumComment(table string) string {
+	return "migro:" + table
+}
+
+// compileGetTables returns a query listing the tables of the current schema
+func (g *Grammar) compileGetTables() string {
+	return "SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name"
+}
+
+// compileGetDumpTables returns a query listing the tables of every schema
+// except the system schemas, and whether they are in the current schema.
+// Tables created by extensions are left out.
+func (g *Grammar) compileGetDumpTables() string {
+	return `SELECT n.nspname, c.relname, n.nspname = current_schema()
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p') AND n.nspname <> 'information_schema' AND n.nspname NOT LIKE 'pg\_%'
+  AND NOT EXISTS (SELECT 1 FROM pg_catalog.pg_depend d
+    WHERE d.classid = 'pg_catalog.pg_class'::regclass AND d.objid = c.oid AND d.deptype = 'e')
+ORDER BY n.nspname <> current_schema(), n.nspname, c.relname`
+}
+
+// compileColumnComment returns a COMMENT ON COLUMN statement
+func (g *Grammar) compileColumnComment(table string, col *schema.Column) string {
+	return fmt.Sprintf("COMMENT ON COLUMN %s.%s IS '%s'",
+		g.wrapTable(table), g.wrap(col.Name), strings.ReplaceAll(col.ColumnComment, "'", "''"))
+}
+
+// compileGetColumns returns a query reading the columns of a table, the
+// values of their enum types and the expression of generated columns. The
+// arguments are the schema, empty for the current schema, and the table.
+func (g *Grammar) compileGetColumns() string {
+	return `SELECT c.column_name, c.data_type, c.udt_name, c.character_maximum_length, c.numeric_precision, c.numeric_scale,
+  c.is_nullable, c.column_default, c.is_identity, pg_catalog.col_description(t.oid, c.ordinal_position::int),
+  a.attgenerated::text, c.generation_expression,
+  ARRAY(SELECT e.enumlabel FROM pg_catalog.pg_enum e
+    JOIN pg_catalog.pg_type ty ON ty.oid = e.enumtypid
+    JOIN pg_catalog.pg_namespace tn ON tn.oid = ty.typnamespace
+    WHERE ty.typname = c.udt_name AND tn.nspname = c.udt_schema ORDER BY e.enumsortorder)
+FROM information_schema.columns c
+JOIN pg_catalog.pg_namespace n ON n.nspname = c.table_schema
+JOIN pg_catalog.pg_class t ON t.relname = c.table_name AND t.relnamespace = n.oid
+JOIN pg_catalog.pg_attribute a ON a.attrelid = t.oid AND a.attname = c.column_name
+WHERE c.table_schema = COALESCE(NULLIF($1, ''), current_schema()) AND c.table_name = $2
+ORDER BY c.ordinal_position`
+}
+
+// compileGetIndexes returns a query reading the index keys of a table, with
+// the same arguments as compileGetColumns. The column name is NULL for
+// expressions, whose definition is read with pg_get_indexdef. Each row also
+// carries the descending flag of the key, the predicate of partial indexes
+// and the index method.
+func (g *Grammar) compileGetIndexes() string {
+	return `SELECT i.relname, ix.indisunique, ix.indisprimary, a.attname,
+  pg_catalog.pg_get_indexdef(ix.indexrelid, k.ord::int, true),
+  (ix.indoption[k.ord::int - 1] & 1) = 1,
+  pg_catalog.pg_get_expr(ix.indpred, ix.indrelid, true), am.amname
+FROM pg_catalog.pg_index ix
+JOIN pg_catalog.pg_class t ON t.oid = ix.indrelid
+JOIN pg_catalog.pg_class i ON i.oid = ix.indexrelid
+JOIN pg_catalog.pg_am am ON am.oid = i.relam
+JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace
+CROSS JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord)
+LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum AND k.attnum > 0
+WHERE n.nspname = COALESCE(NULLIF($1, ''), current_schema()) AND t.relname = $2
+ORDER BY i.relname, k.ord`
+}
+
+// compileGetChecks returns a query reading the CHECK constraints of a table,
+// with the same arguments as compileGetColumns
+func (g *Grammar) compileGetChecks() string {
+	return `SELECT c.conname, pg_catalog.pg_get_constraintdef(c.oid, true)
+FROM pg_catalog.pg_constraint c
+JOIN pg_catalog.pg_class t ON t.oid = c.conrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace
+WHERE c.contype = 'c' AND n.nspname = COALESCE(NULLIF($1, ''), current_schema()) AND t.relname = $2
+ORDER BY c.conname`
+}
+
+// compileGetForeignKeys returns a query reading the foreign key columns of a
+// table, with the same arguments as compileGetColumns. Referenced tables of
+// another schema are schema-qualified.
+func (g *Grammar) compileGetForeignKeys() string {
+	return `SELECT c.conname, a.attname,
+  CASE WHEN rn.oid = n.oid THEN rt.relname ELSE rn.nspname || '.' || rt.relname END,
+  ra.attname, c.confupdtype, c.confdeltype
+FROM pg_catalog.pg_constraint c
+JOIN pg_catalog.pg_class t ON t.oid = c.conrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace
+JOIN pg_catalog.pg_class rt ON rt.oid = c.confrelid
+JOIN pg_catalog.pg_namespace rn ON rn.oid = rt.relnamespace
+CROSS JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, refnum, ord)
+JOIN pg_catalog.pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+JOIN pg_catalog.pg_attribute ra ON ra.attrelid = c.confrelid AND ra.attnum = k.refnum
+WHERE c.contype = 'f' AND n.nspname = COALESCE(NULLIF($1, ''), current_schema()) AND t.relname = $2
+ORDER BY c.conname, k.ord`
 }
 
 // Type mappings
@@ -226,8 +506,14 @@ func (g *Grammar) TypeUUID() string {
 	return "UUID"
 }
 
-// CompileColumn generates column definition SQL
+// CompileColumn generates column definition SQL. Without the table, the
+// type of an enum column is named after the column only.
 func (g *Grammar) CompileColumn(col *schema.Column) string {
+	return g.compileColumn("", col)
+}
+
+// compileColumn generates the definition of a column of table
+func (g *Grammar) compileColumn(table string, col *schema.Column) string {
 	var sb strings.Builder
 
 	sb.WriteString(g.wrap(col.Name))
@@ -243,7 +529,21 @@ func (g *Grammar) CompileColumn(col *schema.Column) string {
 		return sb.String()
 	}
 
-	sb.WriteString(g.getColumnType(col))
+	if col.Type == schema.TypeEnum {
+		sb.WriteString(g.enumType(table, col.Name))
+	} else {
+		sb.WriteString(g.getColumnType(col))
+	}
+
+	if col.GeneratedAs != "" {
+		// ValidateTable rejects virtual columns, which only inspected
+		// PostgreSQL 18 tables have
+		kind := "STORED"
+		if !col.IsStored {
+			kind = "VIRTUAL"
+		}
+		fmt.Fprintf(&sb, " GENERATED ALWAYS AS (%s) %s", col.GeneratedAs, kind)
+	}
 
 	if !col.IsNullable {
 		sb.WriteString(" NOT NULL")
@@ -261,6 +561,36 @@ func (g *Grammar) CompileColumn(col *schema.Column) string {
 	return sb.String()
 }
 
+// ValidateTable returns an error for indexes PostgreSQL cannot create and
+// for generated columns it rejects: virtual columns, columns with a default
+// value or an identity, and changes of generated columns, which
+// ALTER COLUMN ... TYPE cannot express
+func (g *Grammar) ValidateTable(table *schema.Table) error {
+	for _, idx := range table.Indexes {
+		if err := g.validateIndex(table.Name, idx); err != nil {
+			return err
+		}
+	}
+	for _, col := range table.Columns {
+		if col.GeneratedAs == "" {
+			continue
+		}
+		if !col.IsStored {
+			return fmt.Errorf("generated column %s: PostgreSQL does not support virtual generated columns, use StoredAs", col.Name)
+		}
+		if col.DefaultValue != nil {
+			return fmt.Errorf("generated column %s cannot have a default value", col.Name)
+		}
+		if col.IsAutoIncrement {
+			return fmt.Errorf("generated column %s cannot be auto-incrementing", col.Name)
+		}
+		if col.Change {
+			return fmt.Errorf("generated column %s cannot be changed, drop it and add it again", col.Name)
+		}
+	}
+	return nil
+}
+
 func (g *Grammar) getColumnType(col *schema.Column) string {
 	switch col.Type {
 	case schema.TypeString:
@@ -309,21 +639,74 @@ func (g *Grammar) CompileIndex(tableName string, idx *schema.Index) string {
 		indexName = g.generateIndexName(tableName, idx.Columns, idx.Type)
 	}
 
-	cols := make([]string, len(idx.Columns))
-	for i, col := range idx.Columns {
-		cols[i] = g.wrap(col)
+	var sb strings.Builder
+	sb.WriteString("CREATE ")
+	if idx.Type == schema.IndexTypeUnique {
+		sb.WriteString("UNIQUE ")
+	}
+	fmt.Fprintf(&sb, "INDEX %s ON %s", g.wrap(indexName), g.wrapTable(tableName))
+	if idx.Method != "" {
+		sb.WriteString(" USING ")
+		sb.WriteString(strings.ToLower(idx.Method))
+	}
+	fmt.Fprintf(&sb, " (%s)", g.compileIndexKeys(idx))
+	if idx.Predicate != "" {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(idx.Predicate)
+	}
+	return sb.String()
+}
+
+// compileIndexKeys generates the columns and expressions of an index
+func (g *Grammar) compileIndexKeys(idx *schema.Index) string {
+	keys := idx.Keys()
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		part := "(" + key.Expression + ")"
+		if key.Column != "" {
+			part = g.wrap(key.Column)
+		}
+		if key.Order != "" {
+			part += " " + key.Order
+		}
+		parts[i] = part
 	}
+	return strings.Join(parts, ", ")
+}
 
-	switch idx.Type {
-	case schema.IndexTypeUnique:
-		return fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s)", g.wrap(indexName), g.wrapTable(tableName), strings.Join(cols, ", "))
+// validateIndex returns an error for unnamed indexes on expressions, prefix
+// lengths and unknown index methods
+func (g *Grammar) validateIndex(tableName string, idx *schema.Index) error {
+	if idx.HasExpressions() && idx.Name == "" {
+		return fmt.Errorf("index on expressions of table %s must be named", tableName)
+	}
+	name := idx.Name
+	if name == "" {
+		name = g.generateIndexName(tableName, idx.Columns, idx.Type)
+	}
+	for _, key := range idx.Keys() {
+		if key.Length > 0 {
+			return fmt.Errorf("index %s: PostgreSQL does not support prefix lengths, index an expression such as left(%s, %d) instead", name, key.Column, key.Length)
+		}
+	}
+	switch method := strings.ToLower(idx.Method); method {
+	case "", "btree":
+	case "hash", "gist", "spgist", "gin", "brin":
+		if idx.Type == schema.IndexTypeUnique {
+			return fmt.Errorf("index %s: PostgreSQL only supports unique indexes with the btree method", name)
+		}
 	default:
-		return fmt.Sprintf("CREATE INDEX %s ON %s (%s)", g.wrap(indexName), g.wrapTable(tableName), strings.Join(cols, ", "))
+		return fmt.Errorf("index %s: unsupported index method %s (supported: btree, hash, gist, spgist, gin, brin)", name, idx.Method)
 	}
+	return nil
 }
 
-// CompileDropIndex generates DROP INDEX SQL
+// CompileDropIndex generates DROP INDEX SQL. Indexes live in the schema of
+// their table.
 func (g *Grammar) CompileDropIndex(tableName, indexName string) string {
+	if schemaName, _ := schema.SplitTableName(tableName); schemaName != "" {
+		return fmt.Sprintf("DROP INDEX %s.%s", g.wrap(schemaName), g.wrap(indexName))
+	}
 	return fmt.Sprintf("DROP INDEX %s", g.wrap(indexName))
 }
 
@@ -361,6 +744,16 @@ func (g *Grammar) CompileDropForeignKey(tableName, fkName string) string {
 	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", g.wrapTable(tableName), g.wrap(fkName))
 }
 
+// CompileCheck generates ADD CONSTRAINT ... CHECK SQL
+func (g *Grammar) CompileCheck(tableName string, check *schema.Check) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD %s", g.wrapTable(tableName), g.compileCheckInline(check))
+}
+
+// CompileDropCheck generates DROP CONSTRAINT SQL
+func (g *Grammar) CompileDropCheck(tableName, checkName string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", g.wrapTable(tableName), g.wrap(checkName))
+}
+
 // Migrations table operations
 
 func (g *Grammar) CompileCreateMigrationsTable(tableName string) string {
@@ -368,16 +761,22 @@ func (g *Grammar) CompileCreateMigrationsTable(tableName string) string {
   id SERIAL PRIMARY KEY,
   migration VARCHAR(255) NOT NULL,
   batch INTEGER NOT NULL,
-  executed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+  executed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  checksum VARCHAR(64) NULL,
+  duration_ms BIGINT NULL,
+  host VARCHAR(255) NULL,
+  migro_version VARCHAR(64) NULL,
+  os_user VARCHAR(255) NULL,
+  note TEXT NULL
 )`, g.wrapTable(tableName))
 }
 
 func (g *Grammar) CompileGetMigrations(tableName string) string {
-	return fmt.Sprintf("SELECT id, migration, batch, executed_at FROM %s ORDER BY batch, migration", g.wrapTable(tableName))
+	return fmt.Sprintf("SELECT id, migration, batch, executed_at, COALESCE(checksum, ''), COALESCE(duration_ms, 0), COALESCE(host, ''), COALESCE(migro_version, ''), COALESCE(os_user, ''), COALESCE(note, '') FROM %s ORDER BY batch, migration", g.wrapTable(tableName))
 }
 
 func (g *Grammar) CompileInsertMigration(tableName string) string {
-	return fmt.Sprintf("INSERT INTO %s (migration, batch) VALUES ($1, $2)", g.wrapTable(tableName))
+	return fmt.Sprintf("INSERT INTO %s (migration, batch, checksum, duration_ms, host, migro_version, os_user, note) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)", g.wrapTable(tableName))
 }
 
 func (g *Grammar) CompileDeleteMigration(tableName string) string {
@@ -388,14 +787,51 @@ func (g *Grammar) CompileGetLastBatch(tableName string) string {
 	return fmt.Sprintf("SELECT COALESCE(MAX(batch), 0) FROM %s", g.wrapTable(tableName))
 }
 
+// migrationsColumn is a column added to the migrations table after its first release
+type migrationsColumn struct {
+	name       string
+	definition string
+}
+
+// migrationsTableUpgrades returns the columns that existing migrations tables may lack
+func (g *Grammar) migrationsTableUpgrades() []migrationsColumn {
+	return []migrationsColumn{
+		{name: "checksum", definition: "VARCHAR(64) NULL"},
+		{name: "duration_ms", definition: "BIGINT NULL"},
+		{name: "host", definition: "VARCHAR(255) NULL"},
+		{name: "migro_version", definition: "VARCHAR(64) NULL"},
+		{name: "os_user", definition: "VARCHAR(255) NULL"},
+		{name: "note", definition: "TEXT NULL"},
+	}
+}
+
+// compileHasColumn returns a query counting the columns of a table with the
+// given schema, empty for the current schema, table and column name
+func (g *Grammar) compileHasColumn() string {
+	return "SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema()) AND table_name = $2 AND column_name = $3"
+}
+
+func (g *Grammar) compileAddMigrationsColumn(tableName string, col migrationsColumn) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", g.wrapTable(tableName), g.wrap(col.name), col.definition)
+}
+
 // Helper methods
 
 func (g *Grammar) wrap(name string) string {
 	return "\"" + name + "\""
 }
 
+// wrapTable quotes a table name, and its schema for schema-qualified names
+// such as billing.invoices
 func (g *Grammar) wrapTable(name string) string {
-	return "\"" + name + "\""
+	if schemaName, table := schema.SplitTableName(name); schemaName != "" {
+		return g.wrap(schemaName) + "." + g.wrap(table)
+	}
+	return g.wrap(name)
+}
+
+func (g *Grammar) compileCheckInline(check *schema.Check) string {
+	return fmt.Sprintf("CONSTRAINT %s CHECK (%s)", g.wrap(check.Name), check.Expression)
 }
 
 func (g *Grammar) compileForeignKeyInline(fk *schema.ForeignKey) string {
@@ -426,6 +862,7 @@ func (g *Grammar) compileForeignKeyInline(fk *schema.ForeignKey) string {
 }
 
 func (g *Grammar) generateIndexName(tableName string, columns []string, indexType schema.IndexType) string {
+	_, tableName = schema.SplitTableName(tableName)
 	suffix := "idx"
 	if indexType == schema.IndexTypeUnique {
 		suffix = "unique"
@@ -434,6 +871,7 @@ func (g *Grammar) generateIndexName(tableName string, columns []string, indexTyp
 }
 
 func (g *Grammar) generateForeignKeyName(tableName string, columns []string) string {
+	_, tableName = schema.SplitTableName(tableName)
 	return fmt.Sprintf("%s_%s_fk", tableName, strings.Join(columns, "_"))
 }
 
@@ -295,9 +295,13 @@ func TestGrammar_CompileInsertMigration(t *testing.T) {
 
 	sql := g.CompileInsertMigration("migrations")
 
-	// PostgreSQL 使用 $1, $2 占位符
-	if !strings.Contains(sql, "$1") || !strings.Contains(sql, "$2") {
-		t.Error("expected PostgreSQL-style placeholders ($1, $2)")
+	// PostgreSQL 使用 $1, $2, $3 占位符
+	if !strings.Contains(sql, "$1") || !strings.Contains(sql, "$2") || !strings.Contains(sql, "$3") {
+		t.Error("expected PostgreSQL-style placeholders ($1, $2, $3)")
+	}
+	// 占位符数量与 MigrationRecord.InsertArgs 一致
+	if !strings.Contains(sql, "$8") || strings.Contains(sql, "$9") {
+		t.Errorf("expected 8 placeholders, got %s", sql)
 	}
 }
 
@@ -779,3 +783,279 @@ func TestGrammar_CompileForeignKey_WithOnUpdate(t *testing.T) {
 		t.Error("expected ON UPDATE CASCADE")
 	}
 }
+
+func TestGrammar_compileColumnComment(t *testing.T) {
+	g := NewGrammar()
+
+	col := &schema.Column{Name: "status", ColumnComment: "the post's status"}
+	sql := g.compileColumnComment("posts", col)
+
+	expected := `COMMENT ON COLUMN "posts"."status" IS 'the post''s status'`
+	if sql != expected {
+		t.Errorf("expected %q, got %q", expected, sql)
+	}
+}
+
+// 测试目标：验证带 schema 的表名（如 billing.invoices）被正确引用，索引和外键名不包含 schema
+func TestGrammar_SchemaQualifiedTables(t *testing.T) {
+	g := NewGrammar()
+
+	table := schema.NewTable("billing.invoices")
+	table.ID()
+	table.BigInteger("customer_id")
+	table.Foreign("customer_id").References("crm.customers", "id")
+	create := g.CompileCreate(table)
+	if !strings.HasPrefix(create, `CREATE TABLE "billing"."invoices" (`) {
+		t.Errorf("expected a qualified table name, got %s", create)
+	}
+	if !strings.Contains(create, `CONSTRAINT "customer_id_fk" FOREIGN KEY ("customer_id") REFERENCES "crm"."customers" ("id")`) {
+		t.Errorf("expected a qualified reference, got %s", create)
+	}
+
+	tests := []struct {
+		name, got, expected string
+	}{
+		{"index", g.CompileIndex("billing.invoices", schema.NewIndex("customer_id")),
+			`CREATE INDEX "invoices_customer_id_idx" ON "billing"."invoices" ("customer_id")`},
+		{"drop index", g.CompileDropIndex("billing.invoices", "invoices_customer_id_idx"),
+			`DROP INDEX "billing"."invoices_customer_id_idx"`},
+		{"foreign key", g.CompileForeignKey("billing.invoices", &schema.ForeignKey{Columns: []string{"customer_id"}, ReferenceTable: "customers", ReferenceColumn: "id"}),
+			`ALTER TABLE "billing"."invoices" ADD CONSTRAINT "invoices_customer_id_fk" FOREIGN KEY ("customer_id") REFERENCES "customers" ("id")`},
+		{"rename", g.CompileRename("billing.invoices", "billing.bills"),
+			`ALTER TABLE "billing"."invoices" RENAME TO "bills"`},
+		{"drop", g.CompileDrop("billing.invoices"), `DROP TABLE "billing"."invoices"`},
+		{"migrations table", g.CompileGetLastBatch("migro.migrations"), `SELECT COALESCE(MAX(batch), 0) FROM "migro"."migrations"`},
+		{"create schema", g.CompileCreateSchema("billing"), `CREATE SCHEMA "billing"`},
+		{"drop schema", g.CompileDropSchema("billing"), `DROP SCHEMA "billing"`},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, tt.got)
+		}
+	}
+
+	t.Run("CompileHasTable", func(t *testing.T) {
+		sql, err := g.CompileHasTable("billing.invoices")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(sql, "table_schema = 'billing' AND table_name = 'invoices'") {
+			t.Errorf("expected the schema in the query, got %s", sql)
+		}
+
+		sql, _ = g.CompileHasTable("invoices")
+		if !strings.Contains(sql, "table_schema = current_schema()") {
+			t.Errorf("expected the current schema for unqualified names, got %s", sql)
+		}
+
+		if _, err := g.CompileHasTable("billing'--.invoices"); err == nil {
+			t.Error("expected an error for an invalid schema name")
+		}
+	})
+}
+
+// 测试目标：验证枚举类型的创建、修改（ADD VALUE）和随表删除
+func TestGrammar_Enum(t *testing.T) {
+	g := NewGrammar()
+
+	table := schema.NewTable("billing.invoices")
+	table.ID()
+	table.Enum("status", "draft", "it's").Default("draft")
+
+	types := g.CompileCreateTypes(table)
+	expected := []string{
+		`CREATE TYPE "billing"."invoices_status" AS ENUM ('draft', 'it''s')`,
+		`COMMENT ON TYPE "billing"."invoices_status" IS 'migro:invoices'`,
+	}
+	if strings.Join(types, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected %v, got %v", expected, types)
+	}
+	if create := g.CompileCreate(table); !strings.Contains(create, `"status" "billing"."invoices_status" NOT NULL DEFAULT 'draft'`) {
+		t.Errorf("expected the enum type in %s", create)
+	}
+
+	t.Run("新增枚举列在 ALTER 中创建类型", func(t *testing.T) {
+		alter := schema.NewTable("posts")
+		alter.Enum("state", "a", "b").Nullable()
+		expected := []string{
+			`CREATE TYPE "posts_state" AS ENUM ('a', 'b')`,
+			`COMMENT ON TYPE "posts_state" IS 'migro:posts'`,
+			`ALTER TABLE "posts" ADD COLUMN "state" "posts_state"`,
+		}
+		if got := g.CompileAlter(alter); strings.Join(got, "|") != strings.Join(expected, "|") {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+		if got := g.CompileAlterTypes(alter); len(got) != 0 {
+			t.Errorf("expected no type statements for a new column, got %v", got)
+		}
+	})
+
+	t.Run("ChangeEnum", func(t *testing.T) {
+		alter := schema.NewTable("posts")
+		alter.ChangeEnum("status", "draft", "published").Default("draft")
+
+		types := g.CompileAlterTypes(alter)
+		if len(types) != 3 {
+			t.Fatalf("expected 3 statements, got %v", types)
+		}
+		if !strings.Contains(types[0], `CREATE TYPE "posts_status" AS ENUM ('draft', 'published');`) ||
+			!strings.Contains(types[0], "EXCEPTION WHEN duplicate_object THEN NULL") {
+			t.Errorf("expected the type to be created if missing, got %s", types[0])
+		}
+		if types[1] != `ALTER TYPE "posts_status" ADD VALUE IF NOT EXISTS 'draft'` ||
+			types[2] != `ALTER TYPE "posts_status" ADD VALUE IF NOT EXISTS 'published' AFTER 'draft'` {
+			t.Errorf("unexpected ADD VALUE statements %v", types[1:])
+		}
+
+		expected := []string{
+			`ALTER TABLE "posts" ALTER COLUMN "status" DROP DEFAULT`,
+			`ALTER TABLE "posts" ALTER COLUMN "status" TYPE "posts_status" USING "status"::text::"posts_status"`,
+			`ALTER TABLE "posts" ALTER COLUMN "status" SET NOT NULL`,
+			`ALTER TABLE "posts" ALTER COLUMN "status" SET DEFAULT 'draft'`,
+		}
+		if got := g.CompileAlter(alter); strings.Join(got, "|") != strings.Join(expected, "|") {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("CompileDropTypes", func(t *testing.T) {
+		drop := g.CompileDropTypes("billing.invoices")
+		if len(drop) != 1 || !strings.Contains(drop[0], "n.nspname = 'billing' AND d.description = 'migro:invoices'") {
+			t.Errorf("unexpected drop statements %v", drop)
+		}
+		if drop := g.CompileDropTypes("posts"); !strings.Contains(drop[0], "n.nspname = current_schema() AND d.description = 'migro:posts'") {
+			t.Errorf("unexpected drop statements %v", drop)
+		}
+	})
+	t.Run("CompileRenameTypes", func(t *testing.T) {
+		rename := g.CompileRenameTypes("billing.invoices", "billing.bills")
+		if len(rename) != 1 {
+			t.Fatalf("expected 1 statement, got %v", rename)
+		}
+		for _, want := range []string{
+			"n.nspname = 'billing' AND d.description = 'migro:invoices'",
+			"EXECUTE format('COMMENT ON TYPE %s IS %L', t.oid, 'migro:bills')",
+			"IF starts_with(t.typname, 'invoices_') THEN",
+			"EXECUTE format('ALTER TYPE %s RENAME TO %I', t.oid, 'bills_' || substr(t.typname, length('invoices_') + 1))",
+		} {
+			if !strings.Contains(rename[0], want) {
+				t.Errorf("expected %q in %s", want, rename[0])
+			}
+		}
+	})
+}
+
+func TestGrammar_Checks(t *testing.T) {
+	g := NewGrammar()
+
+	table := schema.NewTable("billing.products")
+	table.Decimal("price", 10, 2)
+	table.Check("products_price_positive", "price >= 0")
+	sql := g.CompileCreate(table)
+	if !strings.Contains(sql, "  CONSTRAINT \"products_price_positive\" CHECK (price >= 0)\n)") {
+		t.Errorf("expected an inline check in %s", sql)
+	}
+
+	alter := schema.NewTable("billing.products")
+	alter.DropCheck("products_price_positive")
+	alter.Check("products_price_range", "price BETWEEN 0 AND 1000")
+	expected := []string{
+		`ALTER TABLE "billing"."products" DROP CONSTRAINT "products_price_positive"`,
+		`ALTER TABLE "billing"."products" ADD CONSTRAINT "products_price_range" CHECK (price BETWEEN 0 AND 1000)`,
+	}
+	if got := g.CompileAlter(alter); strings.Join(got, ";") != strings.Join(expected, ";") {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestGrammar_GeneratedColumns(t *testing.T) {
+	g := NewGrammar()
+
+	table := schema.NewTable("users")
+	table.Text("search").Nullable().StoredAs("lower(name)")
+	expected := "CREATE TABLE \"users\" (\n  \"search\" TEXT GENERATED ALWAYS AS (lower(name)) STORED\n)"
+	if got := g.CompileCreate(table); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+	if err := g.ValidateTable(table); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		define func(t *schema.Table)
+		want   string
+	}{
+		{"虚拟生成列", func(t *schema.Table) { t.Text("search").VirtualAs("lower(name)") }, "virtual"},
+		{"带默认值", func(t *schema.Table) { t.Text("search").StoredAs("lower(name)").Default("") }, "default"},
+		{"修改生成列", func(t *schema.Table) { t.ChangeText("search").StoredAs("upper(name)") }, "cannot be changed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := schema.NewTable("users")
+			tt.define(table)
+			err := g.ValidateTable(table)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestGrammar_IndexOptions(t *testing.T) {
+	g := NewGrammar()
+
+	tests := []struct {
+		name     string
+		index    *schema.Index
+		expected string
+	}{
+		{
+			"部分唯一索引",
+			schema.NewIndex("email").Unique().Where("deleted_at IS NULL"),
+			`CREATE UNIQUE INDEX "users_email_unique" ON "users" ("email") WHERE deleted_at IS NULL`,
+		},
+		{
+			"表达式索引",
+			schema.NewIndex().Named("users_email_lower_idx").Expression("lower(email)"),
+			`CREATE INDEX "users_email_lower_idx" ON "users" ((lower(email)))`,
+		},
+		{
+			"索引方法",
+			schema.NewIndex("tags").Using("GIN"),
+			`CREATE INDEX "users_tags_idx" ON "users" USING gin ("tags")`,
+		},
+		{
+			"列排序",
+			schema.NewIndex("team_id", "created_at").Desc("created_at"),
+			`CREATE INDEX "users_team_id_created_at_idx" ON "users" ("team_id", "created_at" DESC)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.CompileIndex("users", tt.index); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+
+	invalid := []struct {
+		name  string
+		index func(t *schema.Table)
+		want  string
+	}{
+		{"前缀长度", func(t *schema.Table) { t.Index("name").Length("name", 10) }, "prefix lengths"},
+		{"唯一的 gin 索引", func(t *schema.Table) { t.Unique("tags").Using("gin") }, "btree"},
+		{"未知的索引方法", func(t *schema.Table) { t.Index("tags").Using("fulltext") }, "unsupported index method"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			table := schema.NewTable("users")
+			tt.index(table)
+			err := g.ValidateTable(table)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/flyits/migro/pkg/driver"
+	"github.com/flyits/migro/pkg/schema"
+	"github.com/lib/pq"
+)
+
+// Ensure Driver implements driver.Inspector and driver.CheckInspector
+var (
+	_ driver.Inspector      = (*Driver)(nil)
+	_ driver.CheckInspector = (*Driver)(nil)
+)
+
+// typeCastPattern matches the type cast PostgreSQL appends to literal defaults,
+// such as 'draft'::character varying
+var typeCastPattern = regexp.MustCompile(`::[\w\s."\[\]()]+$`)
+
+// foreignKeyActions maps pg_constraint action codes to foreign key actions
+var foreignKeyActions = map[string]schema.ForeignKeyAction{
+	"a": schema.ActionNoAction,
+	"r": schema.ActionRestrict,
+	"c": schema.ActionCascade,
+	"n": schema.ActionSetNull,
+	"d": "SET DEFAULT",
+}
+
+// GetTables returns the names of the tables in the current schema
+func (d *Driver) GetTables(ctx context.Context, q driver.Queryer) ([]string, error) {
+	var tables []string
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		tables = append(tables, name)
+		return nil
+	}, d.grammar.compileGetTables())
+	if err != nil {
+		return nil, fmt.Errorf("postgres: failed to read tables: %w", err)
+	}
+	return tables, nil
+}
+
+// GetColumns returns the columns of a table read from information_schema.
+// Serial and identity columns are reported as auto-incrementing, columns of
+// an enum type as enum columns and generated columns with their expression.
+func (d *Driver) GetColumns(ctx context.Context, q driver.Queryer, table string) ([]*schema.Column, error) {
+	var columns []*schema.Column
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		var (
+			name, dataType, udtName, nullable, identity, generated string
+			length, precision, scale                               sql.NullInt64
+			defaultValue, comment, generation                      sql.NullString
+			values                                                 []string
+		)
+		if err := rows.Scan(&name, &dataType, &udtName, &length, &precision, &scale,
+			&nullable, &defaultValue, &identity, &comment, &generated, &generation, pq.Array(&values)); err != nil {
+			return err
+		}
+		col := &schema.Column{
+			Name:            name,
+			IsNullable:      nullable == "YES",
+			IsAutoIncrement: identity == "YES",
+			ColumnComment:   comment.String,
+		}
+		setGenerated(col, generated, generation.String)
+		setColumnType(col, dataType, udtName, length, precision, scale)
+		if len(values) > 0 {
+			col.Type = schema.TypeEnum
+			col.Values = values
+		}
+		if defaultValue.Valid {
+			if strings.HasPrefix(defaultValue.String, "nextval(") {
+				col.IsAutoIncrement = true
+			} else {
+				col.DefaultValue = parseDefault(defaultValue.String)
+			}
+		}
+		columns = append(columns, col)
+		return nil
+	}, d.grammar.compileGetColumns(), tableArgs(table)...)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: failed to read columns of %s: %w", table, err)
+	}
+	return columns, nil
+}
+
+// GetIndexes returns the indexes of a table with their expressions, sort
+// orders, predicate and method. The default btree method is left empty.
+func (d *Driver) GetIndexes(ctx context.Context, q driver.Queryer, table string) ([]*schema.Index, error) {
+	var indexes []*schema.Index
+	byName := make(map[string]*schema.Index)
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		var (
+			name, definition, method    string
+			unique, primary, descending bool
+			column, predicate           sql.NullString
+		)
+		if err := rows.Scan(&name, &unique, &primary, &column, &definition, &descending, &predicate, &method); err != nil {
+			return err
+		}
+		idx, ok := byName[name]
+		if !ok {
+			idx = schema.NewIndex().Named(name).Where(predicate.String)
+			switch {
+			case primary:
+				idx.Primary()
+			case unique:
+				idx.Unique()
+			}
+			if method != "btree" {
+				idx.Using(method)
+			}
+			byName[name] = idx
+			indexes = append(indexes, idx)
+		}
+		part := schema.IndexPart{Column: column.String}
+		if !column.Valid {
+			part.Expression = definition
+		}
+		if descending {
+			part.Order = schema.OrderDesc
+		}
+		addIndexPart(idx, part)
+		return nil
+	}, d.grammar.compileGetIndexes(), tableArgs(table)...)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: failed to read indexes of %s: %w", table, err)
+	}
+	return indexes, nil
+}
+
+// GetForeignKeys returns the foreign keys of a table
+func (d *Driver) GetForeignKeys(ctx context.Context, q driver.Queryer, table string) ([]*schema.ForeignKey, error) {
+	var foreignKeys []*schema.ForeignKey
+	byName := make(map[string]*schema.ForeignKey)
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		var name, column, referenceTable, referenceColumn, onUpdate, onDelete string
+		if err := rows.Scan(&name, &column, &referenceTable, &referenceColumn, &onUpdate, &onDelete); err != nil {
+			return err
+		}
+		fk, ok := byName[name]
+		if !ok {
+			fk = &schema.ForeignKey{
+				Name:           name,
+				ReferenceTable: referenceTable,
+				OnDelete:       foreignKeyActions[onDelete],
+				OnUpdate:       foreignKeyActions[onUpdate],
+			}
+			byName[name] = fk
+			foreignKeys = append(foreignKeys, fk)
+		}
+		fk.Columns = append(fk.Columns, column)
+		if fk.ReferenceColumn != "" {
+			fk.ReferenceColumn += ", "
+		}
+		fk.ReferenceColumn += referenceColumn
+		return nil
+	}, d.grammar.compileGetForeignKeys(), tableArgs(table)...)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: failed to read foreign keys of %s: %w", table, err)
+	}
+	return foreignKeys, nil
+}
+
+// GetChecks returns the CHECK constraints of a table
+func (d *Driver) GetChecks(ctx context.Context, q driver.Queryer, table string) ([]*schema.Check, error) {
+	var checks []*schema.Check
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		var name, definition string
+		if err := rows.Scan(&name, &definition); err != nil {
+			return err
+		}
+		checks = append(checks, &schema.Check{Name: name, Expression: parseCheckDefinition(definition)})
+		return nil
+	}, d.grammar.compileGetChecks(), tableArgs(table)...)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: failed to read checks of %s: %w", table, err)
+	}
+	return checks, nil
+}
+
+// parseCheckDefinition returns the expression of a constraint definition
+// such as CHECK ((price > 0)) NOT VALID
+func parseCheckDefinition(definition string) string {
+	expr := strings.TrimSpace(definition)
+	expr = strings.TrimSpace(strings.TrimSuffix(expr, "NOT VALID"))
+	expr = strings.TrimPrefix(expr, "CHECK")
+	return driver.TrimParens(expr)
+}
+
+// addIndexPart appends a key read from the database to an index. Indexes of
+// plain columns only list their columns, Parts is filled once a key is an
+// expression or has a sort order.
+func addIndexPart(idx *schema.Index, part schema.IndexPart) {
+	if len(idx.Parts) > 0 || part.Expression != "" || part.Order != "" || part.Length > 0 {
+		idx.Parts = append(idx.Keys(), part)
+	}
+	if part.Column != "" {
+		idx.Columns = append(idx.Columns, part.Column)
+	}
+}
+
+// setColumnType maps an information_schema column type to a schema column type
+func setColumnType(col *schema.Column, dataType, udtName string, length, precision, scale sql.NullInt64) {
+	col.DatabaseType = dataType
+	if dataType == "USER-DEFINED" || dataType == "ARRAY" {
+		col.DatabaseType = udtName
+	}
+
+	switch udtName {
+	case "varchar", "bpchar":
+		col.Type = schema.TypeString
+		col.Length = int(length.Int64)
+		if length.Valid {
+			col.DatabaseType = fmt.Sprintf("%s(%d)", dataType, length.Int64)
+		}
+	case "text":
+		col.Type = schema.TypeText
+	case "int2":
+		col.Type = schema.TypeSmallInteger
+	case "int4":
+		col.Type = schema.TypeInteger
+	case "int8":
+		col.Type = schema.TypeBigInteger
+	case "float4":
+		col.Type = schema.TypeFloat
+	case "float8":
+		col.Type = schema.TypeDouble
+	case "numeric":
+		col.Type = schema.TypeDecimal
+		col.Precision = int(precision.Int64)
+		col.Scale = int(scale.Int64)
+		if precision.Valid {
+			col.DatabaseType = fmt.Sprintf("%s(%d,%d)", dataType, precision.Int64, scale.Int64)
+		}
+	case "bool":
+		col.Type = schema.TypeBoolean
+	case "date":
+		col.Type = schema.TypeDate
+	case "timestamp":
+		col.Type = schema.TypeDateTime
+	case "timestamptz":
+		col.Type = schema.TypeTimestamp
+	case "time", "timetz":
+		col.Type = schema.TypeTime
+	case "json", "jsonb":
+		col.Type = schema.TypeJSON
+	case "bytea":
+		col.Type = schema.TypeBinary
+	case "uuid":
+		col.Type = schema.TypeUUID
+	default:
+		col.Type = schema.TypeText
+	}
+}
+
+// setGenerated makes a column generated from the attgenerated code of the
+// column, s for stored and v for virtual (PostgreSQL 18), and its expression
+func setGenerated(col *schema.Column, generated, expression string) {
+	switch generated {
+	case "s":
+		col.StoredAs(driver.TrimParens(expression))
+	case "v":
+		col.VirtualAs(driver.TrimParens(expression))
+	}
+}
+
+// parseDefault converts a column_default value to a column default: string
+// literals are unquoted and other values are kept as expressions
+func parseDefault(value string) interface{} {
+	value = strings.TrimSpace(value)
+	literal := typeCastPattern.ReplaceAllString(value, "")
+	if len(literal) >= 2 && literal[0] == '\'' && literal[len(literal)-1] == '\'' {
+		return strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+	}
+	if strings.EqualFold(literal, "NULL") {
+		return nil
+	}
+	return schema.Expression(value)
+}
+
+// tableArgs returns the schema, empty for unqualified names, and the table
+// arguments of the inspection queries
+func tableArgs(name string) []interface{} {
+	schemaName, table := schema.SplitTableName(name)
+	return []interface{}{schemaName, table}
+}
+
+// queryEach runs a query and calls scan for each row. All rows are consumed
+// before it returns, so it is safe to use on a transaction.
+func queryEach(ctx context.Context, q driver.Queryer, scan func(*sql.Rows) error, query string, args ...interface{}) error {
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// 测试目标：验证 information_schema 中的列类型与默认值被正确映射为 schema.Column
+func TestSetColumnType(t *testing.T) {
+	valid := func(n int64) sql.NullInt64 { return sql.NullInt64{Int64: n, Valid: true} }
+
+	tests := []struct {
+		dataType, udtName    string
+		length, prec, scale  sql.NullInt64
+		expectedType         schema.ColumnType
+		expectedDatabaseType string
+	}{
+		{"character varying", "varchar", valid(100), sql.NullInt64{}, sql.NullInt64{}, schema.TypeString, "character varying(100)"},
+		{"integer", "int4", sql.NullInt64{}, valid(32), valid(0), schema.TypeInteger, "integer"},
+		{"bigint", "int8", sql.NullInt64{}, valid(64), valid(0), schema.TypeBigInteger, "bigint"},
+		{"numeric", "numeric", sql.NullInt64{}, valid(10), valid(2), schema.TypeDecimal, "numeric(10,2)"},
+		{"boolean", "bool", sql.NullInt64{}, sql.NullInt64{}, sql.NullInt64{}, schema.TypeBoolean, "boolean"},
+		{"timestamp with time zone", "timestamptz", sql.NullInt64{}, sql.NullInt64{}, sql.NullInt64{}, schema.TypeTimestamp, "timestamp with time zone"},
+		{"jsonb", "jsonb", sql.NullInt64{}, sql.NullInt64{}, sql.NullInt64{}, schema.TypeJSON, "jsonb"},
+		{"USER-DEFINED", "citext", sql.NullInt64{}, sql.NullInt64{}, sql.NullInt64{}, schema.TypeText, "citext"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dataType, func(t *testing.T) {
+			col := &schema.Column{}
+			setColumnType(col, tt.dataType, tt.udtName, tt.length, tt.prec, tt.scale)
+			if col.Type != tt.expectedType {
+				t.Errorf("expected type %v, got %v", tt.expectedType, col.Type)
+			}
+			if col.DatabaseType != tt.expectedDatabaseType {
+				t.Errorf("expected database type %q, got %q", tt.expectedDatabaseType, col.DatabaseType)
+			}
+		})
+	}
+}
+
+func TestParseDefault(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected interface{}
+	}{
+		{"'draft'::character varying", "draft"},
+		{"'it''s'::text", "it's"},
+		{"'a::b'::text", "a::b"},
+		{"0", schema.Expression("0")},
+		{"now()", schema.Expression("now()")},
+		{"CURRENT_TIMESTAMP", schema.Expression("CURRENT_TIMESTAMP")},
+		{"NULL::character varying", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseDefault(tt.value); got != tt.expected {
+				t.Errorf("parseDefault(%q) = %#v, want %#v", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+// 测试目标：验证表达式和降序键被还原为索引键，普通列索引只保留 Columns
+func TestAddIndexPart(t *testing.T) {
+	idx := schema.NewIndex().Named("users_lookup")
+	addIndexPart(idx, schema.IndexPart{Column: "tenant_id"})
+	addIndexPart(idx, schema.IndexPart{Expression: "lower(email::text)"})
+	addIndexPart(idx, schema.IndexPart{Column: "created_at", Order: schema.OrderDesc})
+
+	expected := []schema.IndexPart{
+		{Column: "tenant_id"},
+		{Expression: "lower(email::text)"},
+		{Column: "created_at", Order: schema.OrderDesc},
+	}
+	if len(idx.Parts) != len(expected) {
+		t.Fatalf("expected %d parts, got %+v", len(expected), idx.Parts)
+	}
+	for i, part := range expected {
+		if idx.Parts[i] != part {
+			t.Errorf("part %d: expected %+v, got %+v", i, part, idx.Parts[i])
+		}
+	}
+	if len(idx.Columns) != 2 || idx.Columns[0] != "tenant_id" || idx.Columns[1] != "created_at" {
+		t.Errorf("expected columns [tenant_id created_at], got %v", idx.Columns)
+	}
+
+	plain := schema.NewIndex().Named("users_email")
+	addIndexPart(plain, schema.IndexPart{Column: "email"})
+	if len(plain.Parts) != 0 || len(plain.Columns) != 1 {
+		t.Errorf("expected a plain column index, got %+v", plain)
+	}
+}
+
+// 测试目标：验证 pg_get_constraintdef 返回的定义被还原为 CHECK 表达式
+func TestParseCheckDefinition(t *testing.T) {
+	tests := []struct {
+		definition, expected string
+	}{
+		{"CHECK (price > 0::numeric)", "price > 0::numeric"},
+		{"CHECK ((price > 0) AND (stock >= 0))", "(price > 0) AND (stock >= 0)"},
+		{"CHECK (status::text <> ''::text) NOT VALID", "status::text <> ''::text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.definition, func(t *testing.T) {
+			if got := parseCheckDefinition(tt.definition); got != tt.expected {
+				t.Errorf("parseCheckDefinition() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// 测试目标：验证 attgenerated 和 generation_expression 被还原为生成列
+func TestSetGenerated(t *testing.T) {
+	col := &schema.Column{}
+	setGenerated(col, "s", "(price * quantity)")
+	if col.GeneratedAs != "price * quantity" || !col.IsStored {
+		t.Errorf("expected a stored generated column, got %+v", *col)
+	}
+
+	col = &schema.Column{}
+	setGenerated(col, "v", "lower(email)")
+	if col.GeneratedAs != "lower(email)" || col.IsStored {
+		t.Errorf("expected a virtual generated column, got %+v", *col)
+	}
+
+	col = &schema.Column{}
+	setGenerated(col, "", "")
+	if col.GeneratedAs != "" {
+		t.Errorf("expected a plain column, got %+v", *col)
+	}
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/flyits/migro/pkg/driver"
+)
+
+// Ensure Driver implements driver.Locker
+var _ driver.Locker = (*Driver)(nil)
+
+// Lock acquires a session-level advisory lock with pg_try_advisory_lock,
+// retrying until the timeout expires. The lock belongs to a dedicated
+// connection that is kept open until Unlock is called.
+func (d *Driver) Lock(ctx context.Context, name string, timeout time.Duration) error {
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("postgres: failed to get connection for lock: %w", err)
+	}
+
+	key := advisoryLockKey(name)
+	err = driver.PollLock(ctx, timeout, func() (bool, error) {
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+			return false, fmt.Errorf("postgres: failed to acquire advisory lock %d: %w", key, err)
+		}
+		return acquired, nil
+	})
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	d.lockConn = conn
+	return nil
+}
+
+// Unlock releases the advisory lock acquired by Lock and closes its connection
+func (d *Driver) Unlock(ctx context.Context, name string) error {
+	if d.lockConn == nil {
+		return nil
+	}
+	conn := d.lockConn
+	d.lockConn = nil
+	defer conn.Close()
+
+	key := advisoryLockKey(name)
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", key); err != nil {
+		return fmt.Errorf("postgres: failed to release advisory lock %d: %w", key, err)
+	}
+	return nil
+}
+
+// ForceUnlock terminates the backends holding the advisory lock. Advisory
+// locks can only be released by the session that owns them.
+func (d *Driver) ForceUnlock(ctx context.Context, name string) error {
+	key := advisoryLockKey(name)
+	rows, err := d.db.QueryContext(ctx, `SELECT pg_terminate_backend(pid) FROM pg_locks
+WHERE locktype = 'advisory' AND classid = $1 AND objid = $2 AND objsubid = 1
+AND database = (SELECT oid FROM pg_database WHERE datname = current_database())
+AND pid <> pg_backend_pid()`, int64(uint32(uint64(key)>>32)), int64(uint32(uint64(key))))
+	if err != nil {
+		return fmt.Errorf("postgres: failed to release advisory lock %d: %w", key, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var terminated sql.NullBool
+		if err := rows.Scan(&terminated); err != nil {
+			return fmt.Errorf("postgres: failed to release advisory lock %d: %w", key, err)
+		}
+	}
+	return rows.Err()
+}
+
+// advisoryLockKey derives the 64-bit advisory lock key from a lock name
+func advisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("migro:" + name))
+	return int64(h.Sum64())
+}
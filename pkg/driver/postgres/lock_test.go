@@ -0,0 +1,13 @@
+package postgres
+
+import "testing"
+
+// 测试目标：验证 advisory lock key 稳定且按名称区分
+func TestAdvisoryLockKey(t *testing.T) {
+	if advisoryLockKey("migrations") != advisoryLockKey("migrations") {
+		t.Error("expected the same key for the same name")
+	}
+	if advisoryLockKey("migrations") == advisoryLockKey("other_migrations") {
+		t.Error("expected different keys for different names")
+	}
+}
@@ -42,7 +42,7 @@ func (m *mockDriver) CreateMigrationsTable(ctx context.Context, tableName string
 func (m *mockDriver) GetExecutedMigrations(ctx context.Context, tableName string) ([]MigrationRecord, error) {
 	return nil, nil
 }
-func (m *mockDriver) RecordMigration(ctx context.Context, tableName, migration string, batch int) error {
+func (m *mockDriver) RecordMigration(ctx context.Context, tableName string, record MigrationRecord) error {
 	return nil
 }
 func (m *mockDriver) DeleteMigration(ctx context.Context, tableName, migration string) error {
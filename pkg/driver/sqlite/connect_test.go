@@ -188,3 +188,39 @@ func TestConnectWithDB_SetsOwnsConnectionFalse(t *testing.T) {
 		t.Error("connection should NOT be closed after driver.Close() when using ConnectWithDB()")
 	}
 }
+
+// TestBuildDSN tests that the options are appended to the database file in a stable order
+func TestBuildDSN(t *testing.T) {
+	if got := buildDSN(&driver.Config{}); got != ":memory:" {
+		t.Errorf("expected :memory:, got %s", got)
+	}
+
+	config := &driver.Config{Database: "./app.db", Options: map[string]string{"_journal_mode": "WAL", "_busy_timeout": "5000"}}
+	if got, want := buildDSN(config), "./app.db?_busy_timeout=5000&_journal_mode=WAL"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+// TestConnect_ForeignKeysOption tests that the foreign keys option takes precedence over the PRAGMA
+func TestConnect_ForeignKeysOption(t *testing.T) {
+	for _, tt := range []struct {
+		options  map[string]string
+		expected int
+	}{
+		{nil, 1},
+		{map[string]string{"_foreign_keys": "0"}, 0},
+	} {
+		drv := NewDriver()
+		if err := drv.Connect(&driver.Config{Database: ":memory:", Options: tt.options}); err != nil {
+			t.Fatalf("Connect failed: %v", err)
+		}
+		var enabled int
+		if err := drv.DB().QueryRow("PRAGMA foreign_keys").Scan(&enabled); err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		if enabled != tt.expected {
+			t.Errorf("options %v: expected foreign_keys %d, got %d", tt.options, tt.expected, enabled)
+		}
+		drv.Close()
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/flyits/migro/pkg/driver"
@@ -33,25 +35,7 @@ func NewDriver() *Driver {
 
 // Connect establishes a connection to the SQLite database
 func (d *Driver) Connect(config *driver.Config) error {
-	dsn := config.Database
-	if dsn == "" {
-		dsn = ":memory:"
-	}
-
-	// Add options
-	if len(config.Options) > 0 {
-		dsn += "?"
-		first := true
-		for key, value := range config.Options {
-			if !first {
-				dsn += "&"
-			}
-			dsn += key + "=" + value
-			first = false
-		}
-	}
-
-	db, err := sql.Open("sqlite3", dsn)
+	db, err := sql.Open("sqlite3", buildDSN(config))
 	if err != nil {
 		return fmt.Errorf("sqlite: failed to open connection: %w", err)
 	}
@@ -61,10 +45,12 @@ func (d *Driver) Connect(config *driver.Config) error {
 		return fmt.Errorf("sqlite: failed to ping database: %w", err)
 	}
 
-	// Enable foreign keys
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		db.Close()
-		return fmt.Errorf("sqlite: failed to enable foreign keys: %w", err)
+	// Enable foreign keys, unless the options configure them
+	if !hasForeignKeysOption(config.Options) {
+		if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+			db.Close()
+			return fmt.Errorf("sqlite: failed to enable foreign keys: %w", err)
+		}
 	}
 
 	d.db = db
@@ -72,6 +58,38 @@ func (d *Driver) Connect(config *driver.Config) error {
 	return nil
 }
 
+// buildDSN returns the data source name of config: the database file
+// followed by the options as query parameters
+func buildDSN(config *driver.Config) string {
+	dsn := config.Database
+	if dsn == "" {
+		dsn = ":memory:"
+	}
+	if len(config.Options) == 0 {
+		return dsn
+	}
+
+	keys := make([]string, 0, len(config.Options))
+	for key := range config.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	query := make([]string, 0, len(keys))
+	for _, key := range keys {
+		query = append(query, key+"="+config.Options[key])
+	}
+	return dsn + "?" + strings.Join(query, "&")
+}
+
+// hasForeignKeysOption reports whether the options set the foreign key
+// enforcement of go-sqlite3
+func hasForeignKeysOption(options map[string]string) bool {
+	_, long := options["_foreign_keys"]
+	_, short := options["_fk"]
+	return long || short
+}
+
 // Close closes the database connection
 func (d *Driver) Close() error {
 	if d.db != nil && d.ownsConnection {
@@ -146,8 +164,13 @@ func (d *Driver) CreateTable(ctx context.Context, table *schema.Table) error {
 	return nil
 }
 
-// AlterTable modifies an existing table
+// AlterTable modifies an existing table.
+// Operations SQLite cannot perform in place rebuild the table.
 func (d *Driver) AlterTable(ctx context.Context, table *schema.Table) error {
+	if needsRebuild(table) {
+		return d.rebuildTable(ctx, table)
+	}
+
 	statements := d.grammar.CompileAlter(table)
 	for _, stmt := range statements {
 		if stmt == "" {
@@ -211,6 +234,24 @@ func (d *Driver) CreateMigrationsTable(ctx context.Context, tableName string) er
 	if err != nil {
 		return fmt.Errorf("sqlite: failed to create migrations table: %w", err)
 	}
+	return d.upgradeMigrationsTable(ctx, tableName)
+}
+
+// upgradeMigrationsTable adds the columns missing from a migrations table
+// created by an older version
+func (d *Driver) upgradeMigrationsTable(ctx context.Context, tableName string) error {
+	for _, col := range d.grammar.migrationsTableUpgrades() {
+		var count int
+		if err := d.db.QueryRowContext(ctx, d.grammar.compileHasColumn(), tableName, col.name).Scan(&count); err != nil {
+			return fmt.Errorf("sqlite: failed to inspect migrations table: %w", err)
+		}
+		if count > 0 {
+			continue
+		}
+		if _, err := d.db.ExecContext(ctx, d.grammar.compileAddMigrationsColumn(tableName, col)); err != nil {
+			return fmt.Errorf("sqlite: failed to add column %s to migrations table: %w", col.name, err)
+		}
+	}
 	return nil
 }
 
@@ -227,11 +268,14 @@ func (d *Driver) GetExecutedMigrations(ctx context.Context, tableName string) ([
 	for rows.Next() {
 		var r driver.MigrationRecord
 		var executedAt string
-		if err := rows.Scan(&r.ID, &r.Migration, &r.Batch, &executedAt); err != nil {
+		var durationMS int64
+		if err := rows.Scan(&r.ID, &r.Migration, &r.Batch, &executedAt, &r.Checksum,
+			&durationMS, &r.Host, &r.Version, &r.User, &r.Note); err != nil {
 			return nil, fmt.Errorf("sqlite: failed to scan migration record: %w", err)
 		}
 		// Parse the timestamp string
 		r.ExecutedAt, _ = time.Parse("2006-01-02 15:04:05", executedAt)
+		r.Duration = time.Duration(durationMS) * time.Millisecond
 		records = append(records, r)
 	}
 
@@ -243,9 +287,9 @@ func (d *Driver) GetExecutedMigrations(ctx context.Context, tableName string) ([
 }
 
 // RecordMigration records a migration execution
-func (d *Driver) RecordMigration(ctx context.Context, tableName, migration string, batch int) error {
+func (d *Driver) RecordMigration(ctx context.Context, tableName string, record driver.MigrationRecord) error {
 	sql := d.grammar.CompileInsertMigration(tableName)
-	_, err := d.db.ExecContext(ctx, sql, migration, batch)
+	_, err := d.db.ExecContext(ctx, sql, record.InsertArgs()...)
 	if err != nil {
 		return fmt.Errorf("sqlite: failed to record migration: %w", err)
 	}
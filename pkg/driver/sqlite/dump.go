@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/flyits/migro/pkg/driver"
+)
+
+// Ensure Driver implements driver.SchemaDumper
+var _ driver.SchemaDumper = (*Driver)(nil)
+
+// DumpSchema returns the statements stored in sqlite_master: tables, then
+// indexes, views and triggers. The lock tables of the excluded tables are
+// left out as well.
+func (d *Driver) DumpSchema(ctx context.Context, q driver.Queryer, exclude ...string) ([]string, error) {
+	skip := make(map[string]bool, len(exclude)*2)
+	for _, name := range exclude {
+		skip[name] = true
+		skip[lockTableName(name)] = true
+	}
+
+	var statements []string
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		var table, stmt string
+		if err := rows.Scan(&table, &stmt); err != nil {
+			return err
+		}
+		if !skip[table] {
+			statements = append(statements, stmt)
+		}
+		return nil
+	}, d.grammar.compileDumpSchema())
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: failed to dump schema: %w", err)
+	}
+	return statements, nil
+}
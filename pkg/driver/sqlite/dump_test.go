@@ -0,0 +1,80 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flyits/migro/pkg/driver"
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// 测试目标：验证导出 sqlite_master 中的表、索引和触发器，排除迁移表及其锁表，且导出结果可重建表结构
+func TestDriver_DumpSchema(t *testing.T) {
+	ctx := context.Background()
+
+	drv := NewDriver()
+	if err := drv.Connect(&driver.Config{Database: filepath.Join(t.TempDir(), "dump.db")}); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer drv.Close()
+
+	if err := drv.CreateMigrationsTable(ctx, "migrations"); err != nil {
+		t.Fatalf("failed to create migrations table: %v", err)
+	}
+	if err := drv.Lock(ctx, "migrations", time.Second); err != nil {
+		t.Fatalf("failed to lock: %v", err)
+	}
+	defer drv.Unlock(ctx, "migrations")
+
+	users := schema.NewTable("users")
+	users.ID()
+	users.String("email", 255).Unique()
+	users.Index("email")
+	if err := drv.CreateTable(ctx, users); err != nil {
+		t.Fatalf("failed to create users: %v", err)
+	}
+	if _, err := drv.Exec(ctx, `CREATE TRIGGER users_touch AFTER UPDATE ON users BEGIN SELECT 1; END`); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+
+	statements, err := drv.DumpSchema(ctx, drv, "migrations")
+	if err != nil {
+		t.Fatalf("DumpSchema failed: %v", err)
+	}
+	if len(statements) != 3 {
+		t.Fatalf("expected table, index and trigger, got %d statements: %v", len(statements), statements)
+	}
+	if !strings.HasPrefix(statements[0], "CREATE TABLE") || !strings.HasPrefix(statements[1], "CREATE INDEX") ||
+		!strings.HasPrefix(statements[2], "CREATE TRIGGER") {
+		t.Errorf("unexpected statement order: %v", statements)
+	}
+	for _, stmt := range statements {
+		if strings.Contains(stmt, "migrations") {
+			t.Errorf("expected the migrations and lock tables to be excluded, got %q", stmt)
+		}
+	}
+
+	// 导出的语句可以在空数据库中重建表结构
+	fresh := NewDriver()
+	if err := fresh.Connect(&driver.Config{Database: filepath.Join(t.TempDir(), "fresh.db")}); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer fresh.Close()
+	for _, stmt := range statements {
+		if _, err := fresh.Exec(ctx, stmt); err != nil {
+			t.Fatalf("failed to load %q: %v", stmt, err)
+		}
+	}
+	reloaded, err := fresh.DumpSchema(ctx, fresh)
+	if err != nil {
+		t.Fatalf("DumpSchema failed: %v", err)
+	}
+	if strings.Join(reloaded, "\n") != strings.Join(statements, "\n") {
+		t.Errorf("expected the reloaded schema to match, got %v", reloaded)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/flyits/migro/pkg/driver"
 	"github.com/flyits/migro/pkg/schema"
 )
 
@@ -26,6 +27,9 @@ func validateIdentifier(name string) error {
 	return nil
 }
 
+// Ensure Grammar implements driver.TableValidator
+var _ driver.TableValidator = (*Grammar)(nil)
+
 // Grammar implements the SQLite SQL dialect
 type Grammar struct{}
 
@@ -64,7 +68,12 @@ func (g *Grammar) CompileCreate(table *schema.Table) string {
 
 	// Foreign keys inline
 	for _, fk := range table.ForeignKeys {
-		columns = append(columns, "  "+g.compileForeignKeyInline(fk))
+		columns = append(columns, "  "+g.compileForeignKeyInline(table.Name, fk))
+	}
+
+	// Check constraints
+	for _, check := range table.Checks {
+		columns = append(columns, "  "+g.compileCheckInline(check))
 	}
 
 	sb.WriteString(strings.Join(columns, ",\n"))
@@ -74,13 +83,16 @@ func (g *Grammar) CompileCreate(table *schema.Table) string {
 }
 
 // CompileAlter generates ALTER TABLE SQL statements
-// Note: SQLite has limited ALTER TABLE support
+// Note: SQLite has limited ALTER TABLE support. Dropping or changing columns
+// and adding or dropping foreign keys or check constraints require
+// rebuilding the table, which
+// depends on the current table definition and is compiled by
+// Driver.CompileAlterTable instead.
 func (g *Grammar) CompileAlter(table *schema.Table) []string {
 	var statements []string
 	tableName := g.wrapTable(table.Name)
 
 	// SQLite only supports ADD COLUMN and RENAME COLUMN
-	// For other operations, we need to recreate the table
 
 	// Rename columns (SQLite 3.25.0+)
 	for oldName, newName := range table.RenameColumns {
@@ -94,15 +106,16 @@ func (g *Grammar) CompileAlter(table *schema.Table) []string {
 		}
 	}
 
+	// Drop indexes
+	for _, idxName := range table.DropIndexes {
+		statements = append(statements, g.CompileDropIndex(table.Name, idxName))
+	}
+
 	// Add indexes
 	for _, idx := range table.Indexes {
 		statements = append(statements, g.CompileIndex(table.Name, idx))
 	}
 
-	// Note: DROP COLUMN, MODIFY COLUMN, DROP INDEX, DROP FOREIGN KEY
-	// require table recreation in SQLite, which is not implemented here
-	// Users should use Raw SQL for complex alterations
-
 	return statements
 }
 
@@ -130,6 +143,19 @@ func (g *Grammar) CompileHasTable(name string) (string, error) {
 	return fmt.Sprintf("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='%s'", name), nil
 }
 
+// compileGetTables returns a query listing the tables of the database
+func (g *Grammar) compileGetTables() string {
+	return "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name"
+}
+
+// compileDumpSchema returns a query reading the table name and the statement
+// of every schema object, tables first. Automatic indexes have no statement.
+func (g *Grammar) compileDumpSchema() string {
+	return `SELECT tbl_name, sql FROM sqlite_master
+WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'index' THEN 1 WHEN 'view' THEN 2 ELSE 3 END, name`
+}
+
 // Type mappings - SQLite uses type affinity
 
 func (g *Grammar) TypeString(length int) string {
@@ -215,6 +241,10 @@ func (g *Grammar) CompileColumn(col *schema.Column) string {
 
 	sb.WriteString(g.getColumnType(col))
 
+	if col.GeneratedAs != "" {
+		sb.WriteString(g.compileGenerated(col.GeneratedAs, col.IsStored))
+	}
+
 	if col.IsPrimary {
 		sb.WriteString(" PRIMARY KEY")
 	}
@@ -232,13 +262,17 @@ func (g *Grammar) CompileColumn(col *schema.Column) string {
 		sb.WriteString(" UNIQUE")
 	}
 
+	if col.Type == schema.TypeEnum {
+		fmt.Fprintf(&sb, " CHECK (%s IN (%s))", g.wrap(col.Name), compileValues(col.Values))
+	}
+
 	return sb.String()
 }
 
 func (g *Grammar) getColumnType(col *schema.Column) string {
 	switch col.Type {
 	case schema.TypeString, schema.TypeText, schema.TypeDate, schema.TypeDateTime,
-		schema.TypeTimestamp, schema.TypeTime, schema.TypeJSON, schema.TypeUUID:
+		schema.TypeTimestamp, schema.TypeTime, schema.TypeJSON, schema.TypeUUID, schema.TypeEnum:
 		return "TEXT"
 	case schema.TypeInteger, schema.TypeBigInteger, schema.TypeSmallInteger,
 		schema.TypeTinyInteger, schema.TypeBoolean:
@@ -259,17 +293,54 @@ func (g *Grammar) CompileIndex(tableName string, idx *schema.Index) string {
 		indexName = g.generateIndexName(tableName, idx.Columns, idx.Type)
 	}
 
-	cols := make([]string, len(idx.Columns))
-	for i, col := range idx.Columns {
-		cols[i] = g.wrap(col)
+	unique := ""
+	if idx.Type == schema.IndexTypeUnique {
+		unique = "UNIQUE "
 	}
 
-	switch idx.Type {
-	case schema.IndexTypeUnique:
-		return fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s)", g.wrap(indexName), g.wrapTable(tableName), strings.Join(cols, ", "))
-	default:
-		return fmt.Sprintf("CREATE INDEX %s ON %s (%s)", g.wrap(indexName), g.wrapTable(tableName), strings.Join(cols, ", "))
+	sql := fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique, g.wrap(indexName), g.wrapTable(tableName), g.compileIndexKeys(idx))
+	if idx.Predicate != "" {
+		sql += " WHERE " + idx.Predicate
+	}
+	return sql
+}
+
+// compileIndexKeys generates the columns and expressions of an index
+func (g *Grammar) compileIndexKeys(idx *schema.Index) string {
+	keys := idx.Keys()
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		part := "(" + key.Expression + ")"
+		if key.Column != "" {
+			part = g.wrap(key.Column)
+		}
+		if key.Order != "" {
+			part += " " + key.Order
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, ", ")
+}
+
+// validateIndex returns an error for unnamed indexes on expressions, prefix
+// lengths and index methods, which SQLite does not have
+func (g *Grammar) validateIndex(tableName string, idx *schema.Index) error {
+	if idx.HasExpressions() && idx.Name == "" {
+		return fmt.Errorf("index on expressions of table %s must be named", tableName)
+	}
+	name := idx.Name
+	if name == "" {
+		name = g.generateIndexName(tableName, idx.Columns, idx.Type)
 	}
+	for _, key := range idx.Keys() {
+		if key.Length > 0 {
+			return fmt.Errorf("index %s: SQLite does not support prefix lengths", name)
+		}
+	}
+	if idx.Method != "" {
+		return fmt.Errorf("index %s: SQLite does not support index methods (Using %s)", name, idx.Method)
+	}
+	return nil
 }
 
 // CompileDropIndex generates DROP INDEX SQL
@@ -296,16 +367,22 @@ func (g *Grammar) CompileCreateMigrationsTable(tableName string) string {
   id INTEGER PRIMARY KEY AUTOINCREMENT,
   migration TEXT NOT NULL,
   batch INTEGER NOT NULL,
-  executed_at TEXT DEFAULT CURRENT_TIMESTAMP
+  executed_at TEXT DEFAULT CURRENT_TIMESTAMP,
+  checksum TEXT,
+  duration_ms INTEGER,
+  host TEXT,
+  migro_version TEXT,
+  os_user TEXT,
+  note TEXT
 )`, g.wrapTable(tableName))
 }
 
 func (g *Grammar) CompileGetMigrations(tableName string) string {
-	return fmt.Sprintf("SELECT id, migration, batch, executed_at FROM %s ORDER BY batch, migration", g.wrapTable(tableName))
+	return fmt.Sprintf("SELECT id, migration, batch, executed_at, COALESCE(checksum, ''), COALESCE(duration_ms, 0), COALESCE(host, ''), COALESCE(migro_version, ''), COALESCE(os_user, ''), COALESCE(note, '') FROM %s ORDER BY batch, migration", g.wrapTable(tableName))
 }
 
 func (g *Grammar) CompileInsertMigration(tableName string) string {
-	return fmt.Sprintf("INSERT INTO %s (migration, batch) VALUES (?, ?)", g.wrapTable(tableName))
+	return fmt.Sprintf("INSERT INTO %s (migration, batch, checksum, duration_ms, host, migro_version, os_user, note) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", g.wrapTable(tableName))
 }
 
 func (g *Grammar) CompileDeleteMigration(tableName string) string {
@@ -316,6 +393,33 @@ func (g *Grammar) CompileGetLastBatch(tableName string) string {
 	return fmt.Sprintf("SELECT COALESCE(MAX(batch), 0) FROM %s", g.wrapTable(tableName))
 }
 
+// migrationsColumn is a column added to the migrations table after its first release
+type migrationsColumn struct {
+	name       string
+	definition string
+}
+
+// migrationsTableUpgrades returns the columns that existing migrations tables may lack
+func (g *Grammar) migrationsTableUpgrades() []migrationsColumn {
+	return []migrationsColumn{
+		{name: "checksum", definition: "TEXT"},
+		{name: "duration_ms", definition: "INTEGER"},
+		{name: "host", definition: "TEXT"},
+		{name: "migro_version", definition: "TEXT"},
+		{name: "os_user", definition: "TEXT"},
+		{name: "note", definition: "TEXT"},
+	}
+}
+
+// compileHasColumn returns a query counting the columns of a table with the given name
+func (g *Grammar) compileHasColumn() string {
+	return "SELECT COUNT(*) FROM pragma_table_xinfo(?) WHERE name = ? AND hidden <> 1"
+}
+
+func (g *Grammar) compileAddMigrationsColumn(tableName string, col migrationsColumn) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", g.wrapTable(tableName), g.wrap(col.name), col.definition)
+}
+
 // Helper methods
 
 func (g *Grammar) wrap(name string) string {
@@ -326,13 +430,54 @@ func (g *Grammar) wrapTable(name string) string {
 	return "\"" + name + "\""
 }
 
-func (g *Grammar) compileForeignKeyInline(fk *schema.ForeignKey) string {
+// compileGenerated generates the clause of a generated column
+func (g *Grammar) compileGenerated(expression string, stored bool) string {
+	if stored {
+		return fmt.Sprintf(" GENERATED ALWAYS AS (%s) STORED", expression)
+	}
+	return fmt.Sprintf(" GENERATED ALWAYS AS (%s) VIRTUAL", expression)
+}
+
+// ValidateTable returns an error for indexes SQLite cannot create and for
+// generated columns it rejects: columns with a default value and primary key
+// columns
+func (g *Grammar) ValidateTable(table *schema.Table) error {
+	for _, idx := range table.Indexes {
+		if err := g.validateIndex(table.Name, idx); err != nil {
+			return err
+		}
+	}
+	for _, col := range table.Columns {
+		if col.GeneratedAs == "" {
+			continue
+		}
+		if col.DefaultValue != nil {
+			return fmt.Errorf("generated column %s cannot have a default value", col.Name)
+		}
+		if col.IsPrimary || col.IsAutoIncrement {
+			return fmt.Errorf("generated column %s cannot be part of the primary key", col.Name)
+		}
+	}
+	return nil
+}
+
+func (g *Grammar) compileCheckInline(check *schema.Check) string {
+	return fmt.Sprintf("CONSTRAINT %s CHECK (%s)", g.wrap(check.Name), check.Expression)
+}
+
+func (g *Grammar) compileForeignKeyInline(tableName string, fk *schema.ForeignKey) string {
+	fkName := fk.Name
+	if fkName == "" {
+		fkName = g.generateForeignKeyName(tableName, fk.Columns)
+	}
+
 	cols := make([]string, len(fk.Columns))
 	for i, col := range fk.Columns {
 		cols[i] = g.wrap(col)
 	}
 
-	sql := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)",
+	sql := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		g.wrap(fkName),
 		strings.Join(cols, ", "),
 		g.wrapTable(fk.ReferenceTable),
 		g.wrap(fk.ReferenceColumn))
@@ -355,6 +500,10 @@ func (g *Grammar) generateIndexName(tableName string, columns []string, indexTyp
 	return fmt.Sprintf("%s_%s_%s", tableName, strings.Join(columns, "_"), suffix)
 }
 
+func (g *Grammar) generateForeignKeyName(tableName string, columns []string) string {
+	return fmt.Sprintf("%s_%s_fk", tableName, strings.Join(columns, "_"))
+}
+
 func (g *Grammar) formatDefault(value interface{}) string {
 	switch v := value.(type) {
 	case string:
@@ -371,6 +520,32 @@ func (g *Grammar) formatDefault(value interface{}) string {
 	}
 }
 
+// compileValues returns the quoted values of an enum column
+func compileValues(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = "'" + escapeString(value) + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
 func escapeString(s string) string {
 	return strings.ReplaceAll(s, "'", "''")
 }
+
+// Lock table operations
+
+func (g *Grammar) compileCreateLockTable(name string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+  id INTEGER PRIMARY KEY CHECK (id = 1),
+  locked_at TEXT DEFAULT CURRENT_TIMESTAMP
+)`, g.wrapTable(lockTableName(name)))
+}
+
+func (g *Grammar) compileAcquireLock(name string) string {
+	return fmt.Sprintf("INSERT OR IGNORE INTO %s (id) VALUES (1)", g.wrapTable(lockTableName(name)))
+}
+
+func (g *Grammar) compileReleaseLock(name string) string {
+	return fmt.Sprintf("DELETE FROM %s WHERE id = 1", g.wrapTable(lockTableName(name)))
+}
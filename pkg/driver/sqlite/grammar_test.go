@@ -366,8 +366,20 @@ func TestGrammar_CompileAlter(t *testing.T) {
 		}
 	})
 
-	// 注意: SQLite 不支持 DROP COLUMN, DROP INDEX, MODIFY COLUMN
-	// 这些操作需要重建表，在 CompileAlter 中不实现
+	t.Run("drop index", func(t *testing.T) {
+		table := schema.NewTable("users")
+		table.IsAlter = true
+		table.DropIndex("users_email_idx")
+
+		sqls := g.CompileAlter(table)
+
+		if len(sqls) != 1 || sqls[0] != `DROP INDEX "users_email_idx"` {
+			t.Errorf("expected DROP INDEX statement, got %v", sqls)
+		}
+	})
+
+	// 注意: DROP COLUMN, MODIFY COLUMN, 外键操作需要重建表
+	// 由 Driver.CompileAlterTable 实现，见 rebuild_test.go
 }
 
 func TestGrammar_MigrationTableOperations(t *testing.T) {
@@ -551,3 +563,110 @@ func TestGrammar_CompileIndex_WithCustomName(t *testing.T) {
 		t.Error("expected custom index name")
 	}
 }
+
+// 测试目标：验证 Enum 编译为带 CHECK 约束的 TEXT 列
+func TestGrammar_Enum(t *testing.T) {
+	g := NewGrammar()
+
+	col := &schema.Column{Name: "status", Type: schema.TypeEnum, Values: []string{"draft", "it's"}, DefaultValue: "draft"}
+	expected := `"status" TEXT NOT NULL DEFAULT 'draft' CHECK ("status" IN ('draft', 'it''s'))`
+	if got := g.CompileColumn(col); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestGrammar_Checks(t *testing.T) {
+	g := NewGrammar()
+
+	table := schema.NewTable("products")
+	table.Integer("price")
+	table.Check("products_price_positive", "price >= 0")
+
+	expected := "CREATE TABLE \"products\" (\n  \"price\" INTEGER NOT NULL,\n  CONSTRAINT \"products_price_positive\" CHECK (price >= 0)\n)"
+	if got := g.CompileCreate(table); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestGrammar_RenameExpression(t *testing.T) {
+	g := NewGrammar()
+	dropped := map[string]bool{"legacy": true}
+	rename := func(name string) string {
+		if name == "price" {
+			return "amount"
+		}
+		return name
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		expected   string
+		ok         bool
+	}{
+		{"重命名列", `price >= 0 AND "price" < 100`, `"amount" >= 0 AND "amount" < 100`, true},
+		{"忽略字符串", `status <> 'price'`, `status <> 'price'`, true},
+		{"引用已删除的列", `legacy IS NULL OR price > 0`, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := g.renameExpression(tt.expression, dropped, rename)
+			if ok != tt.ok || got != tt.expected {
+				t.Errorf("expected %q, %v, got %q, %v", tt.expected, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestGrammar_GeneratedColumns(t *testing.T) {
+	g := NewGrammar()
+
+	stored := &schema.Column{Name: "total", Type: schema.TypeDouble}
+	stored.StoredAs("price * quantity")
+	expected := `"total" REAL GENERATED ALWAYS AS (price * quantity) STORED NOT NULL`
+	if got := g.CompileColumn(stored); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	virtual := &schema.Column{Name: "email_domain", Type: schema.TypeText, IsNullable: true}
+	virtual.VirtualAs("substr(email, instr(email, '@') + 1)")
+	expected = `"email_domain" TEXT GENERATED ALWAYS AS (substr(email, instr(email, '@') + 1)) VIRTUAL`
+	if got := g.CompileColumn(virtual); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	t.Run("拒绝带默认值的生成列", func(t *testing.T) {
+		table := schema.NewTable("orders")
+		table.Double("total").StoredAs("price * quantity").Default(0)
+		if err := g.ValidateTable(table); err == nil {
+			t.Error("expected an error for a generated column with a default value")
+		}
+	})
+}
+
+func TestGrammar_IndexOptions(t *testing.T) {
+	g := NewGrammar()
+
+	idx := schema.NewIndex("email").Unique().Where("deleted_at IS NULL")
+	expected := `CREATE UNIQUE INDEX "users_email_unique" ON "users" ("email") WHERE deleted_at IS NULL`
+	if got := g.CompileIndex("users", idx); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	idx = schema.NewIndex("created_at").Named("users_recent_idx").Desc("created_at").Expression("lower(email)")
+	expected = `CREATE INDEX "users_recent_idx" ON "users" ("created_at" DESC, (lower(email)))`
+	if got := g.CompileIndex("users", idx); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	for _, define := range []func(t *schema.Table){
+		func(t *schema.Table) { t.Index("name").Length("name", 10) },
+		func(t *schema.Table) { t.Index("name").Using("btree") },
+	} {
+		table := schema.NewTable("users")
+		define(table)
+		if err := g.ValidateTable(table); err == nil {
+			t.Errorf("expected an error for %+v", table.Indexes[0])
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/flyits/migro/pkg/driver"
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// Ensure Driver implements driver.Inspector and driver.CheckInspector
+var (
+	_ driver.Inspector      = (*Driver)(nil)
+	_ driver.CheckInspector = (*Driver)(nil)
+)
+
+// GetTables returns the names of the tables in the database
+func (d *Driver) GetTables(ctx context.Context, q driver.Queryer) ([]string, error) {
+	var tables []string
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		tables = append(tables, name)
+		return nil
+	}, d.grammar.compileGetTables())
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: failed to read tables: %w", err)
+	}
+	return tables, nil
+}
+
+// GetColumns returns the columns of a table read with PRAGMA table_xinfo.
+// SQLite has no column comments.
+func (d *Driver) GetColumns(ctx context.Context, q driver.Queryer, table string) ([]*schema.Column, error) {
+	tableSQL, err := readTableSQL(ctx, q, table)
+	if err != nil || tableSQL == "" {
+		return nil, err
+	}
+	columns, err := readColumns(ctx, q, table)
+	if err != nil {
+		return nil, err
+	}
+
+	primaryColumns := 0
+	for _, c := range columns {
+		if c.pk > 0 {
+			primaryColumns++
+		}
+	}
+
+	list := make([]*schema.Column, len(columns))
+	for i, c := range columns {
+		col := &schema.Column{
+			Name:         c.name,
+			IsNullable:   !c.notNull && c.pk == 0,
+			DatabaseType: c.typ,
+		}
+		setColumnType(col, c.typ)
+		if c.defaultValue.Valid {
+			col.DefaultValue = parseDefault(c.defaultValue.String)
+		}
+		// Only an INTEGER PRIMARY KEY aliases the rowid and increments
+		col.IsAutoIncrement = c.pk > 0 && primaryColumns == 1 &&
+			strings.EqualFold(c.typ, "INTEGER") && autoIncrementPattern.MatchString(tableSQL)
+		list[i] = col
+	}
+	return list, nil
+}
+
+// GetIndexes returns the primary key, the UNIQUE constraints and the indexes
+// of a table. UNIQUE constraints declared in the table have no name.
+func (d *Driver) GetIndexes(ctx context.Context, q driver.Queryer, table string) ([]*schema.Index, error) {
+	columns, err := readColumns(ctx, q, table)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexes []*schema.Index
+	var primary []tableColumn
+	for _, c := range columns {
+		if c.pk > 0 {
+			primary = append(primary, c)
+		}
+	}
+	if len(primary) > 0 {
+		sort.Slice(primary, func(i, j int) bool { return primary[i].pk < primary[j].pk })
+		cols := make([]string, len(primary))
+		for i, c := range primary {
+			cols[i] = c.name
+		}
+		indexes = append(indexes, schema.NewIndex(cols...).Primary())
+	}
+
+	def := &tableDefinition{name: table}
+	if err := readIndexes(ctx, q, def); err != nil {
+		return nil, err
+	}
+	for _, cols := range def.uniques {
+		indexes = append(indexes, schema.NewIndex(cols...).Unique())
+	}
+	for _, idx := range def.indexes {
+		index := schema.NewIndex(idx.columns...).Named(idx.name)
+		if idx.unique {
+			index.Unique()
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+// GetForeignKeys returns the foreign keys of a table
+func (d *Driver) GetForeignKeys(ctx context.Context, q driver.Queryer, table string) ([]*schema.ForeignKey, error) {
+	tableSQL, err := readTableSQL(ctx, q, table)
+	if err != nil || tableSQL == "" {
+		return nil, err
+	}
+
+	def := &tableDefinition{name: table}
+	if err := readForeignKeys(ctx, q, def, tableSQL); err != nil {
+		return nil, err
+	}
+
+	list := make([]*schema.ForeignKey, len(def.foreignKeys))
+	for i, fk := range def.foreignKeys {
+		list[i] = &schema.ForeignKey{
+			Name:            fk.name,
+			Columns:         fk.columns,
+			ReferenceTable:  fk.referenceTable,
+			ReferenceColumn: strings.Join(fk.references, ", "),
+			OnDelete:        schema.ForeignKeyAction(fk.onDelete),
+			OnUpdate:        schema.ForeignKeyAction(fk.onUpdate),
+		}
+	}
+	return list, nil
+}
+
+// GetChecks returns the named CHECK constraints of a table, read from its
+// CREATE TABLE statement
+func (d *Driver) GetChecks(ctx context.Context, q driver.Queryer, table string) ([]*schema.Check, error) {
+	tableSQL, err := readTableSQL(ctx, q, table)
+	if err != nil || tableSQL == "" {
+		return nil, err
+	}
+
+	def := &tableDefinition{name: table}
+	readChecks(def, tableSQL)
+	sort.Slice(def.checks, func(i, j int) bool { return def.checks[i].name < def.checks[j].name })
+
+	list := make([]*schema.Check, len(def.checks))
+	for i, check := range def.checks {
+		list[i] = &schema.Check{Name: check.name, Expression: check.expression}
+	}
+	return list, nil
+}
+
+// setColumnType maps a declared column type to a schema column type
+func setColumnType(col *schema.Column, typ string) {
+	name, args := splitType(typ)
+	switch name {
+	case "VARCHAR", "CHARACTER VARYING", "NVARCHAR", "CHAR", "CHARACTER", "NCHAR":
+		col.Type = schema.TypeString
+		if len(args) > 0 {
+			col.Length = args[0]
+		}
+		return
+	case "BIGINT":
+		col.Type = schema.TypeBigInteger
+		return
+	case "SMALLINT":
+		col.Type = schema.TypeSmallInteger
+		return
+	case "TINYINT":
+		col.Type = schema.TypeTinyInteger
+		return
+	case "BOOLEAN", "BOOL":
+		col.Type = schema.TypeBoolean
+		return
+	case "FLOAT":
+		col.Type = schema.TypeFloat
+		return
+	case "DECIMAL", "NUMERIC":
+		col.Type = schema.TypeDecimal
+		if len(args) > 0 {
+			col.Precision = args[0]
+		}
+		if len(args) > 1 {
+			col.Scale = args[1]
+		}
+		return
+	case "DATE":
+		col.Type = schema.TypeDate
+		return
+	case "DATETIME":
+		col.Type = schema.TypeDateTime
+		return
+	case "TIMESTAMP":
+		col.Type = schema.TypeTimestamp
+		return
+	case "TIME":
+		col.Type = schema.TypeTime
+		return
+	case "JSON":
+		col.Type = schema.TypeJSON
+		return
+	case "UUID":
+		col.Type = schema.TypeUUID
+		return
+	}
+
+	// Fall back to the SQLite type affinity rules
+	switch {
+	case strings.Contains(name, "INT"):
+		col.Type = schema.TypeInteger
+	case strings.Contains(name, "CHAR"), strings.Contains(name, "CLOB"), strings.Contains(name, "TEXT"):
+		col.Type = schema.TypeText
+	case name == "" || strings.Contains(name, "BLOB"):
+		col.Type = schema.TypeBinary
+	case strings.Contains(name, "REAL"), strings.Contains(name, "FLOA"), strings.Contains(name, "DOUB"):
+		col.Type = schema.TypeDouble
+	default:
+		col.Type = schema.TypeDecimal
+	}
+}
+
+// splitType splits a declared type such as "VARCHAR(255)" into its upper-cased
+// name and its numeric arguments
+func splitType(typ string) (string, []int) {
+	name := typ
+	var args []int
+	if open := strings.Index(typ, "("); open >= 0 {
+		name = typ[:open]
+		inner := strings.TrimSuffix(strings.TrimSpace(typ[open+1:]), ")")
+		for _, arg := range strings.Split(inner, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(arg))
+			if err != nil {
+				break
+			}
+			args = append(args, n)
+		}
+	}
+	return strings.ToUpper(strings.TrimSpace(name)), args
+}
+
+// parseDefault converts a default as stored by SQLite to a column default:
+// string literals are unquoted and other values are kept as expressions
+func parseDefault(value string) interface{} {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	if strings.EqualFold(value, "NULL") {
+		return nil
+	}
+	return schema.Expression(value)
+}
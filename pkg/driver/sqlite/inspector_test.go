@@ -0,0 +1,114 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/flyits/migro/pkg/driver"
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// 测试目标：验证通过 PRAGMA 读取的表、列、索引、外键和 CHECK 约束结构
+
+func TestDriver_Inspector(t *testing.T) {
+	ctx := context.Background()
+
+	drv := NewDriver()
+	if err := drv.Connect(&driver.Config{Database: filepath.Join(t.TempDir(), "inspect.db")}); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer drv.Close()
+
+	users := schema.NewTable("users")
+	users.ID()
+	users.String("email", 255)
+	posts := schema.NewTable("posts")
+	posts.ID()
+	posts.BigInteger("user_id")
+	posts.String("status", 20).Default("it's draft")
+	posts.Integer("views").Default(0)
+	posts.Text("body").Nullable()
+	posts.Unique("user_id", "status")
+	posts.Foreign("user_id").References("users", "id").OnDeleteCascade()
+	posts.Check("posts_views_check", "views >= 0")
+
+	for _, table := range []*schema.Table{users, posts} {
+		if err := drv.CreateTable(ctx, table); err != nil {
+			t.Fatalf("failed to create %s: %v", table.Name, err)
+		}
+	}
+	if _, err := drv.Exec(ctx, `CREATE INDEX posts_status_index ON posts (status)`); err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+
+	tables, err := drv.GetTables(ctx, drv)
+	if err != nil {
+		t.Fatalf("GetTables failed: %v", err)
+	}
+	if len(tables) != 2 || tables[0] != "posts" || tables[1] != "users" {
+		t.Errorf("expected tables [posts users], got %v", tables)
+	}
+
+	table, err := driver.InspectTable(ctx, drv, drv, "posts")
+	if err != nil {
+		t.Fatalf("InspectTable failed: %v", err)
+	}
+
+	columns := make(map[string]*schema.Column)
+	for _, col := range table.Columns {
+		columns[col.Name] = col
+	}
+	if len(columns) != 5 {
+		t.Fatalf("expected 5 columns, got %d", len(columns))
+	}
+	if id := columns["id"]; !id.IsPrimary || !id.IsAutoIncrement {
+		t.Errorf("expected id to be an auto-incrementing primary key, got %+v", *id)
+	}
+	if status := columns["status"]; status.IsNullable || status.DefaultValue != "it's draft" {
+		t.Errorf("unexpected status column %+v", *status)
+	}
+	if views := columns["views"]; views.Type != schema.TypeInteger || views.DefaultValue != schema.Expression("0") {
+		t.Errorf("unexpected views column %+v", *views)
+	}
+	if body := columns["body"]; !body.IsNullable || body.Type != schema.TypeText {
+		t.Errorf("unexpected body column %+v", *body)
+	}
+
+	if len(table.PrimaryKey) != 1 || table.PrimaryKey[0] != "id" {
+		t.Errorf("expected primary key [id], got %v", table.PrimaryKey)
+	}
+
+	var unique, named bool
+	for _, idx := range table.Indexes {
+		if idx.Type == schema.IndexTypeUnique && len(idx.Columns) == 2 {
+			unique = true
+		}
+		if idx.Name == "posts_status_index" && idx.Type == schema.IndexTypeIndex {
+			named = true
+		}
+	}
+	if !unique || !named {
+		t.Errorf("expected the unique constraint and the named index, got %d indexes", len(table.Indexes))
+	}
+
+	if len(table.ForeignKeys) != 1 {
+		t.Fatalf("expected 1 foreign key, got %d", len(table.ForeignKeys))
+	}
+	fk := table.ForeignKeys[0]
+	if fk.Name != "posts_user_id_fk" || fk.ReferenceTable != "users" || fk.ReferenceColumn != "id" || fk.OnDelete != schema.ActionCascade {
+		t.Errorf("unexpected foreign key %+v", *fk)
+	}
+
+	if len(table.Checks) != 1 || table.Checks[0].Name != "posts_views_check" || table.Checks[0].Expression != "views >= 0" {
+		t.Errorf("expected the posts_views_check constraint, got %v", table.Checks)
+	}
+
+	t.Run("表不存在", func(t *testing.T) {
+		if _, err := driver.InspectTable(ctx, drv, drv, "missing"); err == nil {
+			t.Error("expected an error for a missing table")
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/flyits/migro/pkg/driver"
+)
+
+// Ensure Driver implements driver.Locker
+var _ driver.Locker = (*Driver)(nil)
+
+// Lock acquires the migration lock by inserting the single row of a lock
+// table named after the migrations table, retrying until the timeout expires.
+// SQLite has no session-level locks, so a crashed process leaves the row
+// behind until ForceUnlock removes it.
+func (d *Driver) Lock(ctx context.Context, name string, timeout time.Duration) error {
+	if _, err := d.db.ExecContext(ctx, d.grammar.compileCreateLockTable(name)); err != nil {
+		return fmt.Errorf("sqlite: failed to create lock table: %w", err)
+	}
+
+	insert := d.grammar.compileAcquireLock(name)
+	return driver.PollLock(ctx, timeout, func() (bool, error) {
+		result, err := d.db.ExecContext(ctx, insert)
+		if err != nil {
+			return false, fmt.Errorf("sqlite: failed to acquire lock: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return false, fmt.Errorf("sqlite: failed to acquire lock: %w", err)
+		}
+		return affected == 1, nil
+	})
+}
+
+// Unlock releases the migration lock
+func (d *Driver) Unlock(ctx context.Context, name string) error {
+	if _, err := d.db.ExecContext(ctx, d.grammar.compileReleaseLock(name)); err != nil {
+		return fmt.Errorf("sqlite: failed to release lock: %w", err)
+	}
+	return nil
+}
+
+// ForceUnlock releases the migration lock regardless of its holder
+func (d *Driver) ForceUnlock(ctx context.Context, name string) error {
+	has, err := d.HasTable(ctx, lockTableName(name))
+	if err != nil || !has {
+		return err
+	}
+	return d.Unlock(ctx, name)
+}
+
+// lockTableName returns the name of the lock table for a lock name
+func lockTableName(name string) string {
+	return name + "_lock"
+}
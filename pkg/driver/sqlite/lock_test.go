@@ -0,0 +1,66 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/flyits/migro/pkg/driver"
+)
+
+// 测试目标：验证 SQLite 锁表的获取、超时、释放与强制释放
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	cfg := &driver.Config{Database: filepath.Join(t.TempDir(), "lock.db")}
+
+	first := NewDriver()
+	if err := first.Connect(cfg); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer first.Close()
+
+	second := NewDriver()
+	if err := second.Connect(cfg); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer second.Close()
+
+	if err := first.Lock(ctx, "migrations", 0); err != nil {
+		t.Fatalf("failed to acquire lock: %v", err)
+	}
+
+	if err := second.Lock(ctx, "migrations", 0); !errors.Is(err, driver.ErrLockTimeout) {
+		t.Fatalf("expected ErrLockTimeout while the lock is held, got %v", err)
+	}
+
+	if err := first.Unlock(ctx, "migrations"); err != nil {
+		t.Fatalf("failed to release lock: %v", err)
+	}
+	if err := second.Lock(ctx, "migrations", 0); err != nil {
+		t.Fatalf("expected lock to be free after Unlock: %v", err)
+	}
+
+	// 模拟持有锁的进程崩溃
+	if err := first.ForceUnlock(ctx, "migrations"); err != nil {
+		t.Fatalf("failed to force unlock: %v", err)
+	}
+	if err := first.Lock(ctx, "migrations", 0); err != nil {
+		t.Fatalf("expected lock to be free after ForceUnlock: %v", err)
+	}
+}
+
+// 测试目标：验证锁表不存在时 ForceUnlock 不报错
+func TestForceUnlock_NoLockTable(t *testing.T) {
+	drv := NewDriver()
+	if err := drv.Connect(&driver.Config{Database: filepath.Join(t.TempDir(), "lock.db")}); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer drv.Close()
+
+	if err := drv.ForceUnlock(context.Background(), "migrations"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
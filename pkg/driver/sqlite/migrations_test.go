@@ -0,0 +1,79 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flyits/migro/pkg/driver"
+)
+
+// 测试目标：验证旧版本迁移表自动补充 checksum 和元数据列，且校验和与元数据可记录与读取
+func TestCreateMigrationsTable_UpgradesExistingTable(t *testing.T) {
+	ctx := context.Background()
+	drv := NewDriver()
+	if err := drv.Connect(&driver.Config{Database: filepath.Join(t.TempDir(), "test.db")}); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer drv.Close()
+
+	// 旧版本的迁移表结构
+	if _, err := drv.Exec(ctx, `CREATE TABLE migrations (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  migration TEXT NOT NULL,
+  batch INTEGER NOT NULL,
+  executed_at TEXT DEFAULT CURRENT_TIMESTAMP
+)`); err != nil {
+		t.Fatalf("failed to create legacy table: %v", err)
+	}
+	if _, err := drv.Exec(ctx, "INSERT INTO migrations (migration, batch) VALUES ('001_legacy', 1)"); err != nil {
+		t.Fatalf("failed to insert legacy record: %v", err)
+	}
+
+	if err := drv.CreateMigrationsTable(ctx, "migrations"); err != nil {
+		t.Fatalf("failed to upgrade migrations table: %v", err)
+	}
+	// 再次调用应当是幂等的
+	if err := drv.CreateMigrationsTable(ctx, "migrations"); err != nil {
+		t.Fatalf("second CreateMigrationsTable failed: %v", err)
+	}
+
+	record := driver.MigrationRecord{
+		Migration: "002_new",
+		Batch:     2,
+		Checksum:  "abc123",
+		Duration:  1250 * time.Millisecond,
+		Host:      "ci-runner",
+		Version:   "v0.1.0",
+		User:      "deploy",
+		Note:      "hotfix #42",
+	}
+	if err := drv.RecordMigration(ctx, "migrations", record); err != nil {
+		t.Fatalf("failed to record migration: %v", err)
+	}
+
+	records, err := drv.GetExecutedMigrations(ctx, "migrations")
+	if err != nil {
+		t.Fatalf("failed to get migrations: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Checksum != "" {
+		t.Errorf("expected empty checksum for legacy record, got %q", records[0].Checksum)
+	}
+	if records[1].Checksum != "abc123" {
+		t.Errorf("expected checksum 'abc123', got %q", records[1].Checksum)
+	}
+	if records[0].Duration != 0 || records[0].Host != "" || records[0].Note != "" {
+		t.Errorf("expected empty metadata for legacy record, got %+v", records[0])
+	}
+	got := records[1]
+	got.ID, got.ExecutedAt = 0, time.Time{}
+	if got != record {
+		t.Errorf("expected %+v, got %+v", record, got)
+	}
+}
@@ -0,0 +1,1125 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/flyits/migro/pkg/driver"
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// Ensure Driver implements driver.AlterCompiler
+var _ driver.AlterCompiler = (*Driver)(nil)
+
+// rebuildTablePrefix prefixes the name of the table created during a rebuild
+const rebuildTablePrefix = "__temp__"
+
+var (
+	autoIncrementPattern   = regexp.MustCompile(`(?i)\bAUTOINCREMENT\b`)
+	namedForeignKeyPattern = regexp.MustCompile("(?i)CONSTRAINT\\s+[\"`\\[]?(\\w+)[\"`\\]]?\\s+FOREIGN\\s+KEY\\s*\\(([^)]*)\\)")
+	namedCheckPattern      = regexp.MustCompile("(?i)CONSTRAINT\\s+[\"`\\[]?(\\w+)[\"`\\]]?\\s+CHECK\\s*\\(")
+	generatedPattern       = regexp.MustCompile(`(?i)\bAS\s*\(`)
+	triggerNamePattern     = regexp.MustCompile("(?is)^\\s*CREATE\\s+(?:TEMP\\s+|TEMPORARY\\s+)?TRIGGER\\s+(?:IF\\s+NOT\\s+EXISTS\\s+)?[\"`\\[]?([\\w.]+)")
+)
+
+// tableDefinition is the current definition of a table read from
+// sqlite_master and the table PRAGMAs
+type tableDefinition struct {
+	name          string
+	columns       []tableColumn
+	autoIncrement bool
+	uniques       [][]string // UNIQUE constraints declared in the table
+	foreignKeys   []tableForeignKey
+	checks        []tableCheck // CHECK table constraints, named or not
+	indexes       []tableIndex // indexes created with CREATE INDEX
+	triggers      []string
+}
+
+type tableColumn struct {
+	name         string
+	typ          string
+	notNull      bool
+	defaultValue sql.NullString
+	pk           int // position in the primary key, 0 if not part of it
+	generated    bool
+	stored       bool   // the generated column is stored
+	expression   string // expression of the generated column
+	collation    string
+	checks       []tableCheck // CHECK constraints declared with the column
+}
+
+type tableForeignKey struct {
+	name           string // constraint name, empty when unnamed
+	columns        []string
+	referenceTable string
+	references     []string
+	onUpdate       string
+	onDelete       string
+}
+
+type tableCheck struct {
+	name       string // constraint name, empty when unnamed
+	expression string
+}
+
+type tableIndex struct {
+	name       string
+	unique     bool
+	columns    []string
+	descending []bool // whether each column is sorted in descending order
+	sql        string
+	complex    bool // partial or expression index, recreated from its SQL
+}
+
+// needsRebuild reports whether the alteration contains operations that
+// SQLite cannot perform with ALTER TABLE
+func needsRebuild(table *schema.Table) bool {
+	if len(table.DropColumns) > 0 || len(table.DropForeignKeys) > 0 || len(table.ForeignKeys) > 0 ||
+		len(table.DropChecks) > 0 || len(table.Checks) > 0 {
+		return true
+	}
+	for _, col := range table.Columns {
+		// ADD COLUMN cannot add stored generated columns
+		if col.Change || col.GeneratedAs != "" && col.IsStored {
+			return true
+		}
+	}
+	return false
+}
+
+// CompileAlterTable compiles an alteration of an existing table. Operations
+// that SQLite cannot perform in place (dropping or changing columns, adding
+// stored generated columns, adding or dropping foreign keys or check
+// constraints) rebuild the table: a new table is created with the
+// altered definition, the data is copied, the old table is dropped, the new
+// one renamed and its indexes and triggers recreated.
+//
+// When q is a transaction, foreign key enforcement cannot be disabled, so the
+// checks are deferred to the commit instead. Dropping the old table would
+// then fire the ON DELETE actions of the tables referencing it, which is
+// refused with an error.
+func (d *Driver) CompileAlterTable(ctx context.Context, q driver.Queryer, table *schema.Table) ([]string, error) {
+	if !needsRebuild(table) {
+		return d.grammar.CompileAlter(table), nil
+	}
+
+	def, err := readTableDefinition(ctx, q, table.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []string
+	if _, inTx := q.(driver.Transaction); inTx {
+		enforced, err := foreignKeysEnforced(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		if enforced {
+			if err := checkReferencingActions(ctx, q, table.Name); err != nil {
+				return nil, err
+			}
+			statements = append(statements, "PRAGMA defer_foreign_keys = ON")
+		}
+	}
+
+	rebuild, err := d.grammar.compileRebuild(def, table)
+	if err != nil {
+		return nil, err
+	}
+	return append(statements, rebuild...), nil
+}
+
+// rebuildTable rebuilds a table outside of a migration transaction, following
+// the procedure recommended by SQLite: foreign keys are disabled on a
+// dedicated connection, the rebuild runs in a transaction and the foreign
+// keys are checked before committing.
+func (d *Driver) rebuildTable(ctx context.Context, table *schema.Table) error {
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("sqlite: failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	var enforced bool
+	if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enforced); err != nil {
+		return fmt.Errorf("sqlite: failed to read foreign_keys: %w", err)
+	}
+	if enforced {
+		if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+			return fmt.Errorf("sqlite: failed to disable foreign keys: %w", err)
+		}
+		defer conn.ExecContext(context.WithoutCancel(ctx), "PRAGMA foreign_keys = ON")
+	}
+
+	sqlTx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite: failed to begin transaction: %w", err)
+	}
+	tx := &transaction{tx: sqlTx}
+
+	err = func() error {
+		def, err := readTableDefinition(ctx, tx, table.Name)
+		if err != nil {
+			return err
+		}
+		statements, err := d.grammar.compileRebuild(def, table)
+		if err != nil {
+			return err
+		}
+		for _, stmt := range statements {
+			if _, err := tx.Exec(ctx, stmt); err != nil {
+				return fmt.Errorf("sqlite: failed to alter table %s: %w", table.Name, err)
+			}
+		}
+		if enforced {
+			return checkForeignKeys(ctx, tx, table.Name)
+		}
+		return nil
+	}()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sqlite: failed to commit rebuild of %s: %w", table.Name, err)
+	}
+	return nil
+}
+
+// compileRebuild generates the statements rebuilding a table with the
+// alteration applied. CHECK constraints, collations and generated columns
+// are kept. A CHECK constraint that only refers to dropped columns is dropped
+// with them, one that also refers to other columns is an error, as are the
+// triggers and the partial or expression indexes referring to a dropped or
+// renamed column: their SQL is recreated as is.
+func (g *Grammar) compileRebuild(def *tableDefinition, table *schema.Table) ([]string, error) {
+	dropped := make(map[string]bool, len(table.DropColumns))
+	for _, name := range table.DropColumns {
+		dropped[name] = true
+	}
+	droppedChecks := make(map[string]bool, len(table.DropChecks))
+	for _, name := range table.DropChecks {
+		droppedChecks[name] = true
+	}
+	droppedIndexes := make(map[string]bool, len(table.DropIndexes))
+	for _, name := range table.DropIndexes {
+		droppedIndexes[name] = true
+	}
+	changed := make(map[string]*schema.Column)
+	for _, col := range table.Columns {
+		if col.Change {
+			changed[col.Name] = col
+		}
+	}
+	rename := func(name string) string {
+		if newName, ok := table.RenameColumns[name]; ok {
+			return newName
+		}
+		return name
+	}
+
+	// Primary key columns ordered by their position in the key
+	var primary []tableColumn
+	for pos := 1; pos <= len(def.columns); pos++ {
+		for _, c := range def.columns {
+			if c.pk == pos && !dropped[c.name] {
+				primary = append(primary, c)
+			}
+		}
+	}
+	inlinePrimary := len(primary) == 1 && changed[rename(primary[0].name)] == nil && changed[primary[0].name] == nil
+
+	var definitions, copyTo, copyFrom []string
+	primaryDeclared := inlinePrimary
+	for _, c := range def.columns {
+		if dropped[c.name] {
+			continue
+		}
+		name := rename(c.name)
+		col, ok := changed[name]
+		if !ok {
+			col, ok = changed[c.name]
+		}
+		if ok {
+			renamed := *col
+			renamed.Name = name
+			definitions = append(definitions, g.CompileColumn(&renamed))
+			primaryDeclared = primaryDeclared || col.IsPrimary || col.IsAutoIncrement
+			// Generated columns cannot be written
+			if col.GeneratedAs != "" {
+				continue
+			}
+		} else {
+			if c.generated && c.expression != "" {
+				expression, ok := g.renameExpression(c.expression, dropped, rename)
+				if !ok {
+					continue
+				}
+				c.expression = expression
+			}
+			checks, err := g.carryChecks(def, table, c.checks, droppedChecks, dropped, rename)
+			if err != nil {
+				return nil, err
+			}
+			c.checks = checks
+			definitions = append(definitions, g.compileExistingColumn(c, name, inlinePrimary && c.pk == 1, def.autoIncrement))
+			if c.generated && c.expression != "" {
+				continue
+			}
+		}
+		copyTo = append(copyTo, g.wrap(name))
+		copyFrom = append(copyFrom, g.wrap(c.name))
+	}
+
+	// Added columns are appended and take their default value
+	for _, col := range table.Columns {
+		if !col.Change {
+			definitions = append(definitions, g.CompileColumn(col))
+		}
+	}
+
+	if len(primary) > 0 && !primaryDeclared {
+		cols := make([]string, len(primary))
+		for i, c := range primary {
+			cols[i] = g.wrap(rename(c.name))
+		}
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(cols, ", ")))
+	}
+
+	for _, unique := range def.uniques {
+		if cols, ok := g.renameColumns(unique, dropped, rename); ok {
+			definitions = append(definitions, fmt.Sprintf("UNIQUE (%s)", strings.Join(cols, ", ")))
+		}
+	}
+
+	droppedForeignKeys := make(map[string]bool, len(table.DropForeignKeys))
+	for _, name := range table.DropForeignKeys {
+		droppedForeignKeys[name] = true
+	}
+	for _, fk := range def.foreignKeys {
+		if droppedForeignKeys[fk.name] || droppedForeignKeys[g.generateForeignKeyName(table.Name, fk.columns)] {
+			continue
+		}
+		cols, ok := g.renameColumns(fk.columns, dropped, rename)
+		if !ok {
+			continue
+		}
+		definitions = append(definitions, g.compileExistingForeignKey(fk, cols))
+	}
+	for _, fk := range table.ForeignKeys {
+		definitions = append(definitions, g.compileForeignKeyInline(table.Name, fk))
+	}
+
+	checks, err := g.carryChecks(def, table, def.checks, droppedChecks, dropped, rename)
+	if err != nil {
+		return nil, err
+	}
+	for _, check := range checks {
+		definitions = append(definitions, g.compileExistingCheck(check))
+	}
+	for _, check := range table.Checks {
+		definitions = append(definitions, g.compileCheckInline(check))
+	}
+
+	tempName := rebuildTablePrefix + table.Name
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", g.wrapTable(tempName), strings.Join(definitions, ",\n  ")),
+	}
+	if len(copyTo) > 0 {
+		statements = append(statements, fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s",
+			g.wrapTable(tempName), strings.Join(copyTo, ", "), strings.Join(copyFrom, ", "), g.wrapTable(table.Name)))
+	}
+	statements = append(statements,
+		g.CompileDrop(table.Name),
+		g.CompileRename(tempName, table.Name),
+	)
+
+	// Recreate the indexes and triggers dropped with the old table
+	for _, idx := range def.indexes {
+		if droppedIndexes[idx.name] {
+			continue
+		}
+		if idx.complex {
+			if err := checkRecreated(def, table, "index "+idx.name, idx.sql); err != nil {
+				return nil, err
+			}
+			statements = append(statements, idx.sql)
+			continue
+		}
+		cols, ok := g.renameColumns(idx.columns, dropped, rename)
+		if !ok {
+			continue
+		}
+		for i, desc := range idx.descending {
+			if desc {
+				cols[i] += " DESC"
+			}
+		}
+		unique := ""
+		if idx.unique {
+			unique = "UNIQUE "
+		}
+		statements = append(statements, fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)",
+			unique, g.wrap(idx.name), g.wrapTable(table.Name), strings.Join(cols, ", ")))
+	}
+	for _, trigger := range def.triggers {
+		name := trigger
+		if match := triggerNamePattern.FindStringSubmatch(trigger); match != nil {
+			name = match[1]
+		}
+		if err := checkRecreated(def, table, "trigger "+name, trigger); err != nil {
+			return nil, err
+		}
+		statements = append(statements, trigger)
+	}
+
+	for _, idx := range table.Indexes {
+		if idx.Type != schema.IndexTypePrimary {
+			statements = append(statements, g.CompileIndex(table.Name, idx))
+		}
+	}
+
+	return statements, nil
+}
+
+// carryChecks returns the CHECK constraints of the current definition that
+// the rebuilt table keeps, with the renamed columns applied
+func (g *Grammar) carryChecks(def *tableDefinition, table *schema.Table, checks []tableCheck, droppedChecks, dropped map[string]bool, rename func(string) string) ([]tableCheck, error) {
+	var kept []tableCheck
+	for _, check := range checks {
+		if check.name != "" && droppedChecks[check.name] {
+			continue
+		}
+		refs, droppedRefs := referencedColumns(def, table, check.expression)
+		if len(droppedRefs) > 0 {
+			if len(droppedRefs) == len(refs) {
+				continue
+			}
+			return nil, fmt.Errorf("sqlite: cannot rebuild table %s: %s refers to dropped column %s, drop the constraint first",
+				table.Name, describeCheck(check), droppedRefs[0])
+		}
+		expression, _ := g.renameExpression(check.expression, dropped, rename)
+		kept = append(kept, tableCheck{name: check.name, expression: expression})
+	}
+	return kept, nil
+}
+
+// checkRecreated returns an error when the SQL of a trigger or of a partial
+// or expression index, recreated as is after the rebuild, refers to a
+// dropped or renamed column
+func checkRecreated(def *tableDefinition, table *schema.Table, object, sql string) error {
+	renamed := make(map[string]bool, len(table.RenameColumns))
+	for from := range table.RenameColumns {
+		renamed[strings.ToLower(from)] = true
+	}
+	refs, droppedRefs := referencedColumns(def, table, sql)
+	if len(droppedRefs) > 0 {
+		return fmt.Errorf("sqlite: cannot rebuild table %s: %s refers to dropped column %s, drop it first and recreate it after the alteration",
+			table.Name, object, droppedRefs[0])
+	}
+	for _, ref := range refs {
+		if renamed[strings.ToLower(ref)] {
+			return fmt.Errorf("sqlite: cannot rebuild table %s: %s refers to renamed column %s, drop it first and recreate it after the alteration",
+				table.Name, object, ref)
+		}
+	}
+	return nil
+}
+
+// referencedColumns returns the columns of the current definition that an
+// expression or statement refers to, and those of them that are dropped
+func referencedColumns(def *tableDefinition, table *schema.Table, sql string) (refs, droppedRefs []string) {
+	columns := make(map[string]string, len(def.columns))
+	for _, c := range def.columns {
+		columns[strings.ToLower(c.name)] = c.name
+	}
+	dropped := make(map[string]bool, len(table.DropColumns))
+	for _, name := range table.DropColumns {
+		dropped[strings.ToLower(name)] = true
+	}
+
+	seen := make(map[string]bool)
+	for _, identifier := range identifiers(sql) {
+		key := strings.ToLower(identifier)
+		name, ok := columns[key]
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		refs = append(refs, name)
+		if dropped[key] {
+			droppedRefs = append(droppedRefs, name)
+		}
+	}
+	return refs, droppedRefs
+}
+
+// identifiers returns the identifiers of an expression or statement, quoted
+// or not, leaving out string literals
+func identifiers(sql string) []string {
+	var list []string
+	for i := 0; i < len(sql); {
+		switch c := sql[i]; {
+		case c == '\'':
+			i = skipQuoted(sql, i, '\'')
+		case c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := skipQuoted(sql, i, closing)
+			list = append(list, strings.TrimSuffix(sql[i+1:end], string(closing)))
+			i = end
+		case isIdentifierStart(c):
+			end := identifierEnd(sql, i)
+			list = append(list, sql[i:end])
+			i = end
+		default:
+			i++
+		}
+	}
+	return list
+}
+
+// describeCheck names a CHECK constraint in error messages
+func describeCheck(check tableCheck) string {
+	if check.name != "" {
+		return "CHECK constraint " + check.name
+	}
+	return fmt.Sprintf("CHECK (%s)", check.expression)
+}
+
+// compileExistingCheck generates a CHECK constraint read from the database
+func (g *Grammar) compileExistingCheck(check tableCheck) string {
+	if check.name == "" {
+		return fmt.Sprintf("CHECK (%s)", check.expression)
+	}
+	return g.compileCheckInline(&schema.Check{Name: check.name, Expression: check.expression})
+}
+
+// compileExistingColumn generates the definition of a column read from the database
+func (g *Grammar) compileExistingColumn(c tableColumn, name string, primary, autoIncrement bool) string {
+	var sb strings.Builder
+
+	sb.WriteString(g.wrap(name))
+	if c.typ != "" {
+		sb.WriteString(" ")
+		sb.WriteString(c.typ)
+	}
+	if c.generated && c.expression != "" {
+		sb.WriteString(g.compileGenerated(c.expression, c.stored))
+	}
+	if primary {
+		sb.WriteString(" PRIMARY KEY")
+		if autoIncrement {
+			sb.WriteString(" AUTOINCREMENT")
+		}
+	}
+	if c.notNull {
+		sb.WriteString(" NOT NULL")
+	}
+	if c.defaultValue.Valid {
+		sb.WriteString(" DEFAULT ")
+		sb.WriteString(c.defaultValue.String)
+	}
+	if c.collation != "" {
+		sb.WriteString(" COLLATE ")
+		sb.WriteString(c.collation)
+	}
+	for _, check := range c.checks {
+		sb.WriteString(" ")
+		sb.WriteString(g.compileExistingCheck(check))
+	}
+
+	return sb.String()
+}
+
+// compileExistingForeignKey generates the definition of a foreign key read from the database
+func (g *Grammar) compileExistingForeignKey(fk tableForeignKey, cols []string) string {
+	var sb strings.Builder
+
+	if fk.name != "" {
+		sb.WriteString("CONSTRAINT ")
+		sb.WriteString(g.wrap(fk.name))
+		sb.WriteString(" ")
+	}
+
+	refs := make([]string, len(fk.references))
+	for i, ref := range fk.references {
+		refs[i] = g.wrap(ref)
+	}
+	fmt.Fprintf(&sb, "FOREIGN KEY (%s) REFERENCES %s", strings.Join(cols, ", "), g.wrapTable(fk.referenceTable))
+	if len(refs) > 0 {
+		fmt.Fprintf(&sb, " (%s)", strings.Join(refs, ", "))
+	}
+
+	if fk.onDelete != "" && fk.onDelete != "NO ACTION" {
+		sb.WriteString(" ON DELETE " + fk.onDelete)
+	}
+	if fk.onUpdate != "" && fk.onUpdate != "NO ACTION" {
+		sb.WriteString(" ON UPDATE " + fk.onUpdate)
+	}
+
+	return sb.String()
+}
+
+// renameColumns wraps the columns with their new names. It reports false
+// when one of the columns is dropped.
+func (g *Grammar) renameColumns(columns []string, dropped map[string]bool, rename func(string) string) ([]string, bool) {
+	cols := make([]string, len(columns))
+	for i, col := range columns {
+		if dropped[col] {
+			return nil, false
+		}
+		cols[i] = g.wrap(rename(col))
+	}
+	return cols, true
+}
+
+// renameExpression rewrites the column references of a CHECK expression
+// with their new names. It reports false when the expression refers to a
+// dropped column.
+func (g *Grammar) renameExpression(expression string, dropped map[string]bool, rename func(string) string) (string, bool) {
+	var sb strings.Builder
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		var name string
+		end := i + 1
+		switch {
+		case c == '\'':
+			end = skipQuoted(expression, i, '\'')
+			sb.WriteString(expression[i:end])
+			i = end
+			continue
+		case c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end = skipQuoted(expression, i, closing)
+			name = strings.TrimSuffix(expression[i+1:end], string(closing))
+		case isIdentifierStart(c):
+			end = identifierEnd(expression, i)
+			name = expression[i:end]
+		default:
+			sb.WriteByte(c)
+			i++
+			continue
+		}
+
+		if dropped[name] {
+			return "", false
+		}
+		if newName := rename(name); newName != name {
+			sb.WriteString(g.wrap(newName))
+		} else {
+			sb.WriteString(expression[i:end])
+		}
+		i = end
+	}
+	return sb.String(), true
+}
+
+// readChecks reads the named CHECK constraints of a table, which SQLite
+// only exposes in the table SQL
+func readChecks(def *tableDefinition, tableSQL string) {
+	for _, match := range namedCheckPattern.FindAllStringSubmatchIndex(tableSQL, -1) {
+		end := closingParen(tableSQL, match[1])
+		if end < 0 {
+			continue
+		}
+		def.checks = append(def.checks, tableCheck{
+			name:       tableSQL[match[2]:match[3]],
+			expression: strings.TrimSpace(tableSQL[match[1]:end]),
+		})
+	}
+}
+
+// readConstraints reads the CHECK constraints of a table and the collations
+// and CHECK constraints of its columns, which SQLite only exposes in the
+// table SQL
+func readConstraints(def *tableDefinition, tableSQL string) {
+	open := strings.IndexByte(tableSQL, '(')
+	if open < 0 {
+		return
+	}
+	end := closingParen(tableSQL, open+1)
+	if end < 0 {
+		return
+	}
+
+	columns := make(map[string]int, len(def.columns))
+	for i, c := range def.columns {
+		columns[strings.ToLower(c.name)] = i
+	}
+	for _, definition := range splitDefinitions(tableSQL[open+1 : end]) {
+		name, rest := leadingToken(definition)
+		if isIdentifierStart(definition[0]) {
+			switch strings.ToUpper(name) {
+			case "CONSTRAINT", "CHECK", "PRIMARY", "UNIQUE", "FOREIGN":
+				checks, _ := parseConstraints(definition)
+				def.checks = append(def.checks, checks...)
+				continue
+			}
+		}
+		if i, ok := columns[strings.ToLower(name)]; ok {
+			def.columns[i].checks, def.columns[i].collation = parseConstraints(definition[rest:])
+		}
+	}
+}
+
+// parseConstraints returns the CHECK constraints and the collation declared
+// in a column or table constraint definition
+func parseConstraints(definition string) (checks []tableCheck, collation string) {
+	var name string // name of the constraint being declared
+	for i := 0; i < len(definition); {
+		switch c := definition[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(definition, i, c)
+		case c == '[':
+			i = skipQuoted(definition, i, ']')
+		case c == '(':
+			end := closingParen(definition, i+1)
+			if end < 0 {
+				return checks, collation
+			}
+			i = end + 1
+		case isIdentifierStart(c):
+			end := identifierEnd(definition, i)
+			switch strings.ToUpper(definition[i:end]) {
+			case "CONSTRAINT":
+				var n int
+				name, n = leadingToken(strings.TrimLeft(definition[end:], " \t\r\n"))
+				end = len(definition) - len(strings.TrimLeft(definition[end:], " \t\r\n")) + n
+			case "CHECK":
+				open := strings.IndexByte(definition[end:], '(')
+				if open < 0 {
+					return checks, collation
+				}
+				closing := closingParen(definition, end+open+1)
+				if closing < 0 {
+					return checks, collation
+				}
+				checks = append(checks, tableCheck{name: name, expression: strings.TrimSpace(definition[end+open+1 : closing])})
+				name = ""
+				end = closing + 1
+			case "COLLATE":
+				var n int
+				collation, n = leadingToken(strings.TrimLeft(definition[end:], " \t\r\n"))
+				end = len(definition) - len(strings.TrimLeft(definition[end:], " \t\r\n")) + n
+			default:
+				name = ""
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	return checks, collation
+}
+
+// readGeneratedExpressions reads the expressions of the generated columns,
+// which SQLite only exposes in the table SQL
+func readGeneratedExpressions(def *tableDefinition, tableSQL string) {
+	open := strings.IndexByte(tableSQL, '(')
+	if open < 0 {
+		return
+	}
+	end := closingParen(tableSQL, open+1)
+	if end < 0 {
+		return
+	}
+
+	expressions := make(map[string]string)
+	for _, definition := range splitDefinitions(tableSQL[open+1 : end]) {
+		loc := generatedPattern.FindStringIndex(definition)
+		if loc == nil {
+			continue
+		}
+		if closing := closingParen(definition, loc[1]); closing >= 0 {
+			expressions[strings.ToLower(leadingIdentifier(definition))] = strings.TrimSpace(definition[loc[1]:closing])
+		}
+	}
+
+	for i, c := range def.columns {
+		if c.generated {
+			def.columns[i].expression = expressions[strings.ToLower(c.name)]
+		}
+	}
+}
+
+// splitDefinitions splits the body of a CREATE TABLE statement into its
+// column and constraint definitions
+func splitDefinitions(body string) []string {
+	var definitions []string
+	depth, start := 0, 0
+	for i := 0; i < len(body); {
+		switch c := body[i]; c {
+		case '\'', '"', '`':
+			i = skipQuoted(body, i, c)
+			continue
+		case '[':
+			i = skipQuoted(body, i, ']')
+			continue
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				definitions = append(definitions, strings.TrimSpace(body[start:i]))
+				start = i + 1
+			}
+		}
+		i++
+	}
+	return append(definitions, strings.TrimSpace(body[start:]))
+}
+
+// leadingIdentifier returns the unquoted identifier a definition starts with
+func leadingIdentifier(definition string) string {
+	name, _ := leadingToken(definition)
+	return name
+}
+
+// leadingToken returns the unquoted identifier a definition starts with and
+// the index after it
+func leadingToken(definition string) (string, int) {
+	if definition == "" {
+		return "", 0
+	}
+	switch c := definition[0]; c {
+	case '"', '`', '[':
+		closing := c
+		if c == '[' {
+			closing = ']'
+		}
+		end := skipQuoted(definition, 0, closing)
+		return strings.TrimSuffix(definition[1:end], string(closing)), end
+	}
+	if end := strings.IndexAny(definition, " \t\r\n("); end >= 0 {
+		return definition[:end], end
+	}
+	return definition, len(definition)
+}
+
+// identifierEnd returns the index after the unquoted identifier starting at start
+func identifierEnd(s string, start int) int {
+	end := start + 1
+	for end < len(s) && (isIdentifierStart(s[end]) || s[end] >= '0' && s[end] <= '9') {
+		end++
+	}
+	return end
+}
+
+// closingParen returns the index of the parenthesis closing an expression
+// that starts at start, or -1 when it is not closed
+func closingParen(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); {
+		switch c := s[i]; c {
+		case '\'', '"', '`':
+			i = skipQuoted(s, i, c)
+			continue
+		case '[':
+			i = skipQuoted(s, i, ']')
+			continue
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+		i++
+	}
+	return -1
+}
+
+// skipQuoted returns the index after the quoted string or identifier
+// starting at start and ending with closing
+func skipQuoted(s string, start int, closing byte) int {
+	if end := strings.IndexByte(s[start+1:], closing); end >= 0 {
+		return start + end + 2
+	}
+	return len(s)
+}
+
+// isIdentifierStart reports whether c can start an unquoted identifier
+func isIdentifierStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// readTableDefinition reads the current definition of a table
+func readTableDefinition(ctx context.Context, q driver.Queryer, name string) (*tableDefinition, error) {
+	def := &tableDefinition{name: name}
+
+	tableSQL, err := readTableSQL(ctx, q, name)
+	if err != nil {
+		return nil, err
+	}
+	if tableSQL == "" {
+		return nil, fmt.Errorf("sqlite: table %s does not exist", name)
+	}
+	def.autoIncrement = autoIncrementPattern.MatchString(tableSQL)
+
+	if def.columns, err = readColumns(ctx, q, name); err != nil {
+		return nil, err
+	}
+	readGeneratedExpressions(def, tableSQL)
+	readConstraints(def, tableSQL)
+
+	if err := readForeignKeys(ctx, q, def, tableSQL); err != nil {
+		return nil, err
+	}
+	if err := readIndexes(ctx, q, def); err != nil {
+		return nil, err
+	}
+
+	err = queryEach(ctx, q, func(rows *sql.Rows) error {
+		var trigger string
+		if err := rows.Scan(&trigger); err != nil {
+			return err
+		}
+		def.triggers = append(def.triggers, trigger)
+		return nil
+	}, "SELECT sql FROM sqlite_master WHERE type = 'trigger' AND tbl_name = ? AND sql IS NOT NULL", name)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: failed to read triggers of %s: %w", name, err)
+	}
+
+	return def, nil
+}
+
+// readTableSQL returns the CREATE TABLE statement of a table, or an empty
+// string when the table does not exist
+func readTableSQL(ctx context.Context, q driver.Queryer, name string) (string, error) {
+	var tableSQL string
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		return rows.Scan(&tableSQL)
+	}, "SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", name)
+	if err != nil {
+		return "", fmt.Errorf("sqlite: failed to read definition of %s: %w", name, err)
+	}
+	return tableSQL, nil
+}
+
+// readColumns reads the columns of a table in declaration order, including
+// the generated columns, without their expressions
+func readColumns(ctx context.Context, q driver.Queryer, name string) ([]tableColumn, error) {
+	var columns []tableColumn
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		var (
+			c      tableColumn
+			hidden int
+		)
+		if err := rows.Scan(&c.name, &c.typ, &c.notNull, &c.defaultValue, &c.pk, &hidden); err != nil {
+			return err
+		}
+		// hidden is 2 for virtual and 3 for stored generated columns
+		c.generated = hidden == 2 || hidden == 3
+		c.stored = hidden == 3
+		columns = append(columns, c)
+		return nil
+	}, `SELECT name, type, "notnull", dflt_value, pk, hidden FROM pragma_table_xinfo(?) WHERE hidden <> 1 ORDER BY cid`, name)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: failed to read columns of %s: %w", name, err)
+	}
+	return columns, nil
+}
+
+// readForeignKeys reads the foreign keys of a table. Constraint names are not
+// exposed by PRAGMA foreign_key_list and are parsed from the table SQL.
+func readForeignKeys(ctx context.Context, q driver.Queryer, def *tableDefinition, tableSQL string) error {
+	names := make(map[string]string)
+	for _, match := range namedForeignKeyPattern.FindAllStringSubmatch(tableSQL, -1) {
+		names[normalizeColumnList(match[2])] = match[1]
+	}
+
+	byID := make(map[int]*tableForeignKey)
+	var order []int
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		var (
+			id, seq      int
+			table, from  string
+			to           sql.NullString
+			onUpd, onDel string
+		)
+		if err := rows.Scan(&id, &seq, &table, &from, &to, &onUpd, &onDel); err != nil {
+			return err
+		}
+		fk, ok := byID[id]
+		if !ok {
+			fk = &tableForeignKey{referenceTable: table, onUpdate: onUpd, onDelete: onDel}
+			byID[id] = fk
+			order = append(order, id)
+		}
+		fk.columns = append(fk.columns, from)
+		if to.Valid {
+			fk.references = append(fk.references, to.String)
+		}
+		return nil
+	}, `SELECT id, seq, "table", "from", "to", on_update, on_delete FROM pragma_foreign_key_list(?) ORDER BY id DESC, seq`, def.name)
+	if err != nil {
+		return fmt.Errorf("sqlite: failed to read foreign keys of %s: %w", def.name, err)
+	}
+
+	// PRAGMA foreign_key_list numbers the constraints in reverse declaration order
+	for _, id := range order {
+		fk := byID[id]
+		fk.name = names[strings.ToLower(strings.Join(fk.columns, ","))]
+		def.foreignKeys = append(def.foreignKeys, *fk)
+	}
+	return nil
+}
+
+// readIndexes reads the UNIQUE constraints and the indexes of a table
+func readIndexes(ctx context.Context, q driver.Queryer, def *tableDefinition) error {
+	type indexInfo struct {
+		name    string
+		unique  bool
+		origin  string
+		partial bool
+	}
+	var list []indexInfo
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		var idx indexInfo
+		if err := rows.Scan(&idx.name, &idx.unique, &idx.origin, &idx.partial); err != nil {
+			return err
+		}
+		list = append(list, idx)
+		return nil
+	}, `SELECT name, "unique", origin, partial FROM pragma_index_list(?) ORDER BY seq DESC`, def.name)
+	if err != nil {
+		return fmt.Errorf("sqlite: failed to read indexes of %s: %w", def.name, err)
+	}
+
+	for _, info := range list {
+		if info.origin == "pk" {
+			continue
+		}
+
+		idx := tableIndex{name: info.name, unique: info.unique, complex: info.partial}
+		err := queryEach(ctx, q, func(rows *sql.Rows) error {
+			var (
+				cid  int
+				name sql.NullString
+				desc bool
+			)
+			if err := rows.Scan(&cid, &name, &desc); err != nil {
+				return err
+			}
+			if !name.Valid {
+				idx.complex = true
+				return nil
+			}
+			idx.columns = append(idx.columns, name.String)
+			idx.descending = append(idx.descending, desc)
+			return nil
+		}, `SELECT cid, name, "desc" FROM pragma_index_xinfo(?) WHERE key = 1 ORDER BY seqno`, info.name)
+		if err != nil {
+			return fmt.Errorf("sqlite: failed to read index %s: %w", info.name, err)
+		}
+
+		if info.origin == "u" {
+			def.uniques = append(def.uniques, idx.columns)
+			continue
+		}
+
+		if idx.complex {
+			err := queryEach(ctx, q, func(rows *sql.Rows) error {
+				return rows.Scan(&idx.sql)
+			}, "SELECT sql FROM sqlite_master WHERE type = 'index' AND name = ?", info.name)
+			if err != nil {
+				return fmt.Errorf("sqlite: failed to read index %s: %w", info.name, err)
+			}
+		}
+		def.indexes = append(def.indexes, idx)
+	}
+	return nil
+}
+
+// foreignKeysEnforced reports whether foreign key constraints are enforced
+func foreignKeysEnforced(ctx context.Context, q driver.Queryer) (bool, error) {
+	var enforced bool
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		return rows.Scan(&enforced)
+	}, "PRAGMA foreign_keys")
+	if err != nil {
+		return false, fmt.Errorf("sqlite: failed to read foreign_keys: %w", err)
+	}
+	return enforced, nil
+}
+
+// checkReferencingActions returns an error when another table references the
+// given one with an action that dropping the table would trigger
+func checkReferencingActions(ctx context.Context, q driver.Queryer, name string) error {
+	var referencing, action string
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		if referencing != "" {
+			return nil
+		}
+		return rows.Scan(&referencing, &action)
+	}, `SELECT m.name, f.on_delete FROM sqlite_master m, pragma_foreign_key_list(m.name) f
+WHERE m.type = 'table' AND m.name <> ? AND f."table" = ? COLLATE NOCASE AND f.on_delete <> 'NO ACTION'`, name, name)
+	if err != nil {
+		return fmt.Errorf("sqlite: failed to read foreign keys referencing %s: %w", name, err)
+	}
+	if referencing != "" {
+		return fmt.Errorf("sqlite: cannot rebuild table %s inside a transaction with foreign keys enabled: "+
+			"table %s references it with ON DELETE %s", name, referencing, action)
+	}
+	return nil
+}
+
+// checkForeignKeys returns an error when the rebuilt table violates a foreign key
+func checkForeignKeys(ctx context.Context, q driver.Queryer, name string) error {
+	var violations int
+	err := queryEach(ctx, q, func(rows *sql.Rows) error {
+		violations++
+		return nil
+	}, fmt.Sprintf("PRAGMA foreign_key_check(%s)", quoteString(name)))
+	if err != nil {
+		return fmt.Errorf("sqlite: failed to check foreign keys of %s: %w", name, err)
+	}
+	if violations > 0 {
+		return fmt.Errorf("sqlite: rebuild of %s violates %d foreign key constraint(s)", name, violations)
+	}
+	return nil
+}
+
+// queryEach runs a query and calls scan for each row. All rows are consumed
+// before it returns, so it is safe to use on a transaction.
+func queryEach(ctx context.Context, q driver.Queryer, scan func(*sql.Rows) error, query string, args ...interface{}) error {
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// normalizeColumnList normalizes a column list of a constraint for lookups
+func normalizeColumnList(list string) string {
+	parts := strings.Split(list, ",")
+	for i, part := range parts {
+		parts[i] = strings.ToLower(strings.Trim(strings.TrimSpace(part), "\"`[]"))
+	}
+	return strings.Join(parts, ",")
+}
+
+// quoteString quotes a string literal
+func quoteString(s string) string {
+	return "'" + escapeString(s) + "'"
+}
@@ -0,0 +1,379 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flyits/migro/pkg/driver"
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// 测试目标：验证重建表后数据、索引、外键保留，且在事务中遇到级联外键时拒绝重建
+
+// newRebuildDriver 创建包含 users/posts 表和数据的测试数据库
+func newRebuildDriver(t *testing.T) *Driver {
+	t.Helper()
+	ctx := context.Background()
+
+	drv := NewDriver()
+	if err := drv.Connect(&driver.Config{Database: filepath.Join(t.TempDir(), "rebuild.db")}); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { drv.Close() })
+
+	users := schema.NewTable("users")
+	users.ID()
+	users.String("name", 100)
+	posts := schema.NewTable("posts")
+	posts.ID()
+	posts.BigInteger("user_id")
+	posts.String("title", 100).Default("untitled")
+	posts.Text("body").Nullable()
+	posts.Index("title")
+	posts.Foreign("user_id").References("users", "id").OnDeleteCascade()
+
+	for _, table := range []*schema.Table{users, posts} {
+		if err := drv.CreateTable(ctx, table); err != nil {
+			t.Fatalf("failed to create %s: %v", table.Name, err)
+		}
+	}
+	for _, stmt := range []string{
+		`INSERT INTO users (id, name) VALUES (1, 'alice')`,
+		`INSERT INTO posts (id, user_id, title, body) VALUES (1, 1, 'hello', 'world')`,
+	} {
+		if _, err := drv.Exec(ctx, stmt); err != nil {
+			t.Fatalf("failed to insert data: %v", err)
+		}
+	}
+	return drv
+}
+
+func columnNames(t *testing.T, drv *Driver, table string) []string {
+	t.Helper()
+	var names []string
+	err := queryEach(context.Background(), drv, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		names = append(names, name)
+		return nil
+	}, "SELECT name FROM pragma_table_info(?) ORDER BY cid", table)
+	if err != nil {
+		t.Fatalf("failed to read columns: %v", err)
+	}
+	return names
+}
+
+func TestAlterTable_Rebuild(t *testing.T) {
+	ctx := context.Background()
+	drv := newRebuildDriver(t)
+
+	alter := schema.NewTable("posts")
+	alter.IsAlter = true
+	alter.DropColumn("body")
+	alter.ChangeString("title", 200).Nullable()
+
+	if err := drv.AlterTable(ctx, alter); err != nil {
+		t.Fatalf("failed to alter table: %v", err)
+	}
+
+	if got := strings.Join(columnNames(t, drv, "posts"), ","); got != "id,user_id,title" {
+		t.Errorf("unexpected columns %s", got)
+	}
+
+	var title string
+	if err := drv.QueryRow(ctx, "SELECT title FROM posts WHERE id = 1").Scan(&title); err != nil || title != "hello" {
+		t.Errorf("expected data to be copied, got %q (%v)", title, err)
+	}
+
+	var indexes int
+	if err := drv.QueryRow(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'posts_title_idx'").Scan(&indexes); err != nil || indexes != 1 {
+		t.Errorf("expected index to be recreated (%v)", err)
+	}
+
+	// 外键仍然生效：删除用户级联删除文章
+	if _, err := drv.Exec(ctx, "DELETE FROM users WHERE id = 1"); err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+	var count int
+	if err := drv.QueryRow(ctx, "SELECT COUNT(*) FROM posts").Scan(&count); err != nil || count != 0 {
+		t.Errorf("expected foreign key cascade to be preserved, got %d posts (%v)", count, err)
+	}
+}
+
+func TestAlterTable_DropForeignKey(t *testing.T) {
+	ctx := context.Background()
+	drv := newRebuildDriver(t)
+
+	alter := schema.NewTable("posts")
+	alter.IsAlter = true
+	alter.DropForeign("posts_user_id_fk")
+
+	if err := drv.AlterTable(ctx, alter); err != nil {
+		t.Fatalf("failed to alter table: %v", err)
+	}
+
+	var count int
+	if err := drv.QueryRow(ctx, "SELECT COUNT(*) FROM pragma_foreign_key_list('posts')").Scan(&count); err != nil || count != 0 {
+		t.Errorf("expected foreign key to be dropped, got %d (%v)", count, err)
+	}
+}
+
+func TestCompileAlterTable_InTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rebuild child table", func(t *testing.T) {
+		drv := newRebuildDriver(t)
+		alter := schema.NewTable("posts")
+		alter.IsAlter = true
+		alter.DropColumn("body")
+
+		tx, err := drv.Begin(ctx)
+		if err != nil {
+			t.Fatalf("failed to begin: %v", err)
+		}
+		sqls, err := drv.CompileAlterTable(ctx, tx, alter)
+		if err != nil {
+			tx.Rollback()
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, stmt := range sqls {
+			if _, err := tx.Exec(ctx, stmt); err != nil {
+				tx.Rollback()
+				t.Fatalf("failed to execute %s: %v", stmt, err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+
+		if got := strings.Join(columnNames(t, drv, "posts"), ","); got != "id,user_id,title" {
+			t.Errorf("unexpected columns %s", got)
+		}
+	})
+
+	t.Run("parent table referenced with cascade", func(t *testing.T) {
+		drv := newRebuildDriver(t)
+		alter := schema.NewTable("users")
+		alter.IsAlter = true
+		alter.ChangeText("name")
+
+		tx, err := drv.Begin(ctx)
+		if err != nil {
+			t.Fatalf("failed to begin: %v", err)
+		}
+		defer tx.Rollback()
+
+		if _, err := drv.CompileAlterTable(ctx, tx, alter); err == nil {
+			t.Error("expected error for a table referenced with ON DELETE CASCADE")
+		}
+	})
+}
+
+func TestAlterTable_ChangeEnum(t *testing.T) {
+	ctx := context.Background()
+	drv := newRebuildDriver(t)
+
+	alter := schema.NewTable("posts")
+	alter.IsAlter = true
+	alter.Enum("status", "draft", "published").Default("draft")
+	if err := drv.AlterTable(ctx, alter); err != nil {
+		t.Fatalf("failed to add enum column: %v", err)
+	}
+	if _, err := drv.Exec(ctx, "UPDATE posts SET status = 'archived'"); err == nil {
+		t.Fatal("expected the CHECK constraint to reject an unknown value")
+	}
+
+	// ChangeEnum 通过重建表修改允许的值
+	change := schema.NewTable("posts")
+	change.IsAlter = true
+	change.ChangeEnum("status", "draft", "published", "archived").Default("draft")
+	if err := drv.AlterTable(ctx, change); err != nil {
+		t.Fatalf("failed to change enum column: %v", err)
+	}
+	if _, err := drv.Exec(ctx, "UPDATE posts SET status = 'archived'"); err != nil {
+		t.Errorf("expected the new value to be accepted: %v", err)
+	}
+}
+
+func TestAlterTable_Checks(t *testing.T) {
+	ctx := context.Background()
+	drv := newRebuildDriver(t)
+
+	alter := schema.NewTable("posts")
+	alter.IsAlter = true
+	alter.Check("posts_title_not_empty", "length(title) > 0")
+	if err := drv.AlterTable(ctx, alter); err != nil {
+		t.Fatalf("failed to add check: %v", err)
+	}
+	if _, err := drv.Exec(ctx, "UPDATE posts SET title = ''"); err == nil {
+		t.Fatal("expected the check to reject an empty title")
+	}
+
+	// 重命名列并重建表后 CHECK 约束仍然保留
+	rename := schema.NewTable("posts")
+	rename.IsAlter = true
+	rename.RenameColumn("title", "headline")
+	rename.ChangeText("body").Nullable()
+	if err := drv.AlterTable(ctx, rename); err != nil {
+		t.Fatalf("failed to rebuild table: %v", err)
+	}
+	if _, err := drv.Exec(ctx, "UPDATE posts SET headline = ''"); err == nil {
+		t.Fatal("expected the check to be kept by the rebuild")
+	}
+
+	drop := schema.NewTable("posts")
+	drop.IsAlter = true
+	drop.DropCheck("posts_title_not_empty")
+	if err := drv.AlterTable(ctx, drop); err != nil {
+		t.Fatalf("failed to drop check: %v", err)
+	}
+	if _, err := drv.Exec(ctx, "UPDATE posts SET headline = ''"); err != nil {
+		t.Errorf("expected the check to be dropped: %v", err)
+	}
+}
+
+func TestAlterTable_GeneratedColumns(t *testing.T) {
+	ctx := context.Background()
+	drv := newRebuildDriver(t)
+
+	// ADD COLUMN 不能添加 STORED 生成列，需要重建表
+	alter := schema.NewTable("posts")
+	alter.IsAlter = true
+	alter.Integer("title_length").StoredAs("length(title)")
+	alter.Text("slug").Nullable().VirtualAs("lower(title)")
+	if !needsRebuild(alter) {
+		t.Fatal("expected a stored generated column to rebuild the table")
+	}
+	if err := drv.AlterTable(ctx, alter); err != nil {
+		t.Fatalf("failed to add generated columns: %v", err)
+	}
+
+	// 重建表时保留生成列，并随列的重命名更新表达式
+	rename := schema.NewTable("posts")
+	rename.IsAlter = true
+	rename.RenameColumn("title", "headline")
+	rename.DropColumn("body")
+	if err := drv.AlterTable(ctx, rename); err != nil {
+		t.Fatalf("failed to rebuild table: %v", err)
+	}
+	if _, err := drv.Exec(ctx, "UPDATE posts SET headline = 'Hello World'"); err != nil {
+		t.Fatalf("failed to update headline: %v", err)
+	}
+
+	var length int
+	var slug string
+	if err := drv.QueryRow(ctx, "SELECT title_length, slug FROM posts WHERE id = 1").Scan(&length, &slug); err != nil {
+		t.Fatalf("failed to read generated columns: %v", err)
+	}
+	if length != 11 || slug != "hello world" {
+		t.Errorf("expected 11 and 'hello world', got %d and %q", length, slug)
+	}
+}
+
+func TestAlterTable_IndexOptions(t *testing.T) {
+	ctx := context.Background()
+	drv := newRebuildDriver(t)
+
+	alter := schema.NewTable("users")
+	alter.IsAlter = true
+	alter.Timestamp("deleted_at").Nullable()
+	alter.Unique("name").Where("deleted_at IS NULL")
+	alter.Index("id").Named("users_id_desc_idx").Desc("id")
+	if err := drv.AlterTable(ctx, alter); err != nil {
+		t.Fatalf("failed to add indexes: %v", err)
+	}
+
+	// 部分唯一索引忽略已软删除的行
+	if _, err := drv.Exec(ctx, "UPDATE users SET deleted_at = '2026-01-01' WHERE id = 1"); err != nil {
+		t.Fatalf("failed to soft delete: %v", err)
+	}
+	if _, err := drv.Exec(ctx, "INSERT INTO users (id, name) VALUES (2, 'alice')"); err != nil {
+		t.Fatalf("expected the soft-deleted row to be ignored: %v", err)
+	}
+	if _, err := drv.Exec(ctx, "INSERT INTO users (id, name) VALUES (3, 'alice')"); err == nil {
+		t.Fatal("expected the partial unique index to reject a duplicate")
+	}
+
+	// 重建表后降序索引仍然降序
+	rebuild := schema.NewTable("users")
+	rebuild.IsAlter = true
+	rebuild.ChangeString("name", 200)
+	if err := drv.AlterTable(ctx, rebuild); err != nil {
+		t.Fatalf("failed to rebuild table: %v", err)
+	}
+	var indexSQL string
+	if err := drv.QueryRow(ctx, "SELECT sql FROM sqlite_master WHERE name = 'users_id_desc_idx'").Scan(&indexSQL); err != nil {
+		t.Fatalf("failed to read index: %v", err)
+	}
+	if !strings.Contains(indexSQL, `"id" DESC`) {
+		t.Errorf("expected a descending index, got %s", indexSQL)
+	}
+}
+
+func TestAlterTable_UnnamedChecksAndCollations(t *testing.T) {
+	ctx := context.Background()
+	drv := newRebuildDriver(t)
+
+	if _, err := drv.Exec(ctx, `CREATE TABLE tags (
+  id INTEGER PRIMARY KEY,
+  name TEXT COLLATE NOCASE CHECK (length(name) > 0),
+  note TEXT,
+  CHECK (id > 0)
+)`); err != nil {
+		t.Fatalf("failed to create tags: %v", err)
+	}
+
+	alter := schema.NewTable("tags")
+	alter.IsAlter = true
+	alter.DropColumn("note")
+	if err := drv.AlterTable(ctx, alter); err != nil {
+		t.Fatalf("failed to rebuild table: %v", err)
+	}
+
+	// 重建后未命名 CHECK 约束与 COLLATE 仍然生效
+	if _, err := drv.Exec(ctx, "INSERT INTO tags (id, name) VALUES (1, '')"); err == nil {
+		t.Error("expected the column check to be kept")
+	}
+	if _, err := drv.Exec(ctx, "INSERT INTO tags (id, name) VALUES (-1, 'go')"); err == nil {
+		t.Error("expected the table check to be kept")
+	}
+	if _, err := drv.Exec(ctx, "INSERT INTO tags (id, name) VALUES (1, 'Go')"); err != nil {
+		t.Fatalf("failed to insert tag: %v", err)
+	}
+	var count int
+	if err := drv.QueryRow(ctx, "SELECT COUNT(*) FROM tags WHERE name = 'go'").Scan(&count); err != nil {
+		t.Fatalf("failed to query tags: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected the NOCASE collation to be kept, got %d rows", count)
+	}
+}
+
+func TestAlterTable_RejectsStaleTrigger(t *testing.T) {
+	ctx := context.Background()
+	drv := newRebuildDriver(t)
+
+	if _, err := drv.Exec(ctx, `CREATE TRIGGER posts_body_touch AFTER UPDATE ON posts BEGIN UPDATE posts SET body = 'edited' WHERE id = NEW.id AND body IS NULL; END`); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+
+	alter := schema.NewTable("posts")
+	alter.IsAlter = true
+	alter.DropColumn("body")
+	err := drv.AlterTable(ctx, alter)
+	if err == nil || !strings.Contains(err.Error(), "trigger posts_body_touch refers to dropped column body") {
+		t.Fatalf("expected an error about the trigger, got %v", err)
+	}
+
+	// 报错发生在重建之前，表保持原样
+	if names := columnNames(t, drv, "posts"); !contains(names, "body") {
+		t.Errorf("expected posts to be left unchanged, got %v", names)
+	}
+}
@@ -0,0 +1,288 @@
+package sqlite
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// 测试目标需求: SQLite 不支持的 ALTER 操作通过重建表实现
+// 覆盖: needsRebuild, compileRebuild
+
+func TestNeedsRebuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		alter    func(*schema.Table)
+		expected bool
+	}{
+		{"add column", func(t *schema.Table) { t.String("phone", 20) }, false},
+		{"rename column", func(t *schema.Table) { t.RenameColumn("a", "b") }, false},
+		{"drop index", func(t *schema.Table) { t.DropIndex("users_email_idx") }, false},
+		{"drop column", func(t *schema.Table) { t.DropColumn("phone") }, true},
+		{"change column", func(t *schema.Table) { t.ChangeText("bio") }, true},
+		{"drop foreign key", func(t *schema.Table) { t.DropForeign("posts_user_id_fk") }, true},
+		{"add foreign key", func(t *schema.Table) { t.Foreign("user_id").References("users", "id") }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := schema.NewTable("users")
+			table.IsAlter = true
+			tt.alter(table)
+			if got := needsRebuild(table); got != tt.expected {
+				t.Errorf("needsRebuild() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// postsDefinition 返回 posts 表的当前定义
+func postsDefinition() *tableDefinition {
+	return &tableDefinition{
+		name:          "posts",
+		autoIncrement: true,
+		columns: []tableColumn{
+			{name: "id", typ: "INTEGER", pk: 1},
+			{name: "user_id", typ: "INTEGER", notNull: true},
+			{name: "title", typ: "TEXT", notNull: true, defaultValue: sql.NullString{String: "''", Valid: true}},
+			{name: "body", typ: "TEXT"},
+			{name: "slug", typ: "TEXT"},
+		},
+		uniques: [][]string{{"slug"}},
+		foreignKeys: []tableForeignKey{
+			{name: "posts_user_id_fk", columns: []string{"user_id"}, referenceTable: "users", references: []string{"id"}, onDelete: "CASCADE", onUpdate: "NO ACTION"},
+		},
+		indexes: []tableIndex{
+			{name: "posts_title_idx", columns: []string{"title"}},
+			{name: "posts_body_idx", columns: []string{"body"}},
+			{name: "posts_lower_title", complex: true, sql: `CREATE INDEX posts_lower_title ON posts (lower(title))`},
+		},
+		triggers: []string{`CREATE TRIGGER posts_touch AFTER UPDATE ON posts BEGIN SELECT 1; END`},
+	}
+}
+
+func TestGrammar_CompileRebuild(t *testing.T) {
+	g := NewGrammar()
+
+	t.Run("drop column", func(t *testing.T) {
+		table := schema.NewTable("posts")
+		table.IsAlter = true
+		table.DropColumn("body")
+
+		sqls, err := g.compileRebuild(postsDefinition(), table)
+		if err != nil {
+			t.Fatalf("compileRebuild() error = %v", err)
+		}
+
+		expected := []string{
+			"CREATE TABLE \"__temp__posts\" (\n" +
+				"  \"id\" INTEGER PRIMARY KEY AUTOINCREMENT,\n" +
+				"  \"user_id\" INTEGER NOT NULL,\n" +
+				"  \"title\" TEXT NOT NULL DEFAULT '',\n" +
+				"  \"slug\" TEXT,\n" +
+				"  UNIQUE (\"slug\"),\n" +
+				"  CONSTRAINT \"posts_user_id_fk\" FOREIGN KEY (\"user_id\") REFERENCES \"users\" (\"id\") ON DELETE CASCADE\n" +
+				")",
+			`INSERT INTO "__temp__posts" ("id", "user_id", "title", "slug") SELECT "id", "user_id", "title", "slug" FROM "posts"`,
+			`DROP TABLE "posts"`,
+			`ALTER TABLE "__temp__posts" RENAME TO "posts"`,
+			`CREATE INDEX "posts_title_idx" ON "posts" ("title")`,
+			`CREATE INDEX posts_lower_title ON posts (lower(title))`,
+			`CREATE TRIGGER posts_touch AFTER UPDATE ON posts BEGIN SELECT 1; END`,
+		}
+		if len(sqls) != len(expected) {
+			t.Fatalf("expected %d statements, got %d:\n%s", len(expected), len(sqls), strings.Join(sqls, "\n"))
+		}
+		for i := range expected {
+			if sqls[i] != expected[i] {
+				t.Errorf("statement %d:\nexpected: %s\ngot:      %s", i, expected[i], sqls[i])
+			}
+		}
+	})
+
+	t.Run("change and rename columns", func(t *testing.T) {
+		table := schema.NewTable("posts")
+		table.IsAlter = true
+		table.RenameColumn("body", "content")
+		table.ChangeString("title", 100).Nullable()
+
+		sqls, err := g.compileRebuild(postsDefinition(), table)
+		if err != nil {
+			t.Fatalf("compileRebuild() error = %v", err)
+		}
+
+		if !strings.Contains(sqls[0], `"title" TEXT,`) {
+			t.Errorf("expected changed title column, got:\n%s", sqls[0])
+		}
+		if !strings.Contains(sqls[0], `"content" TEXT`) {
+			t.Errorf("expected renamed content column, got:\n%s", sqls[0])
+		}
+		if !strings.Contains(sqls[1], `("id", "user_id", "title", "content", "slug") SELECT "id", "user_id", "title", "body", "slug"`) {
+			t.Errorf("expected data copied from the old column names, got:\n%s", sqls[1])
+		}
+		if !contains(sqls, `CREATE INDEX "posts_body_idx" ON "posts" ("content")`) {
+			t.Error("expected index recreated on the renamed column")
+		}
+	})
+
+	t.Run("drop and add foreign keys and indexes", func(t *testing.T) {
+		table := schema.NewTable("posts")
+		table.IsAlter = true
+		table.DropForeign("posts_user_id_fk")
+		table.Foreign("user_id").References("authors", "id").OnDeleteSetNull()
+		table.DropIndex("posts_title_idx")
+		table.Index("slug")
+
+		sqls, err := g.compileRebuild(postsDefinition(), table)
+		if err != nil {
+			t.Fatalf("compileRebuild() error = %v", err)
+		}
+
+		if strings.Contains(sqls[0], `REFERENCES "users"`) {
+			t.Error("expected foreign key to users to be dropped")
+		}
+		if !strings.Contains(sqls[0], `REFERENCES "authors" ("id") ON DELETE SET NULL`) {
+			t.Errorf("expected foreign key to authors, got:\n%s", sqls[0])
+		}
+		if contains(sqls, `CREATE INDEX "posts_title_idx" ON "posts" ("title")`) {
+			t.Error("expected posts_title_idx to be dropped")
+		}
+		if !contains(sqls, `CREATE INDEX "posts_slug_idx" ON "posts" ("slug")`) {
+			t.Error("expected the new index to be created")
+		}
+	})
+
+	t.Run("composite primary key", func(t *testing.T) {
+		def := &tableDefinition{
+			name: "post_tag",
+			columns: []tableColumn{
+				{name: "post_id", typ: "INTEGER", notNull: true, pk: 1},
+				{name: "tag_id", typ: "INTEGER", notNull: true, pk: 2},
+				{name: "note", typ: "TEXT"},
+			},
+		}
+		table := schema.NewTable("post_tag")
+		table.IsAlter = true
+		table.DropColumn("note")
+
+		sqls, err := g.compileRebuild(def, table)
+		if err != nil {
+			t.Fatalf("compileRebuild() error = %v", err)
+		}
+		if !strings.Contains(sqls[0], `PRIMARY KEY ("post_id", "tag_id")`) {
+			t.Errorf("expected composite primary key, got:\n%s", sqls[0])
+		}
+	})
+
+	t.Run("保留未命名 CHECK 约束与排序规则", func(t *testing.T) {
+		def := postsDefinition()
+		def.columns[2].collation = "NOCASE"
+		def.columns[4].checks = []tableCheck{{expression: "length(slug) > 0"}}
+		def.checks = []tableCheck{
+			{expression: "user_id > 0"},
+			{name: "posts_title_check", expression: "title <> ''"},
+		}
+		table := schema.NewTable("posts")
+		table.IsAlter = true
+		table.RenameColumn("slug", "handle")
+		table.DropColumn("user_id")
+
+		sqls, err := g.compileRebuild(def, table)
+		if err != nil {
+			t.Fatalf("compileRebuild() error = %v", err)
+		}
+		for _, want := range []string{
+			`"title" TEXT NOT NULL DEFAULT '' COLLATE NOCASE,`,
+			`"handle" TEXT CHECK (length("handle") > 0)`,
+			`CONSTRAINT "posts_title_check" CHECK (title <> '')`,
+		} {
+			if !strings.Contains(sqls[0], want) {
+				t.Errorf("expected %s, got:\n%s", want, sqls[0])
+			}
+		}
+		if strings.Contains(sqls[0], "user_id > 0") {
+			t.Errorf("expected the check on the dropped column to be dropped, got:\n%s", sqls[0])
+		}
+	})
+
+	t.Run("CHECK 约束同时引用删除与保留的列时报错", func(t *testing.T) {
+		def := postsDefinition()
+		def.checks = []tableCheck{{expression: "body IS NOT NULL OR title <> ''"}}
+		table := schema.NewTable("posts")
+		table.IsAlter = true
+		table.DropColumn("body")
+
+		_, err := g.compileRebuild(def, table)
+		if err == nil || !strings.Contains(err.Error(), "refers to dropped column body") {
+			t.Errorf("expected an error about the dropped column, got %v", err)
+		}
+	})
+
+	t.Run("触发器与表达式索引引用删除或重命名的列时报错", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			alter  func(*schema.Table)
+			object string
+		}{
+			{"drop column in index", func(t *schema.Table) { t.DropColumn("title") }, "index posts_lower_title refers to dropped column title"},
+			{"rename column in index", func(t *schema.Table) { t.RenameColumn("title", "heading"); t.DropColumn("body") }, "index posts_lower_title refers to renamed column title"},
+			{"drop column in trigger", func(t *schema.Table) { t.DropColumn("slug") }, "trigger posts_touch refers to dropped column slug"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				def := postsDefinition()
+				def.triggers = []string{`CREATE TRIGGER posts_touch AFTER UPDATE ON posts BEGIN UPDATE posts SET "slug" = 'x'; END`}
+				table := schema.NewTable("posts")
+				table.IsAlter = true
+				tt.alter(table)
+
+				_, err := g.compileRebuild(def, table)
+				if err == nil || !strings.Contains(err.Error(), tt.object) {
+					t.Errorf("expected error %q, got %v", tt.object, err)
+				}
+			})
+		}
+	})
+}
+
+func TestReadConstraints(t *testing.T) {
+	def := &tableDefinition{columns: []tableColumn{{name: "id"}, {name: "name"}, {name: "age"}}}
+	readConstraints(def, `CREATE TABLE "people" (
+  "id" INTEGER PRIMARY KEY,
+  "name" TEXT COLLATE NOCASE CONSTRAINT name_check CHECK (name <> 'a,b'),
+  age INTEGER CHECK (age >= 0) DEFAULT (0),
+  CHECK (age < 200),
+  CONSTRAINT "people_name_check" CHECK (length(name) > 1),
+  UNIQUE (name)
+)`)
+
+	if def.columns[1].collation != "NOCASE" {
+		t.Errorf("expected NOCASE collation, got %q", def.columns[1].collation)
+	}
+	if got := def.columns[1].checks; len(got) != 1 || got[0] != (tableCheck{name: "name_check", expression: "name <> 'a,b'"}) {
+		t.Errorf("unexpected name checks: %+v", got)
+	}
+	if got := def.columns[2].checks; len(got) != 1 || got[0] != (tableCheck{expression: "age >= 0"}) {
+		t.Errorf("unexpected age checks: %+v", got)
+	}
+	expected := []tableCheck{{expression: "age < 200"}, {name: "people_name_check", expression: "length(name) > 1"}}
+	if len(def.checks) != len(expected) {
+		t.Fatalf("expected %d table checks, got %+v", len(expected), def.checks)
+	}
+	for i := range expected {
+		if def.checks[i] != expected[i] {
+			t.Errorf("check %d: expected %+v, got %+v", i, expected[i], def.checks[i])
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
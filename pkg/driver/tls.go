@@ -0,0 +1,137 @@
+package driver
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+// TLS modes, named after the PostgreSQL sslmode values
+const (
+	// TLSDisable connects without TLS
+	TLSDisable = "disable"
+
+	// TLSRequire encrypts the connection without verifying the server certificate
+	TLSRequire = "require"
+
+	// TLSVerifyCA verifies that the server certificate is signed by a trusted CA
+	TLSVerifyCA = "verify-ca"
+
+	// TLSVerifyFull verifies the server certificate and that it matches the host
+	TLSVerifyFull = "verify-full"
+)
+
+// TLSConfig holds the TLS settings of a connection
+type TLSConfig struct {
+	Mode       string // one of the TLS modes, empty keeps the default of the driver
+	CAFile     string // PEM file of the CA that signed the server certificate
+	CertFile   string // PEM file of the client certificate
+	KeyFile    string // PEM file of the client key
+	ServerName string // host name to verify instead of the connection host
+}
+
+// IsZero reports whether no TLS setting is configured
+func (c TLSConfig) IsZero() bool {
+	return c == TLSConfig{}
+}
+
+// EffectiveMode returns the mode, verify-full when only files or a server
+// name are configured
+func (c TLSConfig) EffectiveMode() string {
+	if c.Mode == "" && !c.IsZero() {
+		return TLSVerifyFull
+	}
+	return c.Mode
+}
+
+// Validate checks the mode and that the client certificate and key are
+// configured together
+func (c TLSConfig) Validate() error {
+	switch c.EffectiveMode() {
+	case "", TLSDisable, TLSRequire, TLSVerifyCA, TLSVerifyFull:
+	default:
+		return fmt.Errorf("unsupported TLS mode %q (supported: disable, require, verify-ca, verify-full)", c.Mode)
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("TLS cert_file and key_file must be configured together")
+	}
+	return nil
+}
+
+// Key returns a name identifying the settings, used to register the TLS
+// configuration with database/sql drivers
+func (c TLSConfig) Key() string {
+	sum := sha256.Sum256([]byte(c.EffectiveMode() + "\x00" + c.CAFile + "\x00" + c.CertFile + "\x00" + c.KeyFile + "\x00" + c.ServerName))
+	return "migro-" + hex.EncodeToString(sum[:6])
+}
+
+// ClientConfig returns the crypto/tls configuration of the settings. It
+// returns nil when TLS is not configured or disabled.
+func (c TLSConfig) ClientConfig() (*tls.Config, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	mode := c.EffectiveMode()
+	if mode == "" || mode == TLSDisable {
+		return nil, nil
+	}
+
+	config := &tls.Config{ServerName: c.ServerName}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS CA file: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in TLS CA file %s", c.CAFile)
+		}
+	}
+
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	switch mode {
+	case TLSRequire:
+		config.InsecureSkipVerify = true
+	case TLSVerifyCA:
+		// crypto/tls always checks the host name, so the chain is verified here
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = verifyChain(config.RootCAs)
+	}
+	return config, nil
+}
+
+// verifyChain returns a callback verifying the server certificate chain
+// against roots, without checking the host name
+func verifyChain(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("server sent no certificate")
+		}
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("failed to parse server certificate: %w", err)
+			}
+			certs[i] = cert
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+		return err
+	}
+}
@@ -0,0 +1,158 @@
+package driver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 测试目标：验证 TLS 配置的校验、模式推导以及 crypto/tls 配置的生成
+
+// newTestCertificate 生成一个证书，parent 为 nil 时生成自签名的 CA
+func newTestCertificate(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{name},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return cert, key
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestTLSConfig_Validate(t *testing.T) {
+	tests := []struct {
+		config  TLSConfig
+		mode    string
+		wantErr bool
+	}{
+		{TLSConfig{}, "", false},
+		{TLSConfig{Mode: "disable"}, "disable", false},
+		{TLSConfig{CAFile: "ca.pem"}, "verify-full", false},
+		{TLSConfig{Mode: "verify-ca", CAFile: "ca.pem"}, "verify-ca", false},
+		{TLSConfig{Mode: "prefer"}, "prefer", true},
+		{TLSConfig{Mode: "require", CertFile: "client.pem"}, "require", true},
+	}
+	for _, tt := range tests {
+		if got := tt.config.EffectiveMode(); got != tt.mode {
+			t.Errorf("%+v: expected mode %q, got %q", tt.config, tt.mode, got)
+		}
+		if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v: expected error %v, got %v", tt.config, tt.wantErr, err)
+		}
+	}
+
+	if (TLSConfig{Mode: "require"}).Key() == (TLSConfig{Mode: "verify-full"}).Key() {
+		t.Error("expected different keys for different settings")
+	}
+}
+
+func TestTLSConfig_ClientConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newTestCertificate(t, "test-ca", nil, nil)
+	caFile := filepath.Join(dir, "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", ca.Raw)
+
+	client, clientKey := newTestCertificate(t, "client", ca, caKey)
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", client.Raw)
+	keyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+
+	t.Run("未配置或关闭时返回 nil", func(t *testing.T) {
+		for _, config := range []TLSConfig{{}, {Mode: TLSDisable}} {
+			tlsConfig, err := config.ClientConfig()
+			if err != nil || tlsConfig != nil {
+				t.Errorf("%+v: expected no TLS config, got %v, %v", config, tlsConfig, err)
+			}
+		}
+	})
+
+	t.Run("verify-full 使用 CA 和客户端证书", func(t *testing.T) {
+		tlsConfig, err := TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "db.internal"}.ClientConfig()
+		if err != nil {
+			t.Fatalf("ClientConfig failed: %v", err)
+		}
+		if tlsConfig.InsecureSkipVerify || tlsConfig.RootCAs == nil || tlsConfig.ServerName != "db.internal" {
+			t.Errorf("unexpected TLS config %+v", tlsConfig)
+		}
+		if len(tlsConfig.Certificates) != 1 {
+			t.Errorf("expected the client certificate, got %d certificates", len(tlsConfig.Certificates))
+		}
+	})
+
+	t.Run("verify-ca 只校验证书链", func(t *testing.T) {
+		tlsConfig, err := TLSConfig{Mode: TLSVerifyCA, CAFile: caFile}.ClientConfig()
+		if err != nil {
+			t.Fatalf("ClientConfig failed: %v", err)
+		}
+		if !tlsConfig.InsecureSkipVerify || tlsConfig.VerifyPeerCertificate == nil {
+			t.Fatal("expected the chain to be verified by VerifyPeerCertificate")
+		}
+
+		server, _ := newTestCertificate(t, "other-host", ca, caKey)
+		if err := tlsConfig.VerifyPeerCertificate([][]byte{server.Raw}, nil); err != nil {
+			t.Errorf("expected a certificate signed by the CA to be accepted, got %v", err)
+		}
+		otherCA, otherKey := newTestCertificate(t, "other-ca", nil, nil)
+		untrusted, _ := newTestCertificate(t, "db.internal", otherCA, otherKey)
+		if err := tlsConfig.VerifyPeerCertificate([][]byte{untrusted.Raw}, nil); err == nil {
+			t.Error("expected a certificate of another CA to be rejected")
+		}
+	})
+
+	t.Run("require 不校验证书", func(t *testing.T) {
+		tlsConfig, err := TLSConfig{Mode: TLSRequire}.ClientConfig()
+		if err != nil {
+			t.Fatalf("ClientConfig failed: %v", err)
+		}
+		if !tlsConfig.InsecureSkipVerify || tlsConfig.VerifyPeerCertificate != nil {
+			t.Errorf("unexpected TLS config %+v", tlsConfig)
+		}
+	})
+
+	t.Run("CA 文件不存在时报错", func(t *testing.T) {
+		if _, err := (TLSConfig{CAFile: filepath.Join(dir, "missing.pem")}).ClientConfig(); err == nil {
+			t.Error("expected an error for a missing CA file")
+		}
+	})
+}
@@ -1,7 +1,9 @@
 package migration
 
 import (
-	"github.com/flyits/migro/internal/migrator"
+	"context"
+
+	"github.com/flyits/migro/pkg/migrator"
 )
 
 // Migration defines the interface for database migrations
@@ -10,8 +12,72 @@ type Migration interface {
 	Name() string
 
 	// Up runs the migration
-	Up(e *migrator.Executor) error
+	Up(ctx context.Context, e *migrator.Executor) error
 
 	// Down reverses the migration
-	Down(e *migrator.Executor) error
+	Down(ctx context.Context, e *migrator.Executor) error
+}
+
+// NoTransaction is implemented by migrations that must run outside a
+// transaction, e.g. to create an index with CREATE INDEX CONCURRENTLY on
+// PostgreSQL
+type NoTransaction interface {
+	// DisableTransaction returns true to run the migration outside a transaction
+	DisableTransaction() bool
+}
+
+// Adapt converts a Migration implementation to a migrator.Migration
+func Adapt(m Migration) migrator.Migration {
+	mig := migrator.Migration{
+		Name: m.Name(),
+		Up:   m.Up,
+		Down: m.Down,
+	}
+	if nt, ok := m.(NoTransaction); ok {
+		mig.DisableTransaction = nt.DisableTransaction()
+	}
+	return mig
+}
+
+// ChangeMigration defines a reversible migration. Change applies the
+// migration and its inverse is derived when rolling back, see
+// migrator.Reverse. An implementation that also has a Down method is rolled
+// back with it instead, which is required when Change uses irreversible
+// operations such as DropColumn or Raw.
+type ChangeMigration interface {
+	// Name returns the unique name of the migration
+	Name() string
+
+	// Change runs the migration
+	Change(ctx context.Context, e *migrator.Executor) error
+}
+
+// Reversible converts a ChangeMigration to a Migration.
+// Generated change migrations register themselves with
+// migration.Register(migration.Reversible(&X{})).
+func Reversible(m ChangeMigration) Migration {
+	return reversible{m}
+}
+
+// reversible runs Change as Up and derives Down from it
+type reversible struct {
+	ChangeMigration
+}
+
+func (r reversible) Up(ctx context.Context, e *migrator.Executor) error {
+	return r.Change(ctx, e)
+}
+
+func (r reversible) Down(ctx context.Context, e *migrator.Executor) error {
+	if down, ok := r.ChangeMigration.(interface {
+		Down(ctx context.Context, e *migrator.Executor) error
+	}); ok {
+		return down.Down(ctx, e)
+	}
+	return migrator.Reverse(r.Change)(ctx, e)
+}
+
+func (r reversible) DisableTransaction() bool {
+	nt, ok := r.ChangeMigration.(NoTransaction)
+	return ok && nt.DisableTransaction()
 }
@@ -0,0 +1,57 @@
+package migration
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/flyits/migro/pkg/migrator"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Migration)
+)
+
+// Register adds a migration to the global registry.
+// Generated migration files call it from their init() function.
+func Register(m Migration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if m == nil {
+		panic("migration: Register migration is nil")
+	}
+	name := m.Name()
+	if _, dup := registry[name]; dup {
+		panic("migration: Register called twice for migration " + name)
+	}
+	registry[name] = m
+}
+
+// Registered returns all registered migrations sorted by name
+func Registered() []Migration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	list := make([]Migration, 0, len(registry))
+	for _, m := range registry {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name() < list[j].Name()
+	})
+	return list
+}
+
+// Migrations returns all registered migrations converted to migrator.Migration
+func Migrations() []migrator.Migration {
+	registered := Registered()
+	list := make([]migrator.Migration, 0, len(registered))
+	for _, m := range registered {
+		list = append(list, Adapt(m))
+	}
+	return list
+}
+
+// Load registers every migration from the global registry with the given migrator
+func Load(m *migrator.Migrator) {
+	m.RegisterAll(Migrations())
+}
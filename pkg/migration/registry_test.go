@@ -0,0 +1,231 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/flyits/migro/pkg/migrator"
+)
+
+// 测试目标需求: 全局迁移注册表功能正确性
+// 覆盖: Register, Registered, Migrations, Load, Adapt, Reversible, NoTransaction
+
+// testMigration 用于测试的迁移实现
+type testMigration struct {
+	name     string
+	upCalled bool
+}
+
+func (m *testMigration) Name() string { return m.name }
+func (m *testMigration) Up(ctx context.Context, e *migrator.Executor) error {
+	m.upCalled = true
+	return nil
+}
+func (m *testMigration) Down(ctx context.Context, e *migrator.Executor) error { return nil }
+
+// resetRegistry 重置注册表（用于测试隔离）
+func resetRegistry() {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = make(map[string]Migration)
+}
+
+func TestRegister(t *testing.T) {
+	t.Run("register valid migration", func(t *testing.T) {
+		resetRegistry()
+
+		Register(&testMigration{name: "20260101000000_create_users"})
+
+		list := Registered()
+		if len(list) != 1 {
+			t.Fatalf("expected 1 migration, got %d", len(list))
+		}
+		if list[0].Name() != "20260101000000_create_users" {
+			t.Errorf("unexpected migration name %q", list[0].Name())
+		}
+	})
+
+	t.Run("register nil migration panics", func(t *testing.T) {
+		resetRegistry()
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected panic for nil migration")
+			}
+		}()
+
+		Register(nil)
+	})
+
+	t.Run("register duplicate migration panics", func(t *testing.T) {
+		resetRegistry()
+
+		Register(&testMigration{name: "001_dup"})
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected panic for duplicate registration")
+			}
+		}()
+
+		Register(&testMigration{name: "001_dup"})
+	})
+}
+
+func TestRegistered(t *testing.T) {
+	t.Run("returns migrations sorted by name", func(t *testing.T) {
+		resetRegistry()
+
+		Register(&testMigration{name: "003_c"})
+		Register(&testMigration{name: "001_a"})
+		Register(&testMigration{name: "002_b"})
+
+		list := Registered()
+		expected := []string{"001_a", "002_b", "003_c"}
+		if len(list) != len(expected) {
+			t.Fatalf("expected %d migrations, got %d", len(expected), len(list))
+		}
+		for i, name := range expected {
+			if list[i].Name() != name {
+				t.Errorf("position %d: expected %q, got %q", i, name, list[i].Name())
+			}
+		}
+	})
+}
+
+func TestMigrations(t *testing.T) {
+	t.Run("adapts registered migrations", func(t *testing.T) {
+		resetRegistry()
+
+		mig := &testMigration{name: "001_create_users"}
+		Register(mig)
+
+		list := Migrations()
+		if len(list) != 1 {
+			t.Fatalf("expected 1 migration, got %d", len(list))
+		}
+		if list[0].Name != "001_create_users" {
+			t.Errorf("unexpected migration name %q", list[0].Name)
+		}
+
+		if err := list[0].Up(context.Background(), nil); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !mig.upCalled {
+			t.Error("expected Up of the registered migration to be called")
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	resetRegistry()
+
+	Register(&testMigration{name: "001_create_users"})
+	Register(&testMigration{name: "002_create_posts"})
+
+	m := migrator.NewMigrator(nil, "./migrations", "migrations")
+	Load(m)
+
+	if got := len(m.Migrations()); got != 2 {
+		t.Errorf("expected 2 migrations loaded, got %d", got)
+	}
+}
+
+func TestAdapt(t *testing.T) {
+	mig := &testMigration{name: "001_create_users"}
+
+	adapted := Adapt(mig)
+	if adapted.Name != "001_create_users" {
+		t.Errorf("unexpected migration name %q", adapted.Name)
+	}
+	if adapted.Up == nil || adapted.Down == nil {
+		t.Fatal("expected Up and Down to be set")
+	}
+
+	if err := adapted.Up(context.Background(), nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !mig.upCalled {
+		t.Error("expected Up of the migration to be called")
+	}
+}
+
+// noTxMigration 关闭事务的测试迁移
+type noTxMigration struct {
+	testMigration
+}
+
+func (m *noTxMigration) DisableTransaction() bool { return true }
+
+// noTxChangeMigration 关闭事务的可逆迁移
+type noTxChangeMigration struct {
+	changeMigration
+}
+
+func (m *noTxChangeMigration) DisableTransaction() bool { return true }
+
+func TestAdapt_DisableTransaction(t *testing.T) {
+	if Adapt(&testMigration{name: "001_create_users"}).DisableTransaction {
+		t.Error("expected transactions to be enabled by default")
+	}
+	if !Adapt(&noTxMigration{testMigration{name: "002_add_index"}}).DisableTransaction {
+		t.Error("expected DisableTransaction to be adapted")
+	}
+	if !Adapt(Reversible(&noTxChangeMigration{})).DisableTransaction {
+		t.Error("expected DisableTransaction of a reversible migration to be adapted")
+	}
+}
+
+// changeMigration 用于测试的可逆迁移实现
+type changeMigration struct {
+	changeCalled bool
+}
+
+func (m *changeMigration) Name() string { return "001_backfill" }
+func (m *changeMigration) Change(ctx context.Context, e *migrator.Executor) error {
+	m.changeCalled = true
+	return e.Raw(ctx, "UPDATE users SET active = 1")
+}
+
+// changeMigrationWithDown 带显式 Down 的可逆迁移
+type changeMigrationWithDown struct {
+	changeMigration
+	downCalled bool
+}
+
+func (m *changeMigrationWithDown) Down(ctx context.Context, e *migrator.Executor) error {
+	m.downCalled = true
+	return nil
+}
+
+func TestReversible(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Up 执行 Change", func(t *testing.T) {
+		mig := &changeMigration{}
+		if err := Reversible(mig).Up(ctx, migrator.NewExecutor(nil, true)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !mig.changeCalled {
+			t.Error("expected Change to be called")
+		}
+	})
+
+	t.Run("推导的 Down 拒绝不可逆操作", func(t *testing.T) {
+		err := Reversible(&changeMigration{}).Down(ctx, migrator.NewExecutor(nil, true))
+		if !errors.Is(err, migrator.ErrIrreversible) {
+			t.Errorf("expected ErrIrreversible, got %v", err)
+		}
+	})
+
+	t.Run("优先使用显式 Down", func(t *testing.T) {
+		mig := &changeMigrationWithDown{}
+		if err := Reversible(mig).Down(ctx, migrator.NewExecutor(nil, true)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !mig.downCalled || mig.changeCalled {
+			t.Error("expected the explicit Down to be used")
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package migration
+
+import (
+	"github.com/flyits/migro/pkg/schema"
+)
+
+var definedTables []*schema.Table
+
+// DefineTable adds a table to the desired schema that `migro make:diff`
+// compares with the database. Schema packages call it from their init()
+// function. Tables are diffed in definition order, so define referenced
+// tables before the tables that reference them.
+func DefineTable(name string, fn func(*schema.Table)) {
+	// Build the table before locking, fn may use the registry
+	table := schema.NewTable(name)
+	fn(table)
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, defined := range definedTables {
+		if defined.Name == name {
+			panic("migration: DefineTable called twice for table " + name)
+		}
+	}
+	definedTables = append(definedTables, table)
+}
+
+// DefinedTables returns the tables of the desired schema in definition order
+func DefinedTables() []*schema.Table {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	list := make([]*schema.Table, len(definedTables))
+	copy(list, definedTables)
+	return list
+}
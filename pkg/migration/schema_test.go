@@ -0,0 +1,82 @@
+package migration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// 测试目标：验证期望表结构的注册顺序与重复定义检测
+
+// resetDefinedTables 重置期望表结构（用于测试隔离）
+func resetDefinedTables() {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	definedTables = nil
+}
+
+func TestDefineTable(t *testing.T) {
+	t.Run("keeps definition order", func(t *testing.T) {
+		resetDefinedTables()
+
+		DefineTable("users", func(t *schema.Table) {
+			t.ID()
+			t.String("email", 255)
+		})
+		DefineTable("posts", func(t *schema.Table) {
+			t.ID()
+		})
+
+		tables := DefinedTables()
+		if len(tables) != 2 || tables[0].Name != "users" || tables[1].Name != "posts" {
+			t.Fatalf("expected [users posts], got %d tables", len(tables))
+		}
+		if len(tables[0].Columns) != 2 {
+			t.Errorf("expected 2 columns on users, got %d", len(tables[0].Columns))
+		}
+	})
+
+	t.Run("duplicate table panics", func(t *testing.T) {
+		resetDefinedTables()
+
+		DefineTable("users", func(t *schema.Table) {})
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected panic for duplicate table definition")
+			}
+		}()
+
+		DefineTable("users", func(t *schema.Table) {})
+	})
+
+	t.Run("callback may use the registry", func(t *testing.T) {
+		resetDefinedTables()
+
+		DefineTable("users", func(t *schema.Table) {
+			t.ID()
+		})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			DefineTable("posts", func(t *schema.Table) {
+				t.ID()
+				// 回调中读取注册表不能死锁
+				for _, defined := range DefinedTables() {
+					t.Foreign(defined.Name+"_id").References(defined.Name, "id")
+				}
+			})
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("DefineTable deadlocked when the callback used the registry")
+		}
+		tables := DefinedTables()
+		if len(tables) != 2 || len(tables[1].ForeignKeys) != 1 {
+			t.Errorf("expected posts to reference users, got %d tables", len(tables))
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// ErrIrreversible is returned by the Down derived from a change function that
+// uses an operation which cannot be reversed automatically
+var ErrIrreversible = errors.New("irreversible operation")
+
+// changeRecorder collects the inverse of the operations of a change function
+type changeRecorder struct {
+	inverse      []func(context.Context, *Executor) error
+	irreversible []string
+}
+
+func (r *changeRecorder) reverse(fn func(context.Context, *Executor) error) {
+	r.inverse = append(r.inverse, fn)
+}
+
+func (r *changeRecorder) refuse(format string, args ...interface{}) {
+	r.irreversible = append(r.irreversible, fmt.Sprintf(format, args...))
+}
+
+// Reverse returns a Down function derived from change, the Up of a
+// reversible migration. Down runs change on an executor that records the
+// operations instead of executing them, then applies their inverses in
+// reverse order: created tables and schemas are dropped, renames are undone
+// and added columns, indexes, foreign keys and check constraints are dropped.
+//
+// Dropping schemas, tables, columns, indexes, foreign keys or check
+// constraints, changing columns and running raw SQL cannot be reversed; Down
+// then fails with ErrIrreversible before executing anything. Reads such as
+// HasColumn run against the database while recording.
+func Reverse(change func(context.Context, *Executor) error) func(context.Context, *Executor) error {
+	return func(ctx context.Context, e *Executor) error {
+		recording := &Executor{
+			driver:   e.driver,
+			tx:       e.tx,
+			recorder: &changeRecorder{},
+		}
+		if err := change(ctx, recording); err != nil {
+			return err
+		}
+
+		rec := recording.recorder
+		if len(rec.irreversible) > 0 {
+			return fmt.Errorf("%w: %s; implement Down explicitly", ErrIrreversible, strings.Join(rec.irreversible, ", "))
+		}
+		for i := len(rec.inverse) - 1; i >= 0; i-- {
+			if err := rec.inverse[i](ctx, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// recordAlter records the inverse of an ALTER TABLE operation
+func (r *changeRecorder) recordAlter(table *schema.Table) {
+	for _, name := range table.DropColumns {
+		r.refuse("DropColumn(%s.%s)", table.Name, name)
+	}
+	for _, name := range table.DropIndexes {
+		r.refuse("DropIndex(%s.%s)", table.Name, name)
+	}
+	for _, name := range table.DropForeignKeys {
+		r.refuse("DropForeign(%s.%s)", table.Name, name)
+	}
+	for _, name := range table.DropChecks {
+		r.refuse("DropCheck(%s.%s)", table.Name, name)
+	}
+
+	var dropColumns, dropIndexes, dropForeignKeys, dropChecks []string
+	for _, col := range table.Columns {
+		if col.Change {
+			r.refuse("changing column %s.%s", table.Name, col.Name)
+			continue
+		}
+		dropColumns = append(dropColumns, col.Name)
+	}
+	for _, idx := range table.Indexes {
+		if idx.Type == schema.IndexTypePrimary {
+			r.refuse("Primary(%s)", table.Name)
+			continue
+		}
+		name := idx.Name
+		if name == "" {
+			name = defaultIndexName(table.Name, idx.Columns, idx.Type)
+		}
+		dropIndexes = append(dropIndexes, name)
+	}
+	for _, fk := range table.ForeignKeys {
+		name := fk.Name
+		if name == "" {
+			name = defaultForeignKeyName(table.Name, fk.Columns)
+		}
+		dropForeignKeys = append(dropForeignKeys, name)
+	}
+	for _, check := range table.Checks {
+		dropChecks = append(dropChecks, check.Name)
+	}
+
+	renames := make(map[string]string, len(table.RenameColumns))
+	for from, to := range table.RenameColumns {
+		renames[to] = from
+	}
+
+	if len(dropColumns) == 0 && len(dropIndexes) == 0 && len(dropForeignKeys) == 0 && len(dropChecks) == 0 && len(renames) == 0 {
+		return
+	}
+	r.reverse(func(ctx context.Context, e *Executor) error {
+		return e.AlterTable(ctx, table.Name, func(t *schema.Table) {
+			t.DropForeignKeys = dropForeignKeys
+			t.DropChecks = dropChecks
+			t.DropIndexes = dropIndexes
+			t.DropColumns = dropColumns
+			t.RenameColumns = renames
+		})
+	})
+}
+
+// defaultIndexName returns the name the grammars generate for an unnamed index
+func defaultIndexName(table string, columns []string, typ schema.IndexType) string {
+	_, table = schema.SplitTableName(table)
+	suffix := "idx"
+	if typ == schema.IndexTypeUnique {
+		suffix = "unique"
+	}
+	return fmt.Sprintf("%s_%s_%s", table, strings.Join(columns, "_"), suffix)
+}
+
+// defaultForeignKeyName returns the name the grammars generate for an unnamed foreign key
+func defaultForeignKeyName(table string, columns []string) string {
+	_, table = schema.SplitTableName(table)
+	return fmt.Sprintf("%s_%s_fk", table, strings.Join(columns, "_"))
+}
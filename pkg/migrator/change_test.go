@@ -0,0 +1,132 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// 测试目标：验证 Reverse 根据 Change 推导出逆操作，并拒绝不可逆操作
+
+// alterCapturingGrammar 记录 CompileAlter 收到的表定义
+type alterCapturingGrammar struct {
+	mockGrammar
+	altered []*schema.Table
+}
+
+func (g *alterCapturingGrammar) CompileAlter(table *schema.Table) []string {
+	g.altered = append(g.altered, table)
+	return []string{"ALTER TABLE " + table.Name}
+}
+
+func TestReverse(t *testing.T) {
+	ctx := context.Background()
+
+	newExecutor := func() (*alterCapturingGrammar, *Executor) {
+		grammar := &alterCapturingGrammar{}
+		drv := newMockDriver("postgres")
+		drv.grammar = grammar
+		return grammar, NewExecutor(drv, true)
+	}
+
+	t.Run("逆操作按相反顺序执行", func(t *testing.T) {
+		grammar, e := newExecutor()
+		change := func(ctx context.Context, e *Executor) error {
+			if err := e.CreateTable(ctx, "users", func(t *schema.Table) { t.ID() }); err != nil {
+				return err
+			}
+			if err := e.RenameTable(ctx, "users", "members"); err != nil {
+				return err
+			}
+			return e.AlterTable(ctx, "members", func(t *schema.Table) {
+				t.String("email", 255)
+				t.RenameColumn("name", "full_name")
+				t.Index("email")
+				t.Unique("full_name").Named("members_name_unique")
+				t.Foreign("team_id").References("teams", "id")
+				t.Check("members_email_not_empty", "email <> ''")
+			})
+		}
+
+		if err := Reverse(change)(ctx, e); err != nil {
+			t.Fatalf("Reverse failed: %v", err)
+		}
+
+		expected := []string{"ALTER TABLE members", "RENAME TABLE members TO users", "DROP TABLE IF EXISTS users"}
+		if got := e.GetSQL(); strings.Join(got, "|") != strings.Join(expected, "|") {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+
+		if len(grammar.altered) != 1 {
+			t.Fatalf("expected 1 alter table, got %d", len(grammar.altered))
+		}
+		inverse := grammar.altered[0]
+		if len(inverse.DropColumns) != 1 || inverse.DropColumns[0] != "email" {
+			t.Errorf("expected email to be dropped, got %v", inverse.DropColumns)
+		}
+		if inverse.RenameColumns["full_name"] != "name" {
+			t.Errorf("expected full_name to be renamed back, got %v", inverse.RenameColumns)
+		}
+		if strings.Join(inverse.DropIndexes, ",") != "members_email_idx,members_name_unique" {
+			t.Errorf("unexpected dropped indexes: %v", inverse.DropIndexes)
+		}
+		if len(inverse.DropForeignKeys) != 1 || inverse.DropForeignKeys[0] != "members_team_id_fk" {
+			t.Errorf("unexpected dropped foreign keys: %v", inverse.DropForeignKeys)
+		}
+		if len(inverse.DropChecks) != 1 || inverse.DropChecks[0] != "members_email_not_empty" {
+			t.Errorf("unexpected dropped checks: %v", inverse.DropChecks)
+		}
+	})
+
+	t.Run("不可逆操作报错且不执行任何语句", func(t *testing.T) {
+		_, e := newExecutor()
+		change := func(ctx context.Context, e *Executor) error {
+			if err := e.CreateTable(ctx, "posts", func(t *schema.Table) { t.ID() }); err != nil {
+				return err
+			}
+			if err := e.AlterTable(ctx, "users", func(t *schema.Table) {
+				t.DropColumn("legacy")
+				t.ChangeString("name", 200)
+				t.DropCheck("users_name_not_empty")
+			}); err != nil {
+				return err
+			}
+			return e.Raw(ctx, "UPDATE users SET name = ''")
+		}
+
+		err := Reverse(change)(ctx, e)
+		if !errors.Is(err, ErrIrreversible) {
+			t.Fatalf("expected ErrIrreversible, got %v", err)
+		}
+		for _, want := range []string{"DropColumn(users.legacy)", "changing column users.name", "DropCheck(users.users_name_not_empty)", "Raw"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("expected error to mention %q, got %v", want, err)
+			}
+		}
+		if len(e.GetSQL()) != 0 {
+			t.Errorf("expected nothing to be executed, got %v", e.GetSQL())
+		}
+	})
+
+	t.Run("删除表不可逆", func(t *testing.T) {
+		_, e := newExecutor()
+		err := Reverse(func(ctx context.Context, e *Executor) error {
+			return e.DropTableIfExists(ctx, "users")
+		})(ctx, e)
+		if !errors.Is(err, ErrIrreversible) {
+			t.Errorf("expected ErrIrreversible, got %v", err)
+		}
+	})
+
+	t.Run("Change 的错误原样返回", func(t *testing.T) {
+		_, e := newExecutor()
+		changeErr := errors.New("boom")
+		err := Reverse(func(ctx context.Context, e *Executor) error { return changeErr })(ctx, e)
+		if !errors.Is(err, changeErr) {
+			t.Errorf("expected the change error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package migrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/flyits/migro/pkg/driver"
+)
+
+// ErrChecksumMismatch is returned by Up when executed migrations have been
+// modified since they ran
+var ErrChecksumMismatch = errors.New("executed migrations have been modified")
+
+// ChecksumMismatch describes an executed migration whose content changed
+type ChecksumMismatch struct {
+	Name     string
+	Recorded string
+	Current  string
+}
+
+// Checksum returns the checksum of a migration. SQL file migrations use the
+// contents of their up file, Go migrations the SQL compiled by a dry run of Up.
+// The dry run does not read the schema: HasTable, HasColumn, HasIndex and
+// HasForeignKey report false and alterations that depend on the current table
+// are hashed as requested, so the checksum stays the same once the migration ran.
+func (m *Migrator) Checksum(ctx context.Context, migration Migration) (string, error) {
+	if migration.Checksum != "" {
+		return migration.Checksum, nil
+	}
+
+	executor := &Executor{driver: m.driver, dryRun: true, checksum: true}
+	if err := migration.Up(ctx, executor); err != nil {
+		return "", fmt.Errorf("failed to compute checksum of %s: %w", migration.Name, err)
+	}
+	return checksumOf(strings.Join(executor.GetSQL(), ";\n")), nil
+}
+
+// Verify returns the executed migrations whose checksum no longer matches
+// the recorded one. Records without a checksum are not verified.
+func (m *Migrator) Verify(ctx context.Context) ([]ChecksumMismatch, error) {
+	if err := m.loadSQLMigrations(); err != nil {
+		return nil, err
+	}
+
+	// Ensure migrations table exists
+	if err := m.driver.CreateMigrationsTable(ctx, m.tableName); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	executed, err := m.driver.GetExecutedMigrations(ctx, m.tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get executed migrations: %w", err)
+	}
+
+	return m.verify(ctx, executed)
+}
+
+// verify compares the recorded checksums with the registered migrations
+func (m *Migrator) verify(ctx context.Context, executed []driver.MigrationRecord) ([]ChecksumMismatch, error) {
+	migrationMap := make(map[string]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		migrationMap[migration.Name] = migration
+	}
+
+	var mismatches []ChecksumMismatch
+	for _, record := range executed {
+		migration, ok := migrationMap[record.Migration]
+		if !ok || record.Checksum == "" {
+			continue
+		}
+		current, err := m.Checksum(ctx, migration)
+		if err != nil {
+			return nil, err
+		}
+		if current != record.Checksum {
+			mismatches = append(mismatches, ChecksumMismatch{
+				Name:     record.Migration,
+				Recorded: record.Checksum,
+				Current:  current,
+			})
+		}
+	}
+
+	return mismatches, nil
+}
+
+// checksumOf returns the hex encoded SHA-256 of content
+func checksumOf(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,165 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flyits/migro/pkg/driver"
+)
+
+// 测试目标需求: 迁移校验和的计算、记录与漂移检测
+// 覆盖: Checksum, Verify, Up 拒绝执行, Status 标记
+
+// rawMigration 返回执行一条原生 SQL 的迁移
+func rawMigration(name, sql string) Migration {
+	return Migration{
+		Name: name,
+		Up:   func(ctx context.Context, e *Executor) error { return e.Raw(ctx, sql) },
+		Down: func(ctx context.Context, e *Executor) error { return nil },
+	}
+}
+
+func TestMigrator_Checksum(t *testing.T) {
+	ctx := context.Background()
+	m := NewMigrator(newMockDriver("mysql"), "", "migrations")
+
+	t.Run("Go migration uses the compiled SQL", func(t *testing.T) {
+		a, err := m.Checksum(ctx, rawMigration("001_a", "CREATE TABLE a (id INT)"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		b, err := m.Checksum(ctx, rawMigration("001_a", "CREATE TABLE a (id INT)"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		c, err := m.Checksum(ctx, rawMigration("001_a", "CREATE TABLE a (id BIGINT)"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(a) != 64 {
+			t.Errorf("expected a hex SHA-256 checksum, got %q", a)
+		}
+		if a != b {
+			t.Error("expected the same checksum for the same SQL")
+		}
+		if a == c {
+			t.Error("expected a different checksum for different SQL")
+		}
+	})
+
+	t.Run("explicit checksum is used as is", func(t *testing.T) {
+		migration := rawMigration("001_a", "SELECT 1")
+		migration.Checksum = "abc"
+		got, err := m.Checksum(ctx, migration)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "abc" {
+			t.Errorf("expected 'abc', got %q", got)
+		}
+	})
+
+	t.Run("SQL file migration uses the file contents", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "CREATE TABLE users (id INT);\n"
+		if err := os.WriteFile(filepath.Join(dir, "001_create_users.up.sql"), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+
+		migrations, err := LoadSQLMigrations(dir, "mysql")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if migrations[0].Checksum != checksumOf(content) {
+			t.Errorf("expected checksum of the up file, got %q", migrations[0].Checksum)
+		}
+	})
+}
+
+func TestMigrator_UpRecordsChecksum(t *testing.T) {
+	ctx := context.Background()
+	drv := newMockDriver("mysql")
+	m := NewMigrator(drv, "", "migrations")
+	migration := rawMigration("001_create_users", "CREATE TABLE users (id INT)")
+	m.Register(migration)
+
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want, _ := m.Checksum(ctx, migration)
+	if got := drv.executedMigrations[0].Checksum; got != want {
+		t.Errorf("expected recorded checksum %q, got %q", want, got)
+	}
+}
+
+func TestMigrator_Verify(t *testing.T) {
+	ctx := context.Background()
+
+	newDrifted := func() (*mockDriver, *Migrator) {
+		drv := newMockDriver("mysql")
+		drv.executedMigrations = []driver.MigrationRecord{
+			{Migration: "001_create_users", Batch: 1, Checksum: checksumOf("CREATE TABLE users (id INT)")},
+			{Migration: "002_create_posts", Batch: 1}, // 旧版本记录，没有校验和
+		}
+		drv.lastBatch = 1
+
+		m := NewMigrator(drv, "", "migrations")
+		m.Register(rawMigration("001_create_users", "CREATE TABLE users (id BIGINT)"))
+		m.Register(rawMigration("002_create_posts", "CREATE TABLE posts (id BIGINT)"))
+		m.Register(rawMigration("003_create_tags", "CREATE TABLE tags (id INT)"))
+		return drv, m
+	}
+
+	t.Run("Verify reports modified migrations", func(t *testing.T) {
+		_, m := newDrifted()
+		mismatches, err := m.Verify(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(mismatches) != 1 || mismatches[0].Name != "001_create_users" {
+			t.Fatalf("expected 001_create_users to be modified, got %+v", mismatches)
+		}
+	})
+
+	t.Run("Status marks modified migrations", func(t *testing.T) {
+		_, m := newDrifted()
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !statuses[0].Modified {
+			t.Error("expected 001_create_users to be marked as modified")
+		}
+		if statuses[1].Modified || statuses[2].Modified {
+			t.Error("expected only 001_create_users to be marked as modified")
+		}
+	})
+
+	t.Run("Up refuses to run", func(t *testing.T) {
+		drv, m := newDrifted()
+		_, err := m.Up(ctx, 0)
+		if !errors.Is(err, ErrChecksumMismatch) {
+			t.Fatalf("expected ErrChecksumMismatch, got %v", err)
+		}
+		if len(drv.executedMigrations) != 2 {
+			t.Error("expected no migration to run")
+		}
+	})
+
+	t.Run("Up runs when mismatches are allowed", func(t *testing.T) {
+		drv, m := newDrifted()
+		m.SetAllowChecksumMismatch(true)
+		executed, err := m.Up(ctx, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(executed) != 1 || len(drv.executedMigrations) != 3 {
+			t.Errorf("expected 003_create_tags to run, got %v", executed)
+		}
+	})
+}
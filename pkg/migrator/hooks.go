@@ -0,0 +1,209 @@
+package migrator
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Direction is the direction a migration runs in
+type Direction string
+
+const (
+	// DirectionUp applies a migration
+	DirectionUp Direction = "up"
+
+	// DirectionDown rolls back a migration
+	DirectionDown Direction = "down"
+)
+
+// Event describes a migration or a statement executed by a migration
+type Event struct {
+	Migration string // empty when a batch transaction fails to begin or commit
+	Direction Direction
+	Batch     int
+	SQL       string        // the statement; for OnError the statement that failed, if any
+	Duration  time.Duration // set for AfterMigration, AfterStatement and OnError
+	Err       error         // set for OnError and for AfterStatement when the statement failed
+}
+
+// Hook receives the events of Up, Down, Reset and Refresh. Hooks are not
+// called in dry run mode. Embed NopHook to implement only some of the
+// callbacks.
+type Hook interface {
+	// BeforeMigration is called before a migration runs
+	BeforeMigration(ctx context.Context, event Event)
+
+	// AfterMigration is called after a migration ran and was recorded
+	AfterMigration(ctx context.Context, event Event)
+
+	// BeforeStatement is called before a statement is executed. Operations
+	// the driver executes as a whole, such as AlterTable outside a
+	// transaction, report all their statements in one event.
+	BeforeStatement(ctx context.Context, event Event)
+
+	// AfterStatement is called after a statement was executed
+	AfterStatement(ctx context.Context, event Event)
+
+	// OnError is called when a migration fails
+	OnError(ctx context.Context, event Event)
+}
+
+// NopHook implements Hook with callbacks that do nothing
+type NopHook struct{}
+
+func (NopHook) BeforeMigration(ctx context.Context, event Event) {}
+func (NopHook) AfterMigration(ctx context.Context, event Event)  {}
+func (NopHook) BeforeStatement(ctx context.Context, event Event) {}
+func (NopHook) AfterStatement(ctx context.Context, event Event)  {}
+func (NopHook) OnError(ctx context.Context, event Event)         {}
+
+// AddHook registers a hook. Hooks are called in registration order.
+func (m *Migrator) AddHook(hook Hook) {
+	m.hooks = append(m.hooks, hook)
+}
+
+// migrationRun reports the events of one migration to the hooks
+type migrationRun struct {
+	hooks     []Hook
+	event     Event
+	start     time.Time
+	failedSQL string
+}
+
+// beginMigration calls BeforeMigration and returns the run used to report
+// the statements and the outcome of the migration
+func (m *Migrator) beginMigration(ctx context.Context, migration Migration, direction Direction, batch int) *migrationRun {
+	run := &migrationRun{
+		hooks: m.hooks,
+		event: Event{Migration: migration.Name, Direction: direction, Batch: batch},
+		start: time.Now(),
+	}
+	if m.dryRun {
+		run.hooks = nil
+	}
+	for _, hook := range run.hooks {
+		hook.BeforeMigration(ctx, run.event)
+	}
+	return run
+}
+
+// end calls AfterMigration, or OnError when err is not nil
+func (r *migrationRun) end(ctx context.Context, err error) {
+	event := r.event
+	event.Duration = time.Since(r.start)
+	if err != nil {
+		event.Err = err
+		event.SQL = r.failedSQL
+		for _, hook := range r.hooks {
+			hook.OnError(ctx, event)
+		}
+		return
+	}
+	for _, hook := range r.hooks {
+		hook.AfterMigration(ctx, event)
+	}
+}
+
+// statement runs exec and reports it as a statement of the migration
+func (r *migrationRun) statement(ctx context.Context, sql string, exec func() error) error {
+	if r == nil || len(r.hooks) == 0 {
+		return exec()
+	}
+
+	event := r.event
+	event.SQL = sql
+	for _, hook := range r.hooks {
+		hook.BeforeStatement(ctx, event)
+	}
+	start := time.Now()
+	err := exec()
+	event.Duration = time.Since(start)
+	event.Err = err
+	if err != nil {
+		r.failedSQL = sql
+	}
+	for _, hook := range r.hooks {
+		hook.AfterStatement(ctx, event)
+	}
+	return err
+}
+
+// batchError calls OnError for a failure of the batch transaction itself
+func (m *Migrator) batchError(ctx context.Context, direction Direction, batch int, err error) {
+	if m.dryRun {
+		return
+	}
+	event := Event{Direction: direction, Batch: batch, Err: err}
+	for _, hook := range m.hooks {
+		hook.OnError(ctx, event)
+	}
+}
+
+// jsonLogger writes events as JSON lines
+type jsonLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// jsonEvent is the JSON representation of an event
+type jsonEvent struct {
+	Time       string  `json:"time"`
+	Event      string  `json:"event"`
+	Migration  string  `json:"migration,omitempty"`
+	Direction  string  `json:"direction"`
+	Batch      int     `json:"batch,omitempty"`
+	SQL        string  `json:"sql,omitempty"`
+	DurationMS float64 `json:"duration_ms,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// NewJSONLogger returns a hook that writes every event to w as a line of
+// JSON, e.g.
+//
+//	{"time":"2026-01-02T15:04:05Z","event":"after_migration","migration":"20260102150405_create_users","direction":"up","batch":1,"duration_ms":12.5}
+func NewJSONLogger(w io.Writer) Hook {
+	return &jsonLogger{w: w}
+}
+
+func (l *jsonLogger) BeforeMigration(ctx context.Context, event Event) {
+	l.log("before_migration", event)
+}
+func (l *jsonLogger) AfterMigration(ctx context.Context, event Event) {
+	l.log("after_migration", event)
+}
+func (l *jsonLogger) BeforeStatement(ctx context.Context, event Event) {
+	l.log("before_statement", event)
+}
+func (l *jsonLogger) AfterStatement(ctx context.Context, event Event) {
+	l.log("after_statement", event)
+}
+func (l *jsonLogger) OnError(ctx context.Context, event Event) {
+	l.log("error", event)
+}
+
+func (l *jsonLogger) log(name string, event Event) {
+	entry := jsonEvent{
+		Time:       time.Now().UTC().Format(time.RFC3339Nano),
+		Event:      name,
+		Migration:  event.Migration,
+		Direction:  string(event.Direction),
+		Batch:      event.Batch,
+		SQL:        event.SQL,
+		DurationMS: float64(event.Duration.Microseconds()) / 1000,
+	}
+	if event.Err != nil {
+		entry.Error = event.Err.Error()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(append(line, '\n'))
+}
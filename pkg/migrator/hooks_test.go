@@ -0,0 +1,170 @@
+package migrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/flyits/migro/pkg/driver"
+)
+
+// 测试目标：验证迁移和语句事件按顺序通知 hook，以及 JSON 日志的格式
+
+// recordingHook 记录收到的事件
+type recordingHook struct {
+	names  []string
+	events []Event
+}
+
+func (h *recordingHook) record(name string, event Event) {
+	h.names = append(h.names, name)
+	h.events = append(h.events, event)
+}
+func (h *recordingHook) BeforeMigration(ctx context.Context, event Event) {
+	h.record("before_migration", event)
+}
+func (h *recordingHook) AfterMigration(ctx context.Context, event Event) {
+	h.record("after_migration", event)
+}
+func (h *recordingHook) BeforeStatement(ctx context.Context, event Event) {
+	h.record("before_statement", event)
+}
+func (h *recordingHook) AfterStatement(ctx context.Context, event Event) {
+	h.record("after_statement", event)
+}
+func (h *recordingHook) OnError(ctx context.Context, event Event) { h.record("error", event) }
+
+func TestMigrator_Hooks(t *testing.T) {
+	ctx := context.Background()
+	createUsers := Migration{
+		Name: "001_create_users",
+		Up:   func(ctx context.Context, e *Executor) error { return e.Raw(ctx, "CREATE TABLE users (id INT)") },
+		Down: func(ctx context.Context, e *Executor) error { return e.Raw(ctx, "DROP TABLE users") },
+	}
+
+	for _, name := range []string{"mysql", "postgres"} {
+		t.Run("Up 事件顺序 "+name, func(t *testing.T) {
+			drv := newMockDriver(name)
+			drv.lastBatch = 2
+			m := NewMigrator(drv, "", "migrations")
+			m.Register(createUsers)
+			hook := &recordingHook{}
+			m.AddHook(hook)
+
+			if _, err := m.Up(ctx, 0); err != nil {
+				t.Fatalf("Up failed: %v", err)
+			}
+
+			expected := "before_migration,before_statement,after_statement,after_migration"
+			if got := strings.Join(hook.names, ","); got != expected {
+				t.Fatalf("expected %s, got %s", expected, got)
+			}
+			statement := hook.events[1]
+			if statement.Migration != "001_create_users" || statement.Direction != DirectionUp || statement.Batch != 3 {
+				t.Errorf("unexpected statement event %+v", statement)
+			}
+			if statement.SQL != "CREATE TABLE users (id INT)" {
+				t.Errorf("unexpected statement SQL %q", statement.SQL)
+			}
+		})
+	}
+
+	t.Run("Down 携带回滚的批次", func(t *testing.T) {
+		drv := newMockDriver("mysql")
+		drv.executedMigrations = []driver.MigrationRecord{{Migration: "001_create_users", Batch: 4}}
+		drv.lastBatch = 4
+		m := NewMigrator(drv, "", "migrations")
+		m.Register(createUsers)
+		hook := &recordingHook{}
+		m.AddHook(hook)
+
+		if _, err := m.Down(ctx, 0); err != nil {
+			t.Fatalf("Down failed: %v", err)
+		}
+		if len(hook.events) != 4 {
+			t.Fatalf("expected 4 events, got %v", hook.names)
+		}
+		last := hook.events[3]
+		if last.Direction != DirectionDown || last.Batch != 4 || last.SQL != "" {
+			t.Errorf("unexpected after_migration event %+v", last)
+		}
+	})
+
+	t.Run("失败时通知 OnError", func(t *testing.T) {
+		drv := newMockDriver("mysql")
+		drv.execErr = errors.New("syntax error")
+		m := NewMigrator(drv, "", "migrations")
+		m.Register(createUsers)
+		hook := &recordingHook{}
+		m.AddHook(hook)
+
+		if _, err := m.Up(ctx, 0); err == nil {
+			t.Fatal("expected Up to fail")
+		}
+
+		expected := "before_migration,before_statement,after_statement,error"
+		if got := strings.Join(hook.names, ","); got != expected {
+			t.Fatalf("expected %s, got %s", expected, got)
+		}
+		if !errors.Is(hook.events[2].Err, drv.execErr) {
+			t.Errorf("expected after_statement to carry the error, got %v", hook.events[2].Err)
+		}
+		failure := hook.events[3]
+		if !errors.Is(failure.Err, drv.execErr) || failure.SQL != "CREATE TABLE users (id INT)" {
+			t.Errorf("unexpected error event %+v", failure)
+		}
+	})
+
+	t.Run("dry run 不通知", func(t *testing.T) {
+		m := NewMigrator(newMockDriver("postgres"), "", "migrations")
+		m.Register(createUsers)
+		m.SetDryRun(true)
+		hook := &recordingHook{}
+		m.AddHook(hook)
+
+		if _, err := m.Up(ctx, 0); err != nil {
+			t.Fatalf("Up failed: %v", err)
+		}
+		if len(hook.names) != 0 {
+			t.Errorf("expected no events in dry run mode, got %v", hook.names)
+		}
+	})
+}
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONLogger(&buf)
+	ctx := context.Background()
+
+	logger.BeforeMigration(ctx, Event{Migration: "001_create_users", Direction: DirectionUp, Batch: 1})
+	logger.OnError(ctx, Event{Migration: "001_create_users", Direction: DirectionUp, Batch: 1, SQL: "CREATE TABLE users", Err: errors.New("boom")})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("invalid JSON line %q: %v", lines[1], err)
+	}
+	expected := map[string]interface{}{
+		"event":     "error",
+		"migration": "001_create_users",
+		"direction": "up",
+		"batch":     float64(1),
+		"sql":       "CREATE TABLE users",
+		"error":     "boom",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("%s: expected %v, got %v", key, value, entry[key])
+		}
+	}
+	if _, ok := entry["time"]; !ok {
+		t.Error("expected a time field")
+	}
+}
@@ -0,0 +1,1125 @@
+package migrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flyits/migro/pkg/driver"
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// Migration represents a migration definition
+type Migration struct {
+	Name string
+	Up   func(context.Context, *Executor) error
+	Down func(context.Context, *Executor) error
+
+	// Checksum identifies the content of the migration. When empty it is
+	// computed from the SQL compiled by a dry run of Up.
+	Checksum string
+
+	// DisableTransaction runs the migration outside a transaction even when
+	// the database supports transactional DDL, e.g. for statements such as
+	// CREATE INDEX CONCURRENTLY that cannot run in a transaction
+	DisableTransaction bool
+}
+
+// DefaultLockTimeout is the default time to wait for the migration lock
+const DefaultLockTimeout = time.Minute
+
+// Migrator handles migration execution
+type Migrator struct {
+	driver         driver.Driver
+	migrationsPath string
+	tableName      string
+	migrations     []Migration
+	dryRun         bool
+	sqlLoaded      bool
+	locking        bool
+	lockTimeout    time.Duration
+	allowMismatch  bool
+	schemaDump     string
+	dumpLoaded     bool
+	batchTx        bool
+	hooks          []Hook
+	runInfo        RunInfo
+
+	// dry run state
+	dryRunResults    []DryRunResult
+	dryRunRolledBack map[string]bool
+}
+
+// DryRunResult holds the SQL a migration would execute in dry run mode
+type DryRunResult struct {
+	Migration   string
+	Rollback    bool // true for the Down direction
+	Transaction TransactionMode
+	SQL         []string
+}
+
+// NewMigrator creates a new migrator instance
+func NewMigrator(drv driver.Driver, migrationsPath, tableName string) *Migrator {
+	return &Migrator{
+		driver:         drv,
+		migrationsPath: migrationsPath,
+		tableName:      tableName,
+		migrations:     make([]Migration, 0),
+		locking:        true,
+		lockTimeout:    DefaultLockTimeout,
+	}
+}
+
+// SetDryRun enables or disables dry run mode
+func (m *Migrator) SetDryRun(dryRun bool) {
+	m.dryRun = dryRun
+}
+
+// SetLocking enables or disables the migration lock (enabled by default)
+func (m *Migrator) SetLocking(locking bool) {
+	m.locking = locking
+}
+
+// SetLockTimeout sets how long to wait for the migration lock.
+// A negative timeout waits forever.
+func (m *Migrator) SetLockTimeout(timeout time.Duration) {
+	m.lockTimeout = timeout
+}
+
+// DryRunResults returns the SQL collected by Up, Down, Reset and Refresh in
+// dry run mode, in execution order
+func (m *Migrator) DryRunResults() []DryRunResult {
+	return m.dryRunResults
+}
+
+// executedMigrations returns the executed migrations. In dry run mode the
+// migrations rolled back by a previous call are left out, so that a dry run
+// of Refresh shows the migrations being re-applied.
+func (m *Migrator) executedMigrations(ctx context.Context) ([]driver.MigrationRecord, error) {
+	executed, err := m.driver.GetExecutedMigrations(ctx, m.tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get executed migrations: %w", err)
+	}
+	if !m.dryRun || len(m.dryRunRolledBack) == 0 {
+		return executed, nil
+	}
+
+	remaining := make([]driver.MigrationRecord, 0, len(executed))
+	for _, r := range executed {
+		if !m.dryRunRolledBack[r.Migration] {
+			remaining = append(remaining, r)
+		}
+	}
+	return remaining, nil
+}
+
+// SetAllowChecksumMismatch lets Up run while executed migrations have been
+// modified since they ran
+func (m *Migrator) SetAllowChecksumMismatch(allow bool) {
+	m.allowMismatch = allow
+}
+
+// Unlock forcibly releases the migration lock, e.g. after a crashed run
+func (m *Migrator) Unlock(ctx context.Context) error {
+	locker, ok := m.driver.(driver.Locker)
+	if !ok {
+		return fmt.Errorf("driver %s does not support migration locks", m.driver.Name())
+	}
+	if err := locker.ForceUnlock(ctx, m.tableName); err != nil {
+		return fmt.Errorf("failed to release migration lock: %w", err)
+	}
+	return nil
+}
+
+// withLock runs fn while holding the migration lock so that concurrent
+// migrators do not apply the same migrations twice.
+// The lock is skipped in dry run mode and for drivers without lock support.
+func (m *Migrator) withLock(ctx context.Context, fn func() error) (err error) {
+	locker, ok := m.driver.(driver.Locker)
+	if !ok || !m.locking || m.dryRun {
+		return fn()
+	}
+
+	if err := locker.Lock(ctx, m.tableName, m.lockTimeout); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// The caller's context may already be cancelled, release the lock anyway
+		if unlockErr := locker.Unlock(context.WithoutCancel(ctx), m.tableName); unlockErr != nil && err == nil {
+			err = fmt.Errorf("failed to release migration lock: %w", unlockErr)
+		}
+	}()
+
+	return fn()
+}
+
+// Register registers a migration
+func (m *Migrator) Register(migration Migration) {
+	m.migrations = append(m.migrations, migration)
+}
+
+// RegisterAll registers multiple migrations
+func (m *Migrator) RegisterAll(migrations []Migration) {
+	m.migrations = append(m.migrations, migrations...)
+}
+
+// Migrations returns the registered migrations
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// supportsTransactionalDDL returns true if the database supports transactional DDL
+// PostgreSQL and SQLite support transactional DDL, MySQL does not
+func (m *Migrator) supportsTransactionalDDL() bool {
+	name := m.driver.Name()
+	return name == "postgres" || name == "sqlite"
+}
+
+// executeMigrationInTransaction executes a migration within a transaction
+// isUp: true for Up migration, false for Down migration
+func (m *Migrator) executeMigrationInTransaction(ctx context.Context, run *migrationRun, migration Migration, batch int, checksum string, isUp bool) error {
+	typesDone, err := m.alterTypesBeforeTransaction(ctx, run, migration, isUp)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.driver.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for migration %s: %w", migration.Name, err)
+	}
+
+	if err := m.runInTransaction(ctx, tx, run, migration, batch, checksum, isUp, typesDone); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback also failed: %v)", err, rbErr)
+		}
+		return fmt.Errorf("%w (rolled back)", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %s: %w", migration.Name, err)
+	}
+
+	return nil
+}
+
+// runInTransaction runs the Up or Down of a migration on tx and records or
+// deletes the migration within the same transaction. The type statements in
+// typesDone already ran before the transaction and are skipped.
+func (m *Migrator) runInTransaction(ctx context.Context, tx driver.Transaction, run *migrationRun, migration Migration, batch int, checksum string, isUp bool, typesDone map[string]bool) error {
+	// Create a transaction-aware executor
+	executor := NewTransactionExecutor(m.driver, tx)
+	executor.run = run
+	executor.typesDone = typesDone
+
+	if isUp {
+		start := time.Now()
+		if err := migration.Up(ctx, executor); err != nil {
+			return fmt.Errorf("migration %s failed: %w", migration.Name, err)
+		}
+		record := m.newRecord(migration, batch, checksum, time.Since(start))
+		sql := m.driver.Grammar().CompileInsertMigration(m.tableName)
+		if _, err := tx.Exec(ctx, sql, record.InsertArgs()...); err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", migration.Name, err)
+		}
+		return nil
+	}
+
+	if err := migration.Down(ctx, executor); err != nil {
+		return fmt.Errorf("rollback of %s failed: %w", migration.Name, err)
+	}
+	sql := m.driver.Grammar().CompileDeleteMigration(m.tableName)
+	if _, err := tx.Exec(ctx, sql, migration.Name); err != nil {
+		return fmt.Errorf("failed to delete migration record %s: %w", migration.Name, err)
+	}
+	return nil
+}
+
+// Up runs all pending migrations
+func (m *Migrator) Up(ctx context.Context, step int) (executed []string, err error) {
+	err = m.withLock(ctx, func() error {
+		executed, err = m.up(ctx, step)
+		return err
+	})
+	return executed, err
+}
+
+// up runs pending migrations without acquiring the migration lock
+func (m *Migrator) up(ctx context.Context, step int) ([]string, error) {
+	if err := m.loadSQLMigrations(); err != nil {
+		return nil, err
+	}
+
+	// Ensure migrations table exists
+	if err := m.driver.CreateMigrationsTable(ctx, m.tableName); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	// Get executed migrations
+	executed, err := m.executedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the schema dump into an empty database
+	var dumped []string
+	if len(executed) == 0 {
+		if dumped, err = m.loadSchemaDump(ctx); err != nil {
+			return nil, err
+		}
+		if len(dumped) > 0 && !m.dryRun {
+			if executed, err = m.executedMigrations(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	executedMap := make(map[string]bool)
+	for _, r := range executed {
+		executedMap[r.Migration] = true
+	}
+	for _, name := range dumped {
+		executedMap[name] = true
+	}
+
+	// Refuse to run while executed migrations have been modified
+	if !m.allowMismatch {
+		mismatches, err := m.verify(ctx, executed)
+		if err != nil {
+			return nil, err
+		}
+		if len(mismatches) > 0 {
+			names := make([]string, len(mismatches))
+			for i, mismatch := range mismatches {
+				names[i] = mismatch.Name
+			}
+			return nil, fmt.Errorf("%w: %s", ErrChecksumMismatch, strings.Join(names, ", "))
+		}
+	}
+
+	// Find pending migrations
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if !executedMap[migration.Name] {
+			pending = append(pending, migration)
+		}
+	}
+
+	// Sort by name (which includes timestamp)
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Name < pending[j].Name
+	})
+
+	// Apply step limit
+	if step > 0 && step < len(pending) {
+		pending = pending[:step]
+	}
+
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	// Get next batch number
+	lastBatch, err := m.driver.GetLastBatch(ctx, m.tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last batch: %w", err)
+	}
+	batch := lastBatch + 1
+
+	if m.batchTx {
+		return m.upInBatchTransaction(ctx, pending, batch)
+	}
+
+	// Execute migrations
+	var executedNames []string
+	for _, migration := range pending {
+		run := m.beginMigration(ctx, migration, DirectionUp, batch)
+		err := m.upMigration(ctx, run, migration, batch)
+		run.end(ctx, err)
+		if err != nil {
+			return executedNames, err
+		}
+
+		executedNames = append(executedNames, migration.Name)
+	}
+
+	return executedNames, nil
+}
+
+// upMigration runs and records a migration, in a transaction of its own
+// when the database supports transactional DDL
+func (m *Migrator) upMigration(ctx context.Context, run *migrationRun, migration Migration, batch int) error {
+	var checksum string
+	if !m.dryRun {
+		var err error
+		if checksum, err = m.Checksum(ctx, migration); err != nil {
+			return err
+		}
+	}
+
+	mode := m.transactionMode(migration)
+	if mode == MigrationTransaction && !m.dryRun {
+		return m.executeMigrationInTransaction(ctx, run, migration, batch, checksum, true)
+	}
+
+	executor := NewExecutor(m.driver, m.dryRun)
+	executor.run = run
+	start := time.Now()
+	if err := migration.Up(ctx, executor); err != nil {
+		return fmt.Errorf("migration %s failed: %w", migration.Name, err)
+	}
+	if m.dryRun {
+		m.addDryRunResult(DryRunResult{Migration: migration.Name, Transaction: mode, SQL: executor.GetSQL()})
+		return nil
+	}
+	record := m.newRecord(migration, batch, checksum, time.Since(start))
+	if err := m.driver.RecordMigration(ctx, m.tableName, record); err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", migration.Name, err)
+	}
+	return nil
+}
+
+// Down rolls back migrations
+func (m *Migrator) Down(ctx context.Context, step int) (rolledBack []string, err error) {
+	err = m.withLock(ctx, func() error {
+		rolledBack, err = m.down(ctx, step)
+		return err
+	})
+	return rolledBack, err
+}
+
+// down rolls back migrations without acquiring the migration lock
+func (m *Migrator) down(ctx context.Context, step int) ([]string, error) {
+	if err := m.loadSQLMigrations(); err != nil {
+		return nil, err
+	}
+
+	// Ensure migrations table exists and has the columns read below
+	if err := m.driver.CreateMigrationsTable(ctx, m.tableName); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	// Get executed migrations
+	executed, err := m.executedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(executed) == 0 {
+		return nil, nil
+	}
+
+	// Get last batch
+	lastBatch, err := m.driver.GetLastBatch(ctx, m.tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last batch: %w", err)
+	}
+	if len(m.dryRunRolledBack) > 0 {
+		// Batches rolled back by a previous dry run are still in the table
+		lastBatch = 0
+		for _, r := range executed {
+			if r.Batch > lastBatch {
+				lastBatch = r.Batch
+			}
+		}
+	}
+
+	// Find migrations to rollback
+	var toRollback []driver.MigrationRecord
+	if step > 0 {
+		// Rollback specific number of migrations
+		count := 0
+		for i := len(executed) - 1; i >= 0 && count < step; i-- {
+			toRollback = append(toRollback, executed[i])
+			count++
+		}
+	} else {
+		// Rollback last batch
+		for _, r := range executed {
+			if r.Batch == lastBatch {
+				toRollback = append(toRollback, r)
+			}
+		}
+	}
+
+	// Reverse order for rollback
+	sort.Slice(toRollback, func(i, j int) bool {
+		return toRollback[i].Migration > toRollback[j].Migration
+	})
+
+	// Create migration map for lookup
+	migrationMap := make(map[string]Migration)
+	for _, migration := range m.migrations {
+		migrationMap[migration.Name] = migration
+	}
+
+	migrations := make([]Migration, 0, len(toRollback))
+	batches := make(map[string]int, len(toRollback))
+	for _, record := range toRollback {
+		migration, ok := migrationMap[record.Migration]
+		if !ok {
+			return nil, fmt.Errorf("migration %s not found in registered migrations", record.Migration)
+		}
+		migrations = append(migrations, migration)
+		batches[migration.Name] = record.Batch
+	}
+
+	if m.batchTx {
+		return m.downInBatchTransaction(ctx, migrations, batches)
+	}
+
+	// Execute rollbacks
+	var rolledBack []string
+	for _, migration := range migrations {
+		run := m.beginMigration(ctx, migration, DirectionDown, batches[migration.Name])
+		err := m.downMigration(ctx, run, migration)
+		run.end(ctx, err)
+		if err != nil {
+			return rolledBack, err
+		}
+
+		rolledBack = append(rolledBack, migration.Name)
+	}
+
+	return rolledBack, nil
+}
+
+// downMigration rolls back a migration and deletes its record, in a
+// transaction of its own when the database supports transactional DDL
+func (m *Migrator) downMigration(ctx context.Context, run *migrationRun, migration Migration) error {
+	mode := m.transactionMode(migration)
+	if mode == MigrationTransaction && !m.dryRun {
+		return m.executeMigrationInTransaction(ctx, run, migration, 0, "", false)
+	}
+
+	executor := NewExecutor(m.driver, m.dryRun)
+	executor.run = run
+	if err := migration.Down(ctx, executor); err != nil {
+		return fmt.Errorf("rollback of %s failed: %w", migration.Name, err)
+	}
+	if m.dryRun {
+		m.addDryRunResult(DryRunResult{Migration: migration.Name, Rollback: true, Transaction: mode, SQL: executor.GetSQL()})
+		return nil
+	}
+	if err := m.driver.DeleteMigration(ctx, m.tableName, migration.Name); err != nil {
+		return fmt.Errorf("failed to delete migration record %s: %w", migration.Name, err)
+	}
+	return nil
+}
+
+// Reset rolls back all migrations
+func (m *Migrator) Reset(ctx context.Context) (rolledBack []string, err error) {
+	err = m.withLock(ctx, func() error {
+		rolledBack, err = m.reset(ctx)
+		return err
+	})
+	return rolledBack, err
+}
+
+// reset rolls back all migrations without acquiring the migration lock
+func (m *Migrator) reset(ctx context.Context) ([]string, error) {
+	// Ensure migrations table exists and has the current columns
+	if err := m.driver.CreateMigrationsTable(ctx, m.tableName); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	executed, err := m.executedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.down(ctx, len(executed))
+}
+
+// Refresh rolls back all migrations and re-runs them.
+// The migration lock is held for the whole operation.
+func (m *Migrator) Refresh(ctx context.Context) (rolledBack, executed []string, err error) {
+	err = m.withLock(ctx, func() error {
+		rolledBack, err = m.reset(ctx)
+		if err != nil {
+			return err
+		}
+		executed, err = m.up(ctx, 0)
+		return err
+	})
+	return rolledBack, executed, err
+}
+
+// Status returns the status of all migrations
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.loadSQLMigrations(); err != nil {
+		return nil, err
+	}
+
+	// Ensure migrations table exists
+	if err := m.driver.CreateMigrationsTable(ctx, m.tableName); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	// Get executed migrations
+	executed, err := m.driver.GetExecutedMigrations(ctx, m.tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get executed migrations: %w", err)
+	}
+
+	executedMap := make(map[string]driver.MigrationRecord)
+	for _, r := range executed {
+		executedMap[r.Migration] = r
+	}
+
+	mismatches, err := m.verify(ctx, executed)
+	if err != nil {
+		return nil, err
+	}
+	modified := make(map[string]bool, len(mismatches))
+	for _, mismatch := range mismatches {
+		modified[mismatch.Name] = true
+	}
+
+	// Build status list
+	var statuses []MigrationStatus
+	for _, migration := range m.migrations {
+		status := MigrationStatus{
+			Name: migration.Name,
+		}
+
+		if record, ok := executedMap[migration.Name]; ok {
+			status.Ran = true
+			status.Batch = record.Batch
+			status.ExecutedAt = record.ExecutedAt.Format("2006-01-02 15:04:05")
+			status.Checksum = record.Checksum
+			status.Modified = modified[migration.Name]
+			status.Duration = record.Duration
+			status.Host = record.Host
+			status.Version = record.Version
+			status.User = record.User
+			status.Note = record.Note
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	// Sort by name
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses, nil
+}
+
+// MigrationStatus represents the status of a migration
+type MigrationStatus struct {
+	Name       string
+	Ran        bool
+	Batch      int
+	ExecutedAt string
+	Checksum   string // recorded checksum
+	Modified   bool   // the migration changed since it ran
+
+	// Metadata recorded when the migration ran, empty for older records
+	Duration time.Duration
+	Host     string
+	Version  string
+	User     string
+	Note     string
+}
+
+// Executor provides the API for migration operations
+type Executor struct {
+	driver driver.Driver
+	tx     driver.Transaction // optional transaction for transactional DDL
+	dryRun bool
+	sqls   []string
+
+	// checksum compiles the statements without reading the schema, so the
+	// checksum of a migration does not change once it ran
+	checksum bool
+
+	// alterTypeSQLs collects the type statements of AlterTable in dry run
+	// mode, typesDone holds those that ran before the transaction began.
+	// See Migrator.alterTypesBeforeTransaction.
+	alterTypeSQLs []string
+	typesDone     map[string]bool
+
+	recorder *changeRecorder // records inverse operations instead of executing, see Reverse
+	run      *migrationRun   // reports the executed statements to the hooks
+}
+
+// NewExecutor creates a new executor
+func NewExecutor(drv driver.Driver, dryRun bool) *Executor {
+	return &Executor{
+		driver: drv,
+		tx:     nil,
+		dryRun: dryRun,
+		sqls:   make([]string, 0),
+	}
+}
+
+// CreateTable creates a new table
+func (e *Executor) CreateTable(ctx context.Context, name string, fn func(*schema.Table)) error {
+	if e.recorder != nil {
+		e.recorder.reverse(func(ctx context.Context, e *Executor) error {
+			return e.DropTableIfExists(ctx, name)
+		})
+		return nil
+	}
+
+	table := schema.NewTable(name)
+	fn(table)
+	if err := e.validateTable(table); err != nil {
+		return fmt.Errorf("failed to create table %s: %w", name, err)
+	}
+
+	typeSQLs := e.createTypes(table)
+	sql := e.driver.Grammar().CompileCreate(table)
+
+	if e.dryRun {
+		e.sqls = append(e.sqls, typeSQLs...)
+		e.sqls = append(e.sqls, sql)
+		return nil
+	}
+
+	// Use transaction if available
+	if e.tx != nil {
+		for _, typeSQL := range typeSQLs {
+			if err := e.txExec(ctx, typeSQL); err != nil {
+				return fmt.Errorf("failed to create table %s: %w", name, err)
+			}
+		}
+		if err := e.txExec(ctx, sql); err != nil {
+			return fmt.Errorf("failed to create table %s: %w", name, err)
+		}
+		// Create indexes separately for transaction mode
+		for _, idx := range table.Indexes {
+			if idx.Type != schema.IndexTypePrimary {
+				idxSQL := e.driver.Grammar().CompileIndex(table.Name, idx)
+				if err := e.txExec(ctx, idxSQL); err != nil {
+					return fmt.Errorf("failed to create index: %w", err)
+				}
+			}
+		}
+		return nil
+	}
+
+	return e.run.statement(ctx, joinSQL(typeSQLs, sql), func() error {
+		return e.driver.CreateTable(ctx, table)
+	})
+}
+
+// txExec executes a statement on the transaction
+func (e *Executor) txExec(ctx context.Context, sql string) error {
+	return e.run.statement(ctx, sql, func() error {
+		_, err := e.tx.Exec(ctx, sql)
+		return err
+	})
+}
+
+// AlterTable modifies an existing table
+func (e *Executor) AlterTable(ctx context.Context, name string, fn func(*schema.Table)) error {
+	table := schema.NewTable(name)
+	table.IsAlter = true
+	fn(table)
+
+	if e.recorder != nil {
+		e.recorder.recordAlter(table)
+		return nil
+	}
+	if err := e.validateTable(table); err != nil {
+		return fmt.Errorf("failed to alter table %s: %w", name, err)
+	}
+
+	sqls, err := e.compileAlter(ctx, table)
+	if err != nil {
+		return fmt.Errorf("failed to alter table %s: %w", name, err)
+	}
+	typeSQLs := e.alterTypes(table)
+
+	if e.dryRun {
+		e.alterTypeSQLs = append(e.alterTypeSQLs, typeSQLs...)
+		e.sqls = append(e.sqls, typeSQLs...)
+		e.sqls = append(e.sqls, sqls...)
+		return nil
+	}
+
+	// Use transaction if available
+	if e.tx != nil {
+		for _, sql := range typeSQLs {
+			if e.typesDone[sql] {
+				continue
+			}
+			if err := e.txExec(ctx, sql); err != nil {
+				return fmt.Errorf("failed to alter table %s: %w", name, err)
+			}
+		}
+		for _, sql := range sqls {
+			if sql == "" {
+				continue
+			}
+			if err := e.txExec(ctx, sql); err != nil {
+				return fmt.Errorf("failed to alter table %s: %w", name, err)
+			}
+		}
+		return nil
+	}
+
+	return e.run.statement(ctx, joinSQL(typeSQLs, sqls...), func() error {
+		return e.driver.AlterTable(ctx, table)
+	})
+}
+
+// validateTable returns the error of grammars implementing
+// driver.TableValidator for a definition they cannot compile
+func (e *Executor) validateTable(table *schema.Table) error {
+	validator, ok := e.driver.Grammar().(driver.TableValidator)
+	if !ok {
+		return nil
+	}
+	return validator.ValidateTable(table)
+}
+
+// compileAlter compiles the ALTER TABLE statements. Drivers implementing
+// driver.AlterCompiler read the current table definition, on the transaction
+// when there is one. A checksum run uses the requested alteration instead.
+func (e *Executor) compileAlter(ctx context.Context, table *schema.Table) ([]string, error) {
+	compiler, ok := e.driver.(driver.AlterCompiler)
+	if !ok {
+		return e.driver.Grammar().CompileAlter(table), nil
+	}
+	if e.checksum {
+		definition, err := json.Marshal(table)
+		if err != nil {
+			return nil, err
+		}
+		return []string{"-- alter " + string(definition)}, nil
+	}
+	return compiler.CompileAlterTable(ctx, e.queryer(), table)
+}
+
+// DropTable drops a table
+func (e *Executor) DropTable(ctx context.Context, name string) error {
+	if e.recorder != nil {
+		e.recorder.refuse("DropTable(%s)", name)
+		return nil
+	}
+
+	sql := e.driver.Grammar().CompileDrop(name)
+	typeSQLs := e.dropTypes(name)
+
+	if e.dryRun {
+		e.sqls = append(e.sqls, sql)
+		e.sqls = append(e.sqls, typeSQLs...)
+		return nil
+	}
+
+	// Use transaction if available
+	if e.tx != nil {
+		for _, stmt := range append([]string{sql}, typeSQLs...) {
+			if err := e.txExec(ctx, stmt); err != nil {
+				return fmt.Errorf("failed to drop table %s: %w", name, err)
+			}
+		}
+		return nil
+	}
+
+	return e.run.statement(ctx, joinSQL([]string{sql}, typeSQLs...), func() error {
+		return e.driver.DropTable(ctx, name)
+	})
+}
+
+// DropTableIfExists drops a table if it exists
+func (e *Executor) DropTableIfExists(ctx context.Context, name string) error {
+	if e.recorder != nil {
+		e.recorder.refuse("DropTableIfExists(%s)", name)
+		return nil
+	}
+
+	sql := e.driver.Grammar().CompileDropIfExists(name)
+	typeSQLs := e.dropTypes(name)
+
+	if e.dryRun {
+		e.sqls = append(e.sqls, sql)
+		e.sqls = append(e.sqls, typeSQLs...)
+		return nil
+	}
+
+	// Use transaction if available
+	if e.tx != nil {
+		for _, stmt := range append([]string{sql}, typeSQLs...) {
+			if err := e.txExec(ctx, stmt); err != nil {
+				return fmt.Errorf("failed to drop table %s: %w", name, err)
+			}
+		}
+		return nil
+	}
+
+	return e.run.statement(ctx, joinSQL([]string{sql}, typeSQLs...), func() error {
+		return e.driver.DropTableIfExists(ctx, name)
+	})
+}
+
+// createTypes returns the statements creating the column types of a new
+// table, for grammars implementing driver.TypeCompiler
+func (e *Executor) createTypes(table *schema.Table) []string {
+	if compiler, ok := e.driver.Grammar().(driver.TypeCompiler); ok {
+		return compiler.CompileCreateTypes(table)
+	}
+	return nil
+}
+
+// alterTypes returns the statements updating the column types of an altered table
+func (e *Executor) alterTypes(table *schema.Table) []string {
+	if compiler, ok := e.driver.Grammar().(driver.TypeCompiler); ok {
+		return compiler.CompileAlterTypes(table)
+	}
+	return nil
+}
+
+// dropTypes returns the statements dropping the column types of a dropped table
+func (e *Executor) dropTypes(name string) []string {
+	if compiler, ok := e.driver.Grammar().(driver.TypeCompiler); ok {
+		return compiler.CompileDropTypes(name)
+	}
+	return nil
+}
+
+// renameTypes returns the statements renaming the column types of a renamed table
+func (e *Executor) renameTypes(from, to string) []string {
+	if compiler, ok := e.driver.Grammar().(driver.TypeCompiler); ok {
+		return compiler.CompileRenameTypes(from, to)
+	}
+	return nil
+}
+
+// joinSQL joins statements executed by a single driver call, to report them
+// in one hook event
+func joinSQL(statements []string, more ...string) string {
+	return strings.Join(append(append([]string(nil), statements...), more...), ";\n")
+}
+
+// HasTable checks if a table exists. A checksum run reports no table.
+func (e *Executor) HasTable(ctx context.Context, name string) (bool, error) {
+	if e.checksum {
+		return false, nil
+	}
+	// HasTable always uses the driver directly (read operation)
+	return e.driver.HasTable(ctx, name)
+}
+
+// HasColumn checks if a table has a column. A checksum run reports no column.
+func (e *Executor) HasColumn(ctx context.Context, table, column string) (bool, error) {
+	columns, err := e.GetColumnListing(ctx, table)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range columns {
+		if strings.EqualFold(name, column) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetColumnListing returns the column names of a table in declaration order.
+// It returns no columns when the table does not exist, and during a checksum run.
+func (e *Executor) GetColumnListing(ctx context.Context, table string) ([]string, error) {
+	if e.checksum {
+		return nil, nil
+	}
+	ins, err := e.inspector()
+	if err != nil {
+		return nil, err
+	}
+	columns, err := ins.GetColumns(ctx, e.queryer(), table)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+	return names, nil
+}
+
+// HasIndex checks if a table has an index with the given name. A checksum
+// run reports no index.
+func (e *Executor) HasIndex(ctx context.Context, table, index string) (bool, error) {
+	if e.checksum {
+		return false, nil
+	}
+	ins, err := e.inspector()
+	if err != nil {
+		return false, err
+	}
+	indexes, err := ins.GetIndexes(ctx, e.queryer(), table)
+	if err != nil {
+		return false, err
+	}
+	for _, idx := range indexes {
+		if strings.EqualFold(idx.Name, index) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HasForeignKey checks if a table has a foreign key constraint with the given
+// name. A checksum run reports no foreign key.
+func (e *Executor) HasForeignKey(ctx context.Context, table, foreignKey string) (bool, error) {
+	if e.checksum {
+		return false, nil
+	}
+	ins, err := e.inspector()
+	if err != nil {
+		return false, err
+	}
+	foreignKeys, err := ins.GetForeignKeys(ctx, e.queryer(), table)
+	if err != nil {
+		return false, err
+	}
+	for _, fk := range foreignKeys {
+		if strings.EqualFold(fk.Name, foreignKey) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// inspector returns the driver as a driver.Inspector
+func (e *Executor) inspector() (driver.Inspector, error) {
+	ins, ok := e.driver.(driver.Inspector)
+	if !ok {
+		return nil, fmt.Errorf("driver %s does not support schema inspection", e.driver.Name())
+	}
+	return ins, nil
+}
+
+// queryer returns the transaction when there is one, so reads see the
+// changes made by the running migration
+func (e *Executor) queryer() driver.Queryer {
+	if e.tx != nil {
+		return e.tx
+	}
+	return e.driver
+}
+
+// RenameTable renames a table
+func (e *Executor) RenameTable(ctx context.Context, from, to string) error {
+	if e.recorder != nil {
+		e.recorder.reverse(func(ctx context.Context, e *Executor) error {
+			return e.RenameTable(ctx, to, from)
+		})
+		return nil
+	}
+
+	sql := e.driver.Grammar().CompileRename(from, to)
+	typeSQLs := e.renameTypes(from, to)
+
+	if e.dryRun {
+		e.sqls = append(e.sqls, sql)
+		e.sqls = append(e.sqls, typeSQLs...)
+		return nil
+	}
+
+	// Use transaction if available
+	if e.tx != nil {
+		for _, stmt := range append([]string{sql}, typeSQLs...) {
+			if err := e.txExec(ctx, stmt); err != nil {
+				return fmt.Errorf("failed to rename table %s to %s: %w", from, to, err)
+			}
+		}
+		return nil
+	}
+
+	return e.run.statement(ctx, joinSQL([]string{sql}, typeSQLs...), func() error {
+		return e.driver.RenameTable(ctx, from, to)
+	})
+}
+
+// CreateSchema creates a schema (namespace), on databases whose grammar
+// implements driver.SchemaCompiler
+func (e *Executor) CreateSchema(ctx context.Context, name string) error {
+	if e.recorder != nil {
+		e.recorder.reverse(func(ctx context.Context, e *Executor) error {
+			return e.DropSchema(ctx, name)
+		})
+		return nil
+	}
+
+	compiler, err := e.schemaCompiler()
+	if err != nil {
+		return err
+	}
+	if err := e.exec(ctx, compiler.CompileCreateSchema(name)); err != nil {
+		return fmt.Errorf("failed to create schema %s: %w", name, err)
+	}
+	return nil
+}
+
+// DropSchema drops a schema. The schema must be empty.
+func (e *Executor) DropSchema(ctx context.Context, name string) error {
+	if e.recorder != nil {
+		e.recorder.refuse("DropSchema(%s)", name)
+		return nil
+	}
+
+	compiler, err := e.schemaCompiler()
+	if err != nil {
+		return err
+	}
+	if err := e.exec(ctx, compiler.CompileDropSchema(name)); err != nil {
+		return fmt.Errorf("failed to drop schema %s: %w", name, err)
+	}
+	return nil
+}
+
+// schemaCompiler returns the grammar as a driver.SchemaCompiler
+func (e *Executor) schemaCompiler() (driver.SchemaCompiler, error) {
+	compiler, ok := e.driver.Grammar().(driver.SchemaCompiler)
+	if !ok {
+		return nil, fmt.Errorf("driver %s does not support schemas", e.driver.Name())
+	}
+	return compiler, nil
+}
+
+// exec executes a statement that has no driver method, on the transaction
+// when there is one
+func (e *Executor) exec(ctx context.Context, sql string) error {
+	if e.dryRun {
+		e.sqls = append(e.sqls, sql)
+		return nil
+	}
+	if e.tx != nil {
+		return e.txExec(ctx, sql)
+	}
+	return e.run.statement(ctx, sql, func() error {
+		_, err := e.driver.Exec(ctx, sql)
+		return err
+	})
+}
+
+// Raw executes raw SQL
+func (e *Executor) Raw(ctx context.Context, sql string) error {
+	if e.recorder != nil {
+		e.recorder.refuse("Raw")
+		return nil
+	}
+
+	return e.exec(ctx, sql)
+}
+
+// GetSQL returns the collected SQL statements (for dry run)
+func (e *Executor) GetSQL() []string {
+	return e.sqls
+}
+
+// NewTransactionExecutor creates a new executor that uses a transaction
+func NewTransactionExecutor(drv driver.Driver, tx driver.Transaction) *Executor {
+	return &Executor{
+		driver: drv,
+		tx:     tx,
+		dryRun: false,
+		sqls:   make([]string, 0),
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -147,13 +149,10 @@ func (d *mockDriver) CreateMigrationsTable(ctx context.Context, tableName string
 func (d *mockDriver) GetExecutedMigrations(ctx context.Context, tableName string) ([]driver.MigrationRecord, error) {
 	return d.executedMigrations, nil
 }
-func (d *mockDriver) RecordMigration(ctx context.Context, tableName, migration string, batch int) error {
-	d.executedMigrations = append(d.executedMigrations, driver.MigrationRecord{
-		Migration:  migration,
-		Batch:      batch,
-		ExecutedAt: time.Now(),
-	})
-	d.lastBatch = batch
+func (d *mockDriver) RecordMigration(ctx context.Context, tableName string, record driver.MigrationRecord) error {
+	record.ExecutedAt = time.Now()
+	d.executedMigrations = append(d.executedMigrations, record)
+	d.lastBatch = record.Batch
 	return nil
 }
 func (d *mockDriver) DeleteMigration(ctx context.Context, tableName, migration string) error {
@@ -709,6 +708,272 @@ func TestExecutor_Operations(t *testing.T) {
 	})
 }
 
+// schemaGrammar 模拟支持 schema 的 Grammar
+type schemaGrammar struct {
+	mockGrammar
+}
+
+func (g *schemaGrammar) CompileCreateSchema(name string) string { return "CREATE SCHEMA " + name }
+func (g *schemaGrammar) CompileDropSchema(name string) string   { return "DROP SCHEMA " + name }
+
+func TestExecutor_Schemas(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("CreateSchema 和 DropSchema", func(t *testing.T) {
+		drv := newMockDriver("postgres")
+		drv.grammar = &schemaGrammar{}
+		e := NewExecutor(drv, true)
+
+		if err := e.CreateSchema(ctx, "billing"); err != nil {
+			t.Fatalf("CreateSchema failed: %v", err)
+		}
+		if err := e.DropSchema(ctx, "billing"); err != nil {
+			t.Fatalf("DropSchema failed: %v", err)
+		}
+		expected := "CREATE SCHEMA billing|DROP SCHEMA billing"
+		if got := strings.Join(e.GetSQL(), "|"); got != expected {
+			t.Errorf("expected %s, got %s", expected, got)
+		}
+	})
+
+	t.Run("CreateSchema 的逆操作", func(t *testing.T) {
+		drv := newMockDriver("postgres")
+		drv.grammar = &schemaGrammar{}
+		e := NewExecutor(drv, true)
+
+		change := func(ctx context.Context, e *Executor) error { return e.CreateSchema(ctx, "billing") }
+		if err := Reverse(change)(ctx, e); err != nil {
+			t.Fatalf("Reverse failed: %v", err)
+		}
+		if got := strings.Join(e.GetSQL(), "|"); got != "DROP SCHEMA billing" {
+			t.Errorf("expected DROP SCHEMA billing, got %s", got)
+		}
+
+		drop := func(ctx context.Context, e *Executor) error { return e.DropSchema(ctx, "billing") }
+		if err := Reverse(drop)(ctx, e); !errors.Is(err, ErrIrreversible) {
+			t.Errorf("expected ErrIrreversible, got %v", err)
+		}
+	})
+
+	t.Run("不支持 schema 的驱动", func(t *testing.T) {
+		e := NewExecutor(newMockDriver("mysql"), true)
+		if err := e.CreateSchema(ctx, "billing"); err == nil {
+			t.Error("expected an error for a driver without schemas")
+		}
+	})
+}
+
+// typeGrammar 模拟为列创建独立类型的 Grammar
+type typeGrammar struct {
+	mockGrammar
+}
+
+func (g *typeGrammar) CompileCreateTypes(table *schema.Table) []string {
+	return []string{"CREATE TYPE " + table.Name + "_status"}
+}
+func (g *typeGrammar) CompileAlterTypes(table *schema.Table) []string {
+	return []string{"ALTER TYPE " + table.Name + "_status"}
+}
+func (g *typeGrammar) CompileDropTypes(table string) []string {
+	return []string{"DROP TYPE " + table + "_status"}
+}
+func (g *typeGrammar) CompileRenameTypes(from, to string) []string {
+	return []string{"RENAME TYPE " + from + "_status TO " + to + "_status"}
+}
+
+// execRecordingDriver 记录在事务之外执行的语句
+type execRecordingDriver struct {
+	*mockDriver
+	execs []string
+}
+
+func (d *execRecordingDriver) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	d.execs = append(d.execs, query)
+	return nil, nil
+}
+
+// recordingTransaction 记录在事务中执行的语句
+type recordingTransaction struct {
+	mockTransaction
+	execs []string
+}
+
+func (t *recordingTransaction) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	t.execs = append(t.execs, query)
+	return nil, nil
+}
+
+// 测试目标：验证列类型语句的顺序，以及执行器在事务中执行类型语句
+func TestExecutor_Types(t *testing.T) {
+	ctx := context.Background()
+	newDriver := func() *execRecordingDriver {
+		drv := newMockDriver("postgres")
+		drv.grammar = &typeGrammar{}
+		return &execRecordingDriver{mockDriver: drv}
+	}
+	enumColumn := func(t *schema.Table) { t.Enum("status", "draft") }
+
+	t.Run("dry run", func(t *testing.T) {
+		e := NewExecutor(newDriver(), true)
+		if err := e.CreateTable(ctx, "posts", enumColumn); err != nil {
+			t.Fatalf("CreateTable failed: %v", err)
+		}
+		if err := e.AlterTable(ctx, "posts", enumColumn); err != nil {
+			t.Fatalf("AlterTable failed: %v", err)
+		}
+		if err := e.RenameTable(ctx, "posts", "articles"); err != nil {
+			t.Fatalf("RenameTable failed: %v", err)
+		}
+		if err := e.DropTable(ctx, "articles"); err != nil {
+			t.Fatalf("DropTable failed: %v", err)
+		}
+		expected := "CREATE TYPE posts_status|CREATE TABLE test|ALTER TYPE posts_status|ALTER TABLE test|" +
+			"RENAME TABLE posts TO articles|RENAME TYPE posts_status TO articles_status|DROP TABLE articles|DROP TYPE articles_status"
+		if got := strings.Join(e.GetSQL(), "|"); got != expected {
+			t.Errorf("expected %s, got %s", expected, got)
+		}
+	})
+
+	t.Run("事务中", func(t *testing.T) {
+		drv := newDriver()
+		tx := &recordingTransaction{}
+		e := NewTransactionExecutor(drv, tx)
+		if err := e.CreateTable(ctx, "posts", enumColumn); err != nil {
+			t.Fatalf("CreateTable failed: %v", err)
+		}
+		if err := e.AlterTable(ctx, "posts", enumColumn); err != nil {
+			t.Fatalf("AlterTable failed: %v", err)
+		}
+		if err := e.RenameTable(ctx, "posts", "articles"); err != nil {
+			t.Fatalf("RenameTable failed: %v", err)
+		}
+		if err := e.DropTableIfExists(ctx, "articles"); err != nil {
+			t.Fatalf("DropTableIfExists failed: %v", err)
+		}
+
+		expected := "CREATE TYPE posts_status|CREATE TABLE test|ALTER TYPE posts_status|ALTER TABLE test|" +
+			"RENAME TABLE posts TO articles|RENAME TYPE posts_status TO articles_status|DROP TABLE IF EXISTS articles|DROP TYPE articles_status"
+		if got := strings.Join(tx.execs, "|"); got != expected {
+			t.Errorf("expected %s in the transaction, got %s", expected, got)
+		}
+		if len(drv.execs) != 0 {
+			t.Errorf("expected no statement outside the transaction, got %v", drv.execs)
+		}
+	})
+}
+
+// orderRecordingDriver 按顺序记录在连接上和事务中执行的语句
+type orderRecordingDriver struct {
+	*mockDriver
+	log []string
+}
+
+func (d *orderRecordingDriver) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	d.log = append(d.log, "conn: "+query)
+	return nil, nil
+}
+
+func (d *orderRecordingDriver) Begin(ctx context.Context) (driver.Transaction, error) {
+	d.log = append(d.log, "BEGIN")
+	return &orderRecordingTransaction{driver: d}, nil
+}
+
+type orderRecordingTransaction struct {
+	mockTransaction
+	driver *orderRecordingDriver
+}
+
+func (t *orderRecordingTransaction) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	t.driver.log = append(t.driver.log, "tx: "+query)
+	return nil, nil
+}
+
+// enumDefaultGrammar 为修改后的默认值生成 SET DEFAULT 语句
+type enumDefaultGrammar struct {
+	typeGrammar
+}
+
+func (g *enumDefaultGrammar) CompileAlter(table *schema.Table) []string {
+	var sqls []string
+	for _, col := range table.Columns {
+		sqls = append(sqls, fmt.Sprintf("ALTER TABLE %s ALTER %s SET DEFAULT %v", table.Name, col.Name, col.DefaultValue))
+	}
+	return sqls
+}
+
+// 测试目标：验证 ALTER TYPE 在迁移事务开始之前执行，同一个迁移可以把新增的枚举值用作默认值
+func TestMigrator_AlterTypesBeforeTransaction(t *testing.T) {
+	ctx := context.Background()
+	mock := newMockDriver("postgres")
+	mock.grammar = &enumDefaultGrammar{}
+	drv := &orderRecordingDriver{mockDriver: mock}
+
+	m := NewMigrator(drv, "", "migrations")
+	m.Register(Migration{
+		Name: "20260101000001_publish_posts",
+		Up: func(ctx context.Context, e *Executor) error {
+			return e.AlterTable(ctx, "posts", func(t *schema.Table) {
+				t.ChangeEnum("status", "draft", "published").Default("published")
+			})
+		},
+	})
+
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	expected := []string{
+		"conn: ALTER TYPE posts_status",
+		"BEGIN",
+		"tx: ALTER TABLE posts ALTER status SET DEFAULT published",
+	}
+	if len(drv.log) < len(expected) || strings.Join(drv.log[:len(expected)], "|") != strings.Join(expected, "|") {
+		t.Errorf("expected %v, got %v", expected, drv.log)
+	}
+	for _, entry := range drv.log[1:] {
+		if strings.Contains(entry, "ALTER TYPE") {
+			t.Errorf("expected ALTER TYPE to run once before the transaction, got %v", drv.log)
+		}
+	}
+}
+
+// validatingGrammar 模拟拒绝所有生成列的 Grammar
+type validatingGrammar struct {
+	mockGrammar
+}
+
+func (g *validatingGrammar) ValidateTable(table *schema.Table) error {
+	for _, col := range table.Columns {
+		if col.GeneratedAs != "" {
+			return errors.New("generated columns are not supported")
+		}
+	}
+	return nil
+}
+
+// 测试目标：验证 Grammar 拒绝的表定义在编译和执行之前报错
+func TestExecutor_ValidateTable(t *testing.T) {
+	ctx := context.Background()
+	drv := newMockDriver("postgres")
+	drv.grammar = &validatingGrammar{}
+	e := NewExecutor(drv, true)
+
+	err := e.CreateTable(ctx, "users", func(t *schema.Table) { t.Text("search").VirtualAs("lower(name)") })
+	if err == nil || !strings.Contains(err.Error(), "failed to create table users: generated columns are not supported") {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+	err = e.AlterTable(ctx, "users", func(t *schema.Table) { t.Text("search").VirtualAs("lower(name)") })
+	if err == nil || !strings.Contains(err.Error(), "failed to alter table users") {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+	if len(e.GetSQL()) != 0 {
+		t.Errorf("expected no SQL, got %v", e.GetSQL())
+	}
+	if err := e.CreateTable(ctx, "posts", func(t *schema.Table) { t.ID() }); err != nil {
+		t.Errorf("expected a valid table to be created, got %v", err)
+	}
+}
+
 func TestNewTransactionExecutor(t *testing.T) {
 	drv := newMockDriver("postgres")
 	tx := &mockTransaction{}
@@ -725,3 +990,355 @@ func TestNewTransactionExecutor(t *testing.T) {
 		t.Error("expected dryRun to be false")
 	}
 }
+
+// mockLockingDriver 模拟支持迁移锁的驱动
+type mockLockingDriver struct {
+	*mockDriver
+	lockErr     error
+	locks       int
+	unlocks     int
+	forced      int
+	lockName    string
+	lockTimeout time.Duration
+}
+
+func (d *mockLockingDriver) Lock(ctx context.Context, name string, timeout time.Duration) error {
+	if d.lockErr != nil {
+		return d.lockErr
+	}
+	d.locks++
+	d.lockName = name
+	d.lockTimeout = timeout
+	return nil
+}
+func (d *mockLockingDriver) Unlock(ctx context.Context, name string) error {
+	d.unlocks++
+	return nil
+}
+func (d *mockLockingDriver) ForceUnlock(ctx context.Context, name string) error {
+	d.forced++
+	return nil
+}
+
+// 测试目标：验证 Up/Down/Reset/Refresh 在迁移锁内执行，且每次操作只加锁一次
+func TestMigrator_Locking(t *testing.T) {
+	ctx := context.Background()
+	noop := func(ctx context.Context, e *Executor) error { return nil }
+	newMigrator := func(drv *mockLockingDriver) *Migrator {
+		m := NewMigrator(drv, "./migrations", "migrations")
+		m.Register(Migration{Name: "001_create_users", Up: noop, Down: noop})
+		return m
+	}
+
+	t.Run("Up acquires and releases the lock", func(t *testing.T) {
+		drv := &mockLockingDriver{mockDriver: newMockDriver("mysql")}
+		m := newMigrator(drv)
+		m.SetLockTimeout(5 * time.Second)
+
+		if _, err := m.Up(ctx, 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if drv.locks != 1 || drv.unlocks != 1 {
+			t.Errorf("expected 1 lock and 1 unlock, got %d and %d", drv.locks, drv.unlocks)
+		}
+		if drv.lockName != "migrations" {
+			t.Errorf("expected lock name 'migrations', got %q", drv.lockName)
+		}
+		if drv.lockTimeout != 5*time.Second {
+			t.Errorf("expected lock timeout 5s, got %v", drv.lockTimeout)
+		}
+	})
+
+	t.Run("Refresh locks once", func(t *testing.T) {
+		drv := &mockLockingDriver{mockDriver: newMockDriver("mysql")}
+		m := newMigrator(drv)
+		if _, err := m.Up(ctx, 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		rolledBack, executed, err := m.Refresh(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(rolledBack) != 1 || len(executed) != 1 {
+			t.Errorf("expected 1 rolled back and 1 executed, got %v and %v", rolledBack, executed)
+		}
+		if drv.locks != 2 || drv.unlocks != 2 {
+			t.Errorf("expected 2 locks and 2 unlocks, got %d and %d", drv.locks, drv.unlocks)
+		}
+	})
+
+	t.Run("Reset and Down acquire the lock", func(t *testing.T) {
+		drv := &mockLockingDriver{mockDriver: newMockDriver("mysql")}
+		m := newMigrator(drv)
+		if _, err := m.Up(ctx, 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := m.Reset(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := m.Down(ctx, 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if drv.locks != 3 || drv.unlocks != 3 {
+			t.Errorf("expected 3 locks and 3 unlocks, got %d and %d", drv.locks, drv.unlocks)
+		}
+	})
+
+	t.Run("lock error aborts the run", func(t *testing.T) {
+		drv := &mockLockingDriver{mockDriver: newMockDriver("mysql"), lockErr: driver.ErrLockTimeout}
+		m := newMigrator(drv)
+
+		_, err := m.Up(ctx, 0)
+		if !errors.Is(err, driver.ErrLockTimeout) {
+			t.Fatalf("expected ErrLockTimeout, got %v", err)
+		}
+		if len(drv.executedMigrations) != 0 {
+			t.Error("expected no migrations to run without the lock")
+		}
+	})
+
+	t.Run("locking disabled", func(t *testing.T) {
+		drv := &mockLockingDriver{mockDriver: newMockDriver("mysql")}
+		m := newMigrator(drv)
+		m.SetLocking(false)
+
+		if _, err := m.Up(ctx, 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if drv.locks != 0 {
+			t.Errorf("expected no lock, got %d", drv.locks)
+		}
+	})
+
+	t.Run("dry run skips the lock", func(t *testing.T) {
+		drv := &mockLockingDriver{mockDriver: newMockDriver("mysql")}
+		m := newMigrator(drv)
+		m.SetDryRun(true)
+
+		if _, err := m.Up(ctx, 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if drv.locks != 0 {
+			t.Errorf("expected no lock, got %d", drv.locks)
+		}
+	})
+}
+
+// 测试目标：验证 Unlock 强制释放锁，驱动不支持锁时返回错误
+func TestMigrator_Unlock(t *testing.T) {
+	ctx := context.Background()
+
+	drv := &mockLockingDriver{mockDriver: newMockDriver("mysql")}
+	if err := NewMigrator(drv, "./migrations", "migrations").Unlock(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if drv.forced != 1 {
+		t.Errorf("expected ForceUnlock to be called once, got %d", drv.forced)
+	}
+
+	if err := NewMigrator(newMockDriver("mysql"), "./migrations", "migrations").Unlock(ctx); err == nil {
+		t.Error("expected error for a driver without lock support")
+	}
+}
+
+// 测试目标：验证 dry run 模式按迁移返回收集到的 SQL，Refresh 的 dry run 会重新应用已回滚的迁移
+func TestMigrator_DryRunResults(t *testing.T) {
+	ctx := context.Background()
+	newMigrator := func() (*mockDriver, *Migrator) {
+		drv := newMockDriver("postgres")
+		drv.executedMigrations = []driver.MigrationRecord{
+			{Migration: "001_create_users", Batch: 1},
+		}
+		drv.lastBatch = 1
+
+		m := NewMigrator(drv, "", "migrations")
+		m.Register(Migration{
+			Name: "001_create_users",
+			Up:   func(ctx context.Context, e *Executor) error { return e.Raw(ctx, "CREATE TABLE users (id INT)") },
+			Down: func(ctx context.Context, e *Executor) error { return e.DropTable(ctx, "users") },
+		})
+		m.Register(Migration{
+			Name: "002_create_posts",
+			Up: func(ctx context.Context, e *Executor) error {
+				if err := e.Raw(ctx, "CREATE TABLE posts (id INT)"); err != nil {
+					return err
+				}
+				return e.Raw(ctx, "CREATE INDEX posts_id ON posts (id)")
+			},
+			Down: func(ctx context.Context, e *Executor) error { return e.DropTable(ctx, "posts") },
+		})
+		m.SetDryRun(true)
+		return drv, m
+	}
+
+	t.Run("Up", func(t *testing.T) {
+		_, m := newMigrator()
+		if _, err := m.Up(ctx, 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		results := m.DryRunResults()
+		if len(results) != 1 {
+			t.Fatalf("expected 1 result, got %d", len(results))
+		}
+		if results[0].Migration != "002_create_posts" || results[0].Rollback {
+			t.Errorf("unexpected result %+v", results[0])
+		}
+		expected := []string{"CREATE TABLE posts (id INT)", "CREATE INDEX posts_id ON posts (id)"}
+		if len(results[0].SQL) != len(expected) {
+			t.Fatalf("expected %d statements, got %v", len(expected), results[0].SQL)
+		}
+		for i, sql := range expected {
+			if results[0].SQL[i] != sql {
+				t.Errorf("statement %d: expected %q, got %q", i, sql, results[0].SQL[i])
+			}
+		}
+	})
+
+	t.Run("Down", func(t *testing.T) {
+		drv, m := newMigrator()
+		if _, err := m.Down(ctx, 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		results := m.DryRunResults()
+		if len(results) != 1 || !results[0].Rollback || results[0].SQL[0] != "DROP TABLE users" {
+			t.Errorf("unexpected results %+v", results)
+		}
+		if len(drv.executedMigrations) != 1 {
+			t.Error("expected migration record to be kept in dry run mode")
+		}
+	})
+
+	t.Run("Refresh", func(t *testing.T) {
+		drv, m := newMigrator()
+		rolledBack, executed, err := m.Refresh(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(rolledBack) != 1 || len(executed) != 2 {
+			t.Fatalf("expected 1 rolled back and 2 executed, got %v and %v", rolledBack, executed)
+		}
+
+		results := m.DryRunResults()
+		names := []string{"001_create_users", "001_create_users", "002_create_posts"}
+		if len(results) != len(names) {
+			t.Fatalf("expected %d results, got %d", len(names), len(results))
+		}
+		for i, name := range names {
+			if results[i].Migration != name {
+				t.Errorf("result %d: expected %s, got %s", i, name, results[i].Migration)
+			}
+		}
+		if !results[0].Rollback || results[1].Rollback {
+			t.Error("expected the rollback to come first")
+		}
+		if len(drv.executedMigrations) != 1 {
+			t.Error("expected migration records to be unchanged in dry run mode")
+		}
+	})
+}
+
+// mockInspectingDriver 模拟支持读取表结构的驱动，记录读取使用的 Queryer
+type mockInspectingDriver struct {
+	*mockDriver
+	queryers []driver.Queryer
+}
+
+func (d *mockInspectingDriver) GetTables(ctx context.Context, q driver.Queryer) ([]string, error) {
+	d.queryers = append(d.queryers, q)
+	return []string{"users"}, nil
+}
+func (d *mockInspectingDriver) GetColumns(ctx context.Context, q driver.Queryer, table string) ([]*schema.Column, error) {
+	d.queryers = append(d.queryers, q)
+	if table != "users" {
+		return nil, nil
+	}
+	return []*schema.Column{{Name: "id"}, {Name: "email"}}, nil
+}
+func (d *mockInspectingDriver) GetIndexes(ctx context.Context, q driver.Queryer, table string) ([]*schema.Index, error) {
+	d.queryers = append(d.queryers, q)
+	return []*schema.Index{schema.NewIndex("email").Named("users_email_unique").Unique()}, nil
+}
+func (d *mockInspectingDriver) GetForeignKeys(ctx context.Context, q driver.Queryer, table string) ([]*schema.ForeignKey, error) {
+	d.queryers = append(d.queryers, q)
+	return []*schema.ForeignKey{schema.NewForeignKey("team_id").Named("users_team_id_fk").References("teams", "id")}, nil
+}
+
+// 测试目标：验证 HasColumn/GetColumnListing/HasIndex/HasForeignKey 的结果，且在事务执行器中读取走事务
+func TestExecutor_Inspection(t *testing.T) {
+	ctx := context.Background()
+	drv := &mockInspectingDriver{mockDriver: newMockDriver("postgres")}
+	executor := NewExecutor(drv, false)
+
+	columns, err := executor.GetColumnListing(ctx, "users")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(columns) != 2 || columns[0] != "id" || columns[1] != "email" {
+		t.Errorf("expected [id email], got %v", columns)
+	}
+
+	tests := []struct {
+		name     string
+		check    func() (bool, error)
+		expected bool
+	}{
+		{"列存在", func() (bool, error) { return executor.HasColumn(ctx, "users", "email") }, true},
+		{"列名不区分大小写", func() (bool, error) { return executor.HasColumn(ctx, "users", "EMAIL") }, true},
+		{"列不存在", func() (bool, error) { return executor.HasColumn(ctx, "users", "name") }, false},
+		{"表不存在", func() (bool, error) { return executor.HasColumn(ctx, "posts", "id") }, false},
+		{"索引存在", func() (bool, error) { return executor.HasIndex(ctx, "users", "users_email_unique") }, true},
+		{"索引不存在", func() (bool, error) { return executor.HasIndex(ctx, "users", "users_name_index") }, false},
+		{"外键存在", func() (bool, error) { return executor.HasForeignKey(ctx, "users", "users_team_id_fk") }, true},
+		{"外键不存在", func() (bool, error) { return executor.HasForeignKey(ctx, "users", "users_role_id_fk") }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.check()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+	for _, q := range drv.queryers {
+		if q != driver.Queryer(drv) {
+			t.Fatal("expected reads outside a transaction to use the driver")
+		}
+	}
+
+	t.Run("事务中读取走事务", func(t *testing.T) {
+		drv.queryers = nil
+		tx := &mockTransaction{}
+		txExecutor := NewTransactionExecutor(drv, tx)
+
+		for _, check := range []func() (bool, error){
+			func() (bool, error) { return txExecutor.HasColumn(ctx, "users", "id") },
+			func() (bool, error) { return txExecutor.HasIndex(ctx, "users", "users_email_unique") },
+			func() (bool, error) { return txExecutor.HasForeignKey(ctx, "users", "users_team_id_fk") },
+		} {
+			if _, err := check(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if len(drv.queryers) != 3 {
+			t.Fatalf("expected 3 reads, got %d", len(drv.queryers))
+		}
+		for _, q := range drv.queryers {
+			if q != driver.Queryer(tx) {
+				t.Error("expected reads to run on the transaction")
+			}
+		}
+	})
+
+	t.Run("驱动不支持读取表结构", func(t *testing.T) {
+		executor := NewExecutor(newMockDriver("mysql"), false)
+		if _, err := executor.HasColumn(ctx, "users", "id"); err == nil {
+			t.Error("expected an error for a driver without driver.Inspector")
+		}
+	})
+}
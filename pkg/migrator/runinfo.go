@@ -0,0 +1,50 @@
+package migrator
+
+import (
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/flyits/migro/pkg/driver"
+)
+
+// RunInfo describes who ran the migrations. It is recorded in the
+// migrations table with every applied migration.
+type RunInfo struct {
+	Host    string // host name of the machine
+	Version string // migro version
+	User    string // operating system user
+	Note    string // optional note of the operator
+}
+
+// NewRunInfo returns the run information of the current process: the host
+// name and the operating system user, with the given migro version
+func NewRunInfo(version string) RunInfo {
+	info := RunInfo{Version: version}
+	info.Host, _ = os.Hostname()
+	if u, err := user.Current(); err == nil {
+		info.User = u.Username
+	} else {
+		info.User = os.Getenv("USER")
+	}
+	return info
+}
+
+// SetRunInfo sets the run information recorded with the applied migrations
+func (m *Migrator) SetRunInfo(info RunInfo) {
+	m.runInfo = info
+}
+
+// newRecord returns the record of an applied migration
+func (m *Migrator) newRecord(migration Migration, batch int, checksum string, duration time.Duration) driver.MigrationRecord {
+	return driver.MigrationRecord{
+		Migration: migration.Name,
+		Batch:     batch,
+		Checksum:  checksum,
+		Duration:  duration,
+		Host:      m.runInfo.Host,
+		Version:   m.runInfo.Version,
+		User:      m.runInfo.User,
+		Note:      m.runInfo.Note,
+	}
+}
@@ -0,0 +1,54 @@
+package migrator
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// 测试目标：验证执行迁移时记录耗时、主机、版本、用户和备注
+func TestMigrator_RunInfo(t *testing.T) {
+	drv := newMockDriver("mysql")
+	m := NewMigrator(drv, "", "migrations")
+	m.SetRunInfo(RunInfo{Host: "ci-1", Version: "v0.1.0", User: "deploy", Note: "release 42"})
+	m.Register(Migration{
+		Name: "001_create_users",
+		Up: func(ctx context.Context, e *Executor) error {
+			time.Sleep(2 * time.Millisecond)
+			return nil
+		},
+	})
+
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	if len(drv.executedMigrations) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(drv.executedMigrations))
+	}
+	record := drv.executedMigrations[0]
+	if record.Host != "ci-1" || record.Version != "v0.1.0" || record.User != "deploy" || record.Note != "release 42" {
+		t.Errorf("unexpected metadata %+v", record)
+	}
+	if record.Duration < 2*time.Millisecond {
+		t.Errorf("expected the duration to be recorded, got %v", record.Duration)
+	}
+
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if statuses[0].Host != "ci-1" || statuses[0].Note != "release 42" || statuses[0].Duration != record.Duration {
+		t.Errorf("expected the metadata in the status, got %+v", statuses[0])
+	}
+}
+
+func TestNewRunInfo(t *testing.T) {
+	info := NewRunInfo("v1.2.3")
+	if info.Version != "v1.2.3" {
+		t.Errorf("expected version v1.2.3, got %q", info.Version)
+	}
+	if info.Host == "" {
+		t.Error("expected the host name to be set")
+	}
+}
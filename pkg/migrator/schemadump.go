@@ -0,0 +1,217 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/flyits/migro/pkg/driver"
+)
+
+// SchemaDumpFile returns the path of the schema dump of a driver in dir
+func SchemaDumpFile(dir, driverName string) string {
+	return filepath.Join(dir, driverName+"-schema.sql")
+}
+
+// SetSchemaDump sets the schema dump that Up loads before running
+// migrations when the migrations table is empty
+func (m *Migrator) SetSchemaDump(path string) {
+	m.schemaDump = path
+}
+
+// SchemaDumpLoaded reports whether Up loaded the schema dump
+func (m *Migrator) SchemaDumpLoaded() bool {
+	return m.dumpLoaded
+}
+
+// DumpSchema returns a SQL script recreating the current schema: the DDL of
+// every table except the migrations table, followed by the records of the
+// migrations table. It also returns the names of the recorded migrations.
+func (m *Migrator) DumpSchema(ctx context.Context) (string, []string, error) {
+	dumper, ok := m.driver.(driver.SchemaDumper)
+	if !ok {
+		return "", nil, fmt.Errorf("driver %s does not support schema dumps", m.driver.Name())
+	}
+
+	if err := m.driver.CreateMigrationsTable(ctx, m.tableName); err != nil {
+		return "", nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	statements, err := dumper.DumpSchema(ctx, m.driver, m.tableName)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to dump schema: %w", err)
+	}
+	executed, err := m.driver.GetExecutedMigrations(ctx, m.tableName)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get executed migrations: %w", err)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "-- migro schema dump for %s\n", m.driver.Name())
+	sb.WriteString("-- Loaded by 'migro up' when the migrations table is empty.\n\n")
+	for _, stmt := range statements {
+		sb.WriteString(strings.TrimSpace(stmt))
+		sb.WriteString(";\n\n")
+	}
+
+	names := make([]string, len(executed))
+	prefix := m.insertMigrationPrefix()
+	for i, record := range executed {
+		names[i] = record.Migration
+		values := make([]string, 0, 8)
+		for _, arg := range record.InsertArgs() {
+			if s, ok := arg.(string); ok {
+				values = append(values, quoteLiteral(s, m.driver.Name()))
+			} else {
+				values = append(values, fmt.Sprint(arg))
+			}
+		}
+		fmt.Fprintf(&sb, "%sVALUES (%s);\n", prefix, strings.Join(values, ", "))
+	}
+
+	return sb.String(), names, nil
+}
+
+// loadSchemaDump runs the statements of the schema dump, in a transaction
+// when the database supports transactional DDL. It returns the names of the
+// migrations the dump records, or nil when there is no dump.
+func (m *Migrator) loadSchemaDump(ctx context.Context) ([]string, error) {
+	if m.schemaDump == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(m.schemaDump)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read schema dump: %w", err)
+	}
+
+	statements := SplitStatements(string(content), m.driver.Name())
+	names := m.dumpedMigrations(statements)
+	m.dumpLoaded = true
+
+	if m.dryRun {
+		result := DryRunResult{Migration: filepath.Base(m.schemaDump), SQL: statements}
+		if m.supportsTransactionalDDL() {
+			result.Transaction = MigrationTransaction
+		}
+		m.dryRunResults = append(m.dryRunResults, result)
+		return names, nil
+	}
+
+	if !m.supportsTransactionalDDL() {
+		for _, stmt := range statements {
+			if _, err := m.driver.Exec(ctx, stmt); err != nil {
+				return nil, fmt.Errorf("failed to load schema dump: %w", err)
+			}
+		}
+		return names, nil
+	}
+
+	tx, err := m.driver.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction for schema dump: %w", err)
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				return nil, fmt.Errorf("failed to load schema dump: %w (rollback also failed: %v)", err, rbErr)
+			}
+			return nil, fmt.Errorf("failed to load schema dump (rolled back): %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit schema dump: %w", err)
+	}
+	return names, nil
+}
+
+// dumpedMigrations returns the migration names recorded by the INSERT
+// statements of a schema dump. Dumps written by older versions list fewer
+// columns, so only the table is matched.
+func (m *Migrator) dumpedMigrations(statements []string) []string {
+	into := m.insertMigrationPrefix()
+	if i := strings.Index(into, "("); i >= 0 {
+		into = into[:i]
+	}
+	into = strings.TrimSpace(into)
+
+	var names []string
+	for _, stmt := range statements {
+		stmt = stripLeadingComments(stmt)
+		if !strings.HasPrefix(stmt, into) {
+			continue
+		}
+		start := strings.Index(stmt, "VALUES ('")
+		if start < 0 {
+			continue
+		}
+		if name, ok := readLiteral(stmt[start+len("VALUES ("):], m.driver.Name()); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// insertMigrationPrefix returns the INSERT INTO clause of the migrations
+// table up to its VALUES keyword
+func (m *Migrator) insertMigrationPrefix() string {
+	sql := m.driver.Grammar().CompileInsertMigration(m.tableName)
+	if i := strings.Index(sql, "VALUES"); i >= 0 {
+		return sql[:i]
+	}
+	return sql + " "
+}
+
+// stripLeadingComments removes the comment lines preceding a statement
+func stripLeadingComments(stmt string) string {
+	for {
+		stmt = strings.TrimSpace(stmt)
+		if !strings.HasPrefix(stmt, "--") {
+			return stmt
+		}
+		end := strings.IndexByte(stmt, '\n')
+		if end < 0 {
+			return ""
+		}
+		stmt = stmt[end+1:]
+	}
+}
+
+// quoteLiteral quotes a string literal. MySQL treats backslashes as escape
+// characters unless NO_BACKSLASH_ESCAPES is set, so they are escaped too.
+func quoteLiteral(s, dialect string) string {
+	if dialect == "mysql" {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// readLiteral reads the string literal s starts with, as quoted by
+// quoteLiteral. It reports false when s does not start with a complete literal.
+func readLiteral(s, dialect string) (string, bool) {
+	if !strings.HasPrefix(s, "'") {
+		return "", false
+	}
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && dialect == "mysql" && i+1 < len(s):
+			i++
+			sb.WriteByte(s[i])
+		case c == '\'':
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				sb.WriteByte('\'')
+				continue
+			}
+			return sb.String(), true
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,185 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flyits/migro/pkg/driver"
+)
+
+// 测试目标：验证 schema dump 的生成，以及迁移表为空时 Up 先加载 dump 再执行更新的迁移
+
+// mockDumpingDriver 模拟支持导出表结构的驱动，记录执行的语句
+type mockDumpingDriver struct {
+	*mockDriver
+	statements []string
+	executed   []string
+}
+
+func (d *mockDumpingDriver) DumpSchema(ctx context.Context, q driver.Queryer, exclude ...string) ([]string, error) {
+	return d.statements, nil
+}
+func (d *mockDumpingDriver) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	d.executed = append(d.executed, query)
+	return nil, nil
+}
+
+func TestMigrator_DumpSchema(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("驱动不支持导出", func(t *testing.T) {
+		m := NewMigrator(newMockDriver("mysql"), "", "migrations")
+		if _, _, err := m.DumpSchema(ctx); err == nil {
+			t.Error("expected an error for a driver without schema dump support")
+		}
+	})
+
+	t.Run("DDL 后跟迁移记录", func(t *testing.T) {
+		drv := &mockDumpingDriver{
+			mockDriver: newMockDriver("mysql"),
+			statements: []string{"CREATE TABLE users (id INT)", "CREATE INDEX users_id ON users (id)"},
+		}
+		drv.executedMigrations = []driver.MigrationRecord{
+			{Migration: "001_create_users", Batch: 1, Checksum: "abc", Duration: 1500 * time.Millisecond, Host: "ci-1", Version: "v0.1.0", User: "deploy", Note: "release 42"},
+			{Migration: "002_it's_quoted", Batch: 2, Note: `C:\temp\`},
+		}
+
+		m := NewMigrator(drv, "", "migrations")
+		content, names, err := m.DumpSchema(ctx)
+		if err != nil {
+			t.Fatalf("DumpSchema failed: %v", err)
+		}
+		if len(names) != 2 || names[0] != "001_create_users" || names[1] != "002_it's_quoted" {
+			t.Errorf("unexpected dumped migrations: %v", names)
+		}
+		for _, want := range []string{
+			"CREATE TABLE users (id INT);\n",
+			"CREATE INDEX users_id ON users (id);\n",
+			"INSERT INTO migrations VALUES ('001_create_users', 1, 'abc', 1500, 'ci-1', 'v0.1.0', 'deploy', 'release 42');\n",
+			`INSERT INTO migrations VALUES ('002_it''s_quoted', 2, '', 0, '', '', '', 'C:\\temp\\');` + "\n",
+		} {
+			if !strings.Contains(content, want) {
+				t.Errorf("expected dump to contain %q, got:\n%s", want, content)
+			}
+		}
+
+		statements := SplitStatements(content, "mysql")
+		if got := m.dumpedMigrations(statements); len(got) != 2 || got[1] != "002_it's_quoted" {
+			t.Errorf("expected the records to be read back, got %v", got)
+		}
+
+		// Dumps of older versions list fewer columns
+		legacy := []string{"INSERT INTO migrations (migration, batch, checksum) VALUES ('001_legacy', 1, '')"}
+		if got := m.dumpedMigrations(legacy); len(got) != 1 || got[0] != "001_legacy" {
+			t.Errorf("expected the legacy record to be read back, got %v", got)
+		}
+	})
+}
+
+func TestMigrator_UpLoadsSchemaDump(t *testing.T) {
+	ctx := context.Background()
+	dump := filepath.Join(t.TempDir(), "mysql-schema.sql")
+	content := "-- migro schema dump for mysql\n\n" +
+		"CREATE TABLE users (id INT);\n\n" +
+		"INSERT INTO migrations VALUES ('001_create_users', 1, '');\n"
+	if err := os.WriteFile(dump, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write dump: %v", err)
+	}
+
+	newMigrator := func(drv *mockDumpingDriver) *Migrator {
+		m := NewMigrator(drv, "", "migrations")
+		m.SetSchemaDump(dump)
+		for _, name := range []string{"001_create_users", "002_create_posts"} {
+			m.Register(Migration{
+				Name: name,
+				Up:   func(ctx context.Context, e *Executor) error { return e.Raw(ctx, "CREATE TABLE "+name) },
+				Down: func(ctx context.Context, e *Executor) error { return nil },
+			})
+		}
+		return m
+	}
+
+	t.Run("迁移表为空时加载", func(t *testing.T) {
+		drv := &mockDumpingDriver{mockDriver: newMockDriver("mysql")}
+		m := newMigrator(drv)
+
+		executed, err := m.Up(ctx, 0)
+		if err != nil {
+			t.Fatalf("Up failed: %v", err)
+		}
+		if !m.SchemaDumpLoaded() {
+			t.Error("expected the schema dump to be loaded")
+		}
+		if len(drv.executed) < 2 || !strings.HasSuffix(drv.executed[0], "CREATE TABLE users (id INT)") {
+			t.Errorf("expected the dump statements to run first, got %v", drv.executed)
+		}
+		if len(executed) != 1 || executed[0] != "002_create_posts" {
+			t.Errorf("expected only 002_create_posts to run, got %v", executed)
+		}
+	})
+
+	t.Run("已有迁移记录时不加载", func(t *testing.T) {
+		drv := &mockDumpingDriver{mockDriver: newMockDriver("mysql")}
+		drv.executedMigrations = []driver.MigrationRecord{{Migration: "001_create_users", Batch: 1}}
+		drv.lastBatch = 1
+		m := newMigrator(drv)
+
+		if _, err := m.Up(ctx, 0); err != nil {
+			t.Fatalf("Up failed: %v", err)
+		}
+		if m.SchemaDumpLoaded() {
+			t.Error("expected the schema dump not to be loaded")
+		}
+	})
+
+	t.Run("dry run 输出 dump 语句", func(t *testing.T) {
+		drv := &mockDumpingDriver{mockDriver: newMockDriver("mysql")}
+		m := newMigrator(drv)
+		m.SetDryRun(true)
+
+		executed, err := m.Up(ctx, 0)
+		if err != nil {
+			t.Fatalf("Up failed: %v", err)
+		}
+		if len(drv.executed) != 0 {
+			t.Errorf("expected nothing to be executed in dry run mode, got %v", drv.executed)
+		}
+		results := m.DryRunResults()
+		if len(results) != 2 || results[0].Migration != "mysql-schema.sql" || len(results[0].SQL) != 2 {
+			t.Fatalf("expected the dump followed by 002_create_posts, got %+v", results)
+		}
+		if len(executed) != 1 || executed[0] != "002_create_posts" {
+			t.Errorf("expected only 002_create_posts, got %v", executed)
+		}
+	})
+}
+
+// 测试目标：验证字符串字面量按方言转义并能读回
+func TestQuoteLiteral(t *testing.T) {
+	tests := []struct {
+		dialect  string
+		value    string
+		expected string
+	}{
+		{"mysql", `it's C:\dir\`, `'it''s C:\\dir\\'`},
+		{"postgres", `it's C:\dir\`, `'it''s C:\dir\'`},
+		{"sqlite", `it's C:\dir\`, `'it''s C:\dir\'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			got := quoteLiteral(tt.value, tt.dialect)
+			if got != tt.expected {
+				t.Errorf("quoteLiteral() = %s, want %s", got, tt.expected)
+			}
+			if back, ok := readLiteral(got+", 1)", tt.dialect); !ok || back != tt.value {
+				t.Errorf("readLiteral() = %q, %v, want %q", back, ok, tt.value)
+			}
+		})
+	}
+}
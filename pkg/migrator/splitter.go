@@ -0,0 +1,233 @@
+package migrator
+
+import (
+	"regexp"
+	"strings"
+)
+
+// sqliteTrigger matches the beginning of a SQLite CREATE TRIGGER statement,
+// whose body contains semicolons that do not terminate the statement
+var sqliteTrigger = regexp.MustCompile(`(?is)^\s*CREATE\s+(TEMP\s+|TEMPORARY\s+)?TRIGGER\b`)
+
+// delimiterCommand matches the MySQL client DELIMITER command
+var delimiterCommand = regexp.MustCompile(`(?i)^DELIMITER\s+(\S+)\s*$`)
+
+// SplitStatements splits a SQL script into individual statements for the given
+// dialect ("mysql", "postgres" or "sqlite"). Delimiters inside quoted strings,
+// quoted identifiers and comments are ignored. PostgreSQL dollar-quoted and
+// escape (E'...') strings and MySQL DELIMITER blocks are supported. Statements
+// that only contain comments are dropped.
+func SplitStatements(script, dialect string) []string {
+	var statements []string
+	var current strings.Builder
+	hasCode := false
+	delimiter := ";"
+
+	flush := func() {
+		stmt := strings.TrimSpace(current.String())
+		if hasCode && stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+		hasCode = false
+	}
+
+	i := 0
+	atLineStart := true
+	for i < len(script) {
+		// MySQL DELIMITER command (client-side, only valid at the start of a line)
+		if dialect == "mysql" && atLineStart && !hasCode {
+			end := strings.IndexByte(script[i:], '\n')
+			line := script[i:]
+			if end >= 0 {
+				line = script[i : i+end]
+			}
+			if m := delimiterCommand.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+				flush()
+				delimiter = m[1]
+				if end < 0 {
+					break
+				}
+				i += end + 1
+				continue
+			}
+		}
+
+		c := script[i]
+		atLineStart = false
+
+		switch {
+		case strings.HasPrefix(script[i:], delimiter):
+			if dialect == "sqlite" && delimiter == ";" && !triggerComplete(current.String()) {
+				current.WriteByte(c)
+				i++
+				continue
+			}
+			flush()
+			i += len(delimiter)
+			continue
+
+		case c == '-' && strings.HasPrefix(script[i:], "--"), dialect == "mysql" && c == '#':
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			current.WriteString(script[i : i+end])
+			i += end
+			continue
+
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i
+			} else {
+				end += 4
+			}
+			current.WriteString(script[i : i+end])
+			i += end
+			continue
+
+		case dialect == "postgres" && (c == 'E' || c == 'e') && strings.HasPrefix(script[i+1:], "'") && (i == 0 || !isIdentByte(script[i-1])):
+			// PostgreSQL escape string constant, E'...' honours backslash escapes
+			end := quotedEnd(script, i+1, '\'', true)
+			current.WriteString(script[i:end])
+			hasCode = true
+			i = end
+			continue
+
+		case c == '\'' || c == '"' || (dialect == "mysql" && c == '`'):
+			end := quotedEnd(script, i, c, dialect == "mysql" && c != '`')
+			current.WriteString(script[i:end])
+			hasCode = true
+			i = end
+			continue
+
+		case c == '$' && dialect == "postgres":
+			if tag := dollarTag(script[i:]); tag != "" {
+				end := strings.Index(script[i+len(tag):], tag)
+				if end < 0 {
+					end = len(script)
+				} else {
+					end = i + len(tag) + end + len(tag)
+				}
+				current.WriteString(script[i:end])
+				hasCode = true
+				i = end
+				continue
+			}
+		}
+
+		if c == '\n' {
+			atLineStart = true
+		} else if c != ' ' && c != '\t' && c != '\r' {
+			hasCode = true
+		}
+		current.WriteByte(c)
+		i++
+	}
+
+	flush()
+	return statements
+}
+
+// quotedEnd returns the index just past the quoted section starting at start.
+// A doubled quote character escapes itself; backslash escapes are honoured
+// when allowed.
+func quotedEnd(script string, start int, quote byte, backslash bool) int {
+	i := start + 1
+	for i < len(script) {
+		c := script[i]
+		if backslash && c == '\\' {
+			i += 2
+			continue
+		}
+		if c == quote {
+			if i+1 < len(script) && script[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1
+		}
+		i++
+	}
+	return len(script)
+}
+
+// dollarTag returns the PostgreSQL dollar-quote tag ($$ or $tag$) at the
+// beginning of s, or an empty string if s does not start with one
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == '$' {
+			return s[:i+1]
+		}
+		isIdent := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 1 && c >= '0' && c <= '9')
+		if !isIdent {
+			return ""
+		}
+	}
+	return ""
+}
+
+// isIdentByte reports whether c can be part of an unquoted identifier
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// triggerComplete reports whether a SQLite statement can be terminated at the
+// next semicolon. CREATE TRIGGER statements are only complete after the END
+// closing their BEGIN; CASE expressions of the body have their own END.
+func triggerComplete(stmt string) bool {
+	if !sqliteTrigger.MatchString(stmt) {
+		return true
+	}
+
+	depth := 0
+	inBody := false
+	last := ""
+	for i := 0; i < len(stmt); {
+		c := stmt[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = quotedEnd(stmt, i, c, false)
+			last = ""
+		case c == '[':
+			i = quotedEnd(stmt, i, ']', false)
+			last = ""
+		case c == '-' && strings.HasPrefix(stmt[i:], "--"):
+			end := strings.IndexByte(stmt[i:], '\n')
+			if end < 0 {
+				return false
+			}
+			i += end
+		case c == '/' && strings.HasPrefix(stmt[i:], "/*"):
+			end := strings.Index(stmt[i+2:], "*/")
+			if end < 0 {
+				return false
+			}
+			i += end + 4
+		case isIdentByte(c):
+			end := i
+			for end < len(stmt) && isIdentByte(stmt[end]) {
+				end++
+			}
+			last = strings.ToUpper(stmt[i:end])
+			switch last {
+			case "BEGIN":
+				inBody = true
+				depth++
+			case "CASE":
+				depth++
+			case "END":
+				depth--
+			}
+			i = end
+		default:
+			if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+				last = ""
+			}
+			i++
+		}
+	}
+	return inBody && depth == 0 && last == "END"
+}
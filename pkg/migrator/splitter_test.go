@@ -0,0 +1,144 @@
+package migrator
+
+import (
+	"reflect"
+	"testing"
+)
+
+// 测试目标需求: SQL 脚本按语句拆分
+// 覆盖: 引号字符串、注释、PostgreSQL 美元引用、MySQL DELIMITER、SQLite 触发器
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  string
+		script   string
+		expected []string
+	}{
+		{
+			name:     "simple statements",
+			dialect:  "mysql",
+			script:   "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n",
+			expected: []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"},
+		},
+		{
+			name:     "missing trailing semicolon",
+			dialect:  "sqlite",
+			script:   "INSERT INTO a VALUES (1);\nINSERT INTO a VALUES (2)",
+			expected: []string{"INSERT INTO a VALUES (1)", "INSERT INTO a VALUES (2)"},
+		},
+		{
+			name:     "semicolon in single quoted string",
+			dialect:  "postgres",
+			script:   "INSERT INTO a VALUES ('x;y');INSERT INTO a VALUES ('it''s;');",
+			expected: []string{"INSERT INTO a VALUES ('x;y')", "INSERT INTO a VALUES ('it''s;')"},
+		},
+		{
+			name:     "mysql backslash escape",
+			dialect:  "mysql",
+			script:   `INSERT INTO a VALUES ('x\';y'); SELECT 1;`,
+			expected: []string{`INSERT INTO a VALUES ('x\';y')`, "SELECT 1"},
+		},
+		{
+			name:     "quoted identifiers",
+			dialect:  "mysql",
+			script:   "SELECT `a;b` FROM t; SELECT \"c;d\" FROM t;",
+			expected: []string{"SELECT `a;b` FROM t", "SELECT \"c;d\" FROM t"},
+		},
+		{
+			name:     "comments are ignored",
+			dialect:  "postgres",
+			script:   "-- leading comment; with semicolon\nSELECT 1; /* block; comment */\nSELECT 2;\n-- trailing comment\n",
+			expected: []string{"-- leading comment; with semicolon\nSELECT 1", "/* block; comment */\nSELECT 2"},
+		},
+		{
+			name:    "postgres dollar quoting",
+			dialect: "postgres",
+			script: `CREATE FUNCTION f() RETURNS trigger AS $$
+BEGIN
+  NEW.updated_at = now();
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+CREATE FUNCTION g() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql;`,
+			expected: []string{
+				"CREATE FUNCTION f() RETURNS trigger AS $$\nBEGIN\n  NEW.updated_at = now();\n  RETURN NEW;\nEND;\n$$ LANGUAGE plpgsql",
+				"CREATE FUNCTION g() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql",
+			},
+		},
+		{
+			name:     "postgres positional parameter is not dollar quoting",
+			dialect:  "postgres",
+			script:   "PREPARE p AS SELECT $1; SELECT 2;",
+			expected: []string{"PREPARE p AS SELECT $1", "SELECT 2"},
+		},
+		{
+			name:     "postgres escape strings",
+			dialect:  "postgres",
+			script:   `INSERT INTO t VALUES (E'it\'s; x'); SELECT e'\\'; SELECT 'a\'; SELECT name'x';`,
+			expected: []string{`INSERT INTO t VALUES (E'it\'s; x')`, `SELECT e'\\'`, `SELECT 'a\'`, `SELECT name'x'`},
+		},
+		{
+			name:    "mysql delimiter blocks",
+			dialect: "mysql",
+			script: `CREATE TABLE a (id INT);
+DELIMITER //
+CREATE PROCEDURE p()
+BEGIN
+  SELECT 1;
+  SELECT 2;
+END //
+DELIMITER ;
+SELECT 3;`,
+			expected: []string{
+				"CREATE TABLE a (id INT)",
+				"CREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND",
+				"SELECT 3",
+			},
+		},
+		{
+			name:    "sqlite trigger body",
+			dialect: "sqlite",
+			script: `CREATE TRIGGER t AFTER INSERT ON a
+BEGIN
+  UPDATE a SET x = 1;
+  UPDATE a SET y = 2;
+END;
+SELECT 1;`,
+			expected: []string{
+				"CREATE TRIGGER t AFTER INSERT ON a\nBEGIN\n  UPDATE a SET x = 1;\n  UPDATE a SET y = 2;\nEND",
+				"SELECT 1",
+			},
+		},
+		{
+			name:    "sqlite trigger with case expression",
+			dialect: "sqlite",
+			script: `CREATE TRIGGER t AFTER UPDATE ON a
+BEGIN
+  UPDATE a SET x = CASE WHEN NEW.y > 0 THEN 1 ELSE 0 END;
+  UPDATE a SET "end" = 'END;' -- END;
+  ;
+END;
+SELECT 1;`,
+			expected: []string{
+				"CREATE TRIGGER t AFTER UPDATE ON a\nBEGIN\n  UPDATE a SET x = CASE WHEN NEW.y > 0 THEN 1 ELSE 0 END;\n  UPDATE a SET \"end\" = 'END;' -- END;\n  ;\nEND",
+				"SELECT 1",
+			},
+		},
+		{
+			name:     "empty script",
+			dialect:  "mysql",
+			script:   "  \n-- only a comment\n",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitStatements(tt.script, tt.dialect)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("SplitStatements() =\n%#v\nwant\n%#v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// noTransactionDirective in the leading comments of an up or down file runs
+// the SQL migration outside a transaction
+const noTransactionDirective = "migro:no-transaction"
+
+// sqlFilePattern matches <timestamp>_<name>.up.sql and <timestamp>_<name>.down.sql
+var sqlFilePattern = regexp.MustCompile(`^(\d+_[A-Za-z0-9_]+)\.(up|down)\.sql$`)
+
+// sqlMigrationFiles holds the file paths of a SQL migration
+type sqlMigrationFiles struct {
+	up   string
+	down string
+}
+
+// LoadSQLMigrations discovers the plain SQL migrations in dir.
+// Each migration consists of a <timestamp>_<name>.up.sql file and an optional
+// <timestamp>_<name>.down.sql file. The statements are split according to
+// the given dialect and executed one by one through Executor.Raw.
+//
+// A "-- migro:no-transaction" comment before the first statement of either
+// file runs the migration outside a transaction.
+func LoadSQLMigrations(dir, dialect string) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	files := make(map[string]*sqlMigrationFiles)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := sqlFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		name := match[1]
+		if files[name] == nil {
+			files[name] = &sqlMigrationFiles{}
+		}
+		path := filepath.Join(dir, entry.Name())
+		if match[2] == "up" {
+			files[name].up = path
+		} else {
+			files[name].down = path
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		f := files[name]
+		if f.up == "" {
+			return nil, fmt.Errorf("SQL migration %s has a down file but no up file", name)
+		}
+		up, err := os.ReadFile(f.up)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.up, err)
+		}
+		var down []byte
+		if f.down != "" {
+			if down, err = os.ReadFile(f.down); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", f.down, err)
+			}
+		}
+		// The files are read once, the checksum covers the statements that run
+		migrations = append(migrations, Migration{
+			Name:               name,
+			Up:                 sqlFileFunc(f.up, string(up), dialect),
+			Down:               sqlDownFunc(name, f.down, string(down), dialect),
+			Checksum:           checksumOf(string(up)),
+			DisableTransaction: hasNoTransactionDirective(string(up)) || hasNoTransactionDirective(string(down)),
+		})
+	}
+
+	return migrations, nil
+}
+
+// sqlFileFunc returns a migration function that runs the statements of the
+// content read from a SQL file
+func sqlFileFunc(path, content, dialect string) func(context.Context, *Executor) error {
+	return func(ctx context.Context, e *Executor) error {
+		for _, stmt := range SplitStatements(content, dialect) {
+			if err := e.Raw(ctx, stmt); err != nil {
+				return fmt.Errorf("%s: %w", filepath.Base(path), err)
+			}
+		}
+		return nil
+	}
+}
+
+// sqlDownFunc returns the down function of a SQL migration
+func sqlDownFunc(name, path, content, dialect string) func(context.Context, *Executor) error {
+	if path == "" {
+		return func(ctx context.Context, e *Executor) error {
+			return fmt.Errorf("SQL migration %s has no down file", name)
+		}
+	}
+	return sqlFileFunc(path, content, dialect)
+}
+
+// hasNoTransactionDirective reports whether the comments before the first
+// statement of a SQL file contain the no-transaction directive
+func hasNoTransactionDirective(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "--") {
+			return false
+		}
+		if strings.TrimSpace(strings.TrimPrefix(line, "--")) == noTransactionDirective {
+			return true
+		}
+	}
+	return false
+}
+
+// loadSQLMigrations registers the SQL migrations found in the migrations path.
+// It runs once per Migrator.
+func (m *Migrator) loadSQLMigrations() error {
+	if m.sqlLoaded || m.migrationsPath == "" {
+		return nil
+	}
+
+	migrations, err := LoadSQLMigrations(m.migrationsPath, m.driver.Name())
+	if err != nil {
+		return err
+	}
+
+	registered := make(map[string]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		registered[migration.Name] = true
+	}
+	for _, migration := range migrations {
+		if registered[migration.Name] {
+			return fmt.Errorf("migration %s is defined both in Go and in SQL files", migration.Name)
+		}
+	}
+
+	m.migrations = append(m.migrations, migrations...)
+	m.sqlLoaded = true
+	return nil
+}
@@ -0,0 +1,187 @@
+package migrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 测试目标需求: 纯 SQL 迁移文件的发现与执行
+// 覆盖: LoadSQLMigrations, Migrator 自动加载 SQL 迁移
+
+// writeFiles 在目录中写入测试文件
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestLoadSQLMigrations(t *testing.T) {
+	t.Run("discovers up and down files", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"20260101000002_add_index.up.sql":      "CREATE INDEX i ON users (email);",
+			"20260101000001_create_users.up.sql":   "CREATE TABLE users (id INT);\nINSERT INTO users VALUES (1);",
+			"20260101000001_create_users.down.sql": "DROP TABLE users;",
+			"20260101000003_go_migration.go":       "package migrations",
+			"README.md":                            "ignored",
+		})
+
+		migrations, err := LoadSQLMigrations(dir, "mysql")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(migrations) != 2 {
+			t.Fatalf("expected 2 migrations, got %d", len(migrations))
+		}
+		if migrations[0].Name != "20260101000001_create_users" {
+			t.Errorf("unexpected first migration %q", migrations[0].Name)
+		}
+		if migrations[1].Name != "20260101000002_add_index" {
+			t.Errorf("unexpected second migration %q", migrations[1].Name)
+		}
+
+		e := NewExecutor(newMockDriver("mysql"), true)
+		if err := migrations[0].Up(context.Background(), e); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(e.GetSQL()) != 2 {
+			t.Errorf("expected 2 statements, got %d: %v", len(e.GetSQL()), e.GetSQL())
+		}
+
+		e = NewExecutor(newMockDriver("mysql"), true)
+		if err := migrations[0].Down(context.Background(), e); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(e.GetSQL()) != 1 || e.GetSQL()[0] != "DROP TABLE users" {
+			t.Errorf("unexpected down statements: %v", e.GetSQL())
+		}
+	})
+
+	t.Run("missing down file fails on rollback", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"20260101000001_create_users.up.sql": "CREATE TABLE users (id INT);",
+		})
+
+		migrations, err := LoadSQLMigrations(dir, "sqlite")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := migrations[0].Down(context.Background(), NewExecutor(newMockDriver("sqlite"), true)); err == nil {
+			t.Error("expected error for missing down file")
+		}
+	})
+
+	t.Run("down file without up file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"20260101000001_create_users.down.sql": "DROP TABLE users;",
+		})
+
+		if _, err := LoadSQLMigrations(dir, "sqlite"); err == nil {
+			t.Error("expected error for down file without up file")
+		}
+	})
+
+	t.Run("no-transaction directive", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"20260101000001_create_users.up.sql":      "CREATE TABLE users (id INT);",
+			"20260101000002_add_index.up.sql":         "-- migro:no-transaction\nCREATE INDEX CONCURRENTLY i ON users (id);",
+			"20260101000003_drop_index.up.sql":        "SELECT 1;",
+			"20260101000003_drop_index.down.sql":      "-- Rebuild without locking\n--  migro:no-transaction\n\nCREATE INDEX CONCURRENTLY i ON users (id);",
+			"20260101000004_directive_in_body.up.sql": "SELECT 1;\n-- migro:no-transaction",
+		})
+
+		migrations, err := LoadSQLMigrations(dir, "postgres")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []bool{false, true, true, false}
+		for i, migration := range migrations {
+			if migration.DisableTransaction != expected[i] {
+				t.Errorf("%s: expected DisableTransaction %v", migration.Name, expected[i])
+			}
+		}
+	})
+
+	t.Run("runs the content the checksum was computed from", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"20260101000001_create_users.up.sql": "CREATE TABLE users (id INT);",
+		})
+
+		migrations, err := LoadSQLMigrations(dir, "mysql")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// 加载之后修改文件，执行的仍是计算校验和时读取的内容
+		writeFiles(t, dir, map[string]string{
+			"20260101000001_create_users.up.sql": "DROP TABLE users;",
+		})
+
+		e := NewExecutor(newMockDriver("mysql"), true)
+		if err := migrations[0].Up(context.Background(), e); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(e.GetSQL()) != 1 || e.GetSQL()[0] != "CREATE TABLE users (id INT)" {
+			t.Errorf("unexpected up statements: %v", e.GetSQL())
+		}
+		if migrations[0].Checksum != checksumOf("CREATE TABLE users (id INT);") {
+			t.Errorf("unexpected checksum %q", migrations[0].Checksum)
+		}
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		migrations, err := LoadSQLMigrations(filepath.Join(t.TempDir(), "missing"), "mysql")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(migrations) != 0 {
+			t.Errorf("expected no migrations, got %d", len(migrations))
+		}
+	})
+}
+
+func TestMigrator_SQLMigrations(t *testing.T) {
+	t.Run("up runs SQL migrations with Go migrations", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"002_create_posts.up.sql": "CREATE TABLE posts (id INT);",
+		})
+
+		drv := newMockDriver("mysql")
+		m := NewMigrator(drv, dir, "migrations")
+		m.Register(Migration{
+			Name: "001_create_users",
+			Up:   func(ctx context.Context, e *Executor) error { return nil },
+		})
+
+		executed, err := m.Up(context.Background(), 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(executed) != 2 || executed[1] != "002_create_posts" {
+			t.Errorf("unexpected executed migrations: %v", executed)
+		}
+	})
+
+	t.Run("duplicate Go and SQL migration", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"001_create_users.up.sql": "CREATE TABLE users (id INT);",
+		})
+
+		m := NewMigrator(newMockDriver("mysql"), dir, "migrations")
+		m.Register(Migration{Name: "001_create_users"})
+
+		if _, err := m.Up(context.Background(), 0); err == nil {
+			t.Error("expected error for duplicate migration name")
+		}
+	})
+}
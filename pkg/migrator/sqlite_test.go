@@ -0,0 +1,136 @@
+//go:build cgo
+
+package migrator
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/flyits/migro/pkg/driver"
+	"github.com/flyits/migro/pkg/driver/sqlite"
+	"github.com/flyits/migro/pkg/schema"
+)
+
+// 测试目标：基于真实 SQLite 数据库的迁移流程
+// 覆盖: 迁移执行后校验和保持不变, 旧版本迁移表上的 Reset/Refresh
+
+// newSQLiteDriver 连接临时目录中的 SQLite 数据库
+func newSQLiteDriver(t *testing.T) *sqlite.Driver {
+	t.Helper()
+	drv := sqlite.NewDriver()
+	if err := drv.Connect(&driver.Config{Database: filepath.Join(t.TempDir(), "migro.db")}); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { drv.Close() })
+	return drv
+}
+
+// 迁移依赖当前表结构（SQLite 重建表、HasColumn 判断）时校验和仍保持不变
+func TestMigrator_ChecksumStableAfterRun(t *testing.T) {
+	ctx := context.Background()
+	m := NewMigrator(newSQLiteDriver(t), "", "migrations")
+	m.Register(Migration{
+		Name: "001_create_users",
+		Up: func(ctx context.Context, e *Executor) error {
+			return e.CreateTable(ctx, "users", func(t *schema.Table) {
+				t.ID()
+				t.String("name", 100)
+				t.String("legacy", 100).Nullable()
+			})
+		},
+		Down: func(ctx context.Context, e *Executor) error { return e.DropTable(ctx, "users") },
+	})
+	m.Register(Migration{
+		Name: "002_update_users",
+		Up: func(ctx context.Context, e *Executor) error {
+			// 删除列会重建表，编译出的 SQL 依赖当前表结构
+			if err := e.AlterTable(ctx, "users", func(t *schema.Table) {
+				t.DropColumn("legacy")
+			}); err != nil {
+				return err
+			}
+			has, err := e.HasColumn(ctx, "users", "email")
+			if err != nil || has {
+				return err
+			}
+			return e.AlterTable(ctx, "users", func(t *schema.Table) {
+				t.String("email", 100).Nullable()
+			})
+		},
+		Down: func(ctx context.Context, e *Executor) error { return nil },
+	})
+
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatalf("first Up failed: %v", err)
+	}
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatalf("second Up failed: %v", err)
+	}
+
+	mismatches, err := m.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("expected no modified migrations, got %+v", mismatches)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	for _, status := range statuses {
+		if !status.Ran || status.Modified {
+			t.Errorf("expected %s to be ran and unmodified, got %+v", status.Name, status)
+		}
+	}
+}
+
+// 旧版本迁移表（没有 checksum 和元数据列）上执行 Reset 和 Refresh
+func TestMigrator_ResetUpgradesLegacyTable(t *testing.T) {
+	ctx := context.Background()
+
+	for _, name := range []string{"Reset", "Refresh"} {
+		t.Run(name, func(t *testing.T) {
+			drv := newSQLiteDriver(t)
+			for _, stmt := range []string{
+				`CREATE TABLE migrations (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  migration TEXT NOT NULL,
+  batch INTEGER NOT NULL,
+  executed_at TEXT DEFAULT CURRENT_TIMESTAMP
+)`,
+				"CREATE TABLE users (id INTEGER PRIMARY KEY)",
+				"INSERT INTO migrations (migration, batch) VALUES ('001_create_users', 1)",
+			} {
+				if _, err := drv.Exec(ctx, stmt); err != nil {
+					t.Fatalf("failed to prepare legacy database: %v", err)
+				}
+			}
+
+			m := NewMigrator(drv, "", "migrations")
+			m.Register(Migration{
+				Name: "001_create_users",
+				Up: func(ctx context.Context, e *Executor) error {
+					return e.CreateTable(ctx, "users", func(t *schema.Table) { t.ID() })
+				},
+				Down: func(ctx context.Context, e *Executor) error { return e.DropTable(ctx, "users") },
+			})
+
+			var rolledBack []string
+			var err error
+			if name == "Reset" {
+				rolledBack, err = m.Reset(ctx)
+			} else {
+				rolledBack, _, err = m.Refresh(ctx)
+			}
+			if err != nil {
+				t.Fatalf("%s failed: %v", name, err)
+			}
+			if len(rolledBack) != 1 || rolledBack[0] != "001_create_users" {
+				t.Errorf("expected 001_create_users to be rolled back, got %v", rolledBack)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flyits/migro/pkg/driver"
+)
+
+// TransactionMode describes how a migration is wrapped in a transaction
+type TransactionMode int
+
+const (
+	// NoTransaction runs the migration outside a transaction, because the
+	// database has no transactional DDL or the migration disables it
+	NoTransaction TransactionMode = iota
+
+	// MigrationTransaction runs the migration in a transaction of its own
+	MigrationTransaction
+
+	// BatchTransaction runs the migration in the transaction of the whole batch
+	BatchTransaction
+)
+
+// SetBatchTransaction runs all the migrations of Up or Down in a single
+// transaction, so that either all of them are applied or none. It requires
+// a database with transactional DDL and fails when one of the migrations
+// disables transactions.
+func (m *Migrator) SetBatchTransaction(batch bool) {
+	m.batchTx = batch
+}
+
+// transactionMode returns how a migration runs
+func (m *Migrator) transactionMode(migration Migration) TransactionMode {
+	switch {
+	case m.batchTx:
+		return BatchTransaction
+	case migration.DisableTransaction || !m.supportsTransactionalDDL():
+		return NoTransaction
+	default:
+		return MigrationTransaction
+	}
+}
+
+// checkBatchTransaction returns an error when the migrations cannot run in
+// a single transaction
+func (m *Migrator) checkBatchTransaction(migrations []Migration) error {
+	if !m.supportsTransactionalDDL() {
+		return fmt.Errorf("driver %s does not support transactional DDL, migrations cannot run in a batch transaction", m.driver.Name())
+	}
+	for _, migration := range migrations {
+		if migration.DisableTransaction {
+			return fmt.Errorf("migration %s disables transactions and cannot run in a batch transaction", migration.Name)
+		}
+	}
+	return nil
+}
+
+// upInBatchTransaction runs the pending migrations in a single transaction.
+// Nothing is applied when one of them fails.
+func (m *Migrator) upInBatchTransaction(ctx context.Context, pending []Migration, batch int) ([]string, error) {
+	if err := m.checkBatchTransaction(pending); err != nil {
+		return nil, err
+	}
+
+	if m.dryRun {
+		var executedNames []string
+		for _, migration := range pending {
+			executor := NewExecutor(m.driver, true)
+			if err := migration.Up(ctx, executor); err != nil {
+				return executedNames, fmt.Errorf("migration %s failed: %w", migration.Name, err)
+			}
+			m.addDryRunResult(DryRunResult{Migration: migration.Name, Transaction: BatchTransaction, SQL: executor.GetSQL()})
+			executedNames = append(executedNames, migration.Name)
+		}
+		return executedNames, nil
+	}
+
+	var executedNames []string
+	err := m.inBatchTransaction(ctx, DirectionUp, batch, func(tx driver.Transaction) error {
+		for _, migration := range pending {
+			run := m.beginMigration(ctx, migration, DirectionUp, batch)
+			checksum, err := m.Checksum(ctx, migration)
+			if err == nil {
+				err = m.runInTransaction(ctx, tx, run, migration, batch, checksum, true, nil)
+			}
+			run.end(ctx, err)
+			if err != nil {
+				return err
+			}
+			executedNames = append(executedNames, migration.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return executedNames, nil
+}
+
+// downInBatchTransaction rolls back the migrations in a single transaction.
+// Nothing is rolled back when one of them fails.
+func (m *Migrator) downInBatchTransaction(ctx context.Context, migrations []Migration, batches map[string]int) ([]string, error) {
+	if err := m.checkBatchTransaction(migrations); err != nil {
+		return nil, err
+	}
+
+	var rolledBack []string
+	if m.dryRun {
+		for _, migration := range migrations {
+			executor := NewExecutor(m.driver, true)
+			if err := migration.Down(ctx, executor); err != nil {
+				return rolledBack, fmt.Errorf("rollback of %s failed: %w", migration.Name, err)
+			}
+			m.addDryRunResult(DryRunResult{Migration: migration.Name, Rollback: true, Transaction: BatchTransaction, SQL: executor.GetSQL()})
+			rolledBack = append(rolledBack, migration.Name)
+		}
+		return rolledBack, nil
+	}
+
+	err := m.inBatchTransaction(ctx, DirectionDown, 0, func(tx driver.Transaction) error {
+		for _, migration := range migrations {
+			run := m.beginMigration(ctx, migration, DirectionDown, batches[migration.Name])
+			err := m.runInTransaction(ctx, tx, run, migration, 0, "", false, nil)
+			run.end(ctx, err)
+			if err != nil {
+				return err
+			}
+			rolledBack = append(rolledBack, migration.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rolledBack, nil
+}
+
+// inBatchTransaction runs fn in a transaction that is committed when fn
+// succeeds and rolled back otherwise. Failures of the transaction itself are
+// reported to the hooks, those of the migrations are reported by fn.
+func (m *Migrator) inBatchTransaction(ctx context.Context, direction Direction, batch int, fn func(tx driver.Transaction) error) error {
+	tx, err := m.driver.Begin(ctx)
+	if err != nil {
+		err = fmt.Errorf("failed to begin batch transaction: %w", err)
+		m.batchError(ctx, direction, batch, err)
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback of the batch also failed: %v)", err, rbErr)
+		}
+		return fmt.Errorf("%w (batch rolled back)", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		err = fmt.Errorf("failed to commit batch transaction: %w", err)
+		m.batchError(ctx, direction, batch, err)
+		return err
+	}
+	return nil
+}
+
+// alterTypesBeforeTransaction runs the statements altering the column types
+// of a migration, such as the ALTER TYPE ... ADD VALUE of PostgreSQL enums, on
+// the connection before the transaction of the migration begins. PostgreSQL
+// cannot use an enum value in the transaction that added it, and before
+// version 12 cannot add one in a transaction at all. The statements are
+// collected by running the migration without reading the schema, as for
+// Checksum, and the transaction skips those returned.
+func (m *Migrator) alterTypesBeforeTransaction(ctx context.Context, run *migrationRun, migration Migration, isUp bool) (map[string]bool, error) {
+	if _, ok := m.driver.Grammar().(driver.TypeCompiler); !ok {
+		return nil, nil
+	}
+
+	executor := &Executor{driver: m.driver, dryRun: true, checksum: true}
+	fn, failure := migration.Up, "migration %s failed: %w"
+	if !isUp {
+		fn, failure = migration.Down, "rollback of %s failed: %w"
+	}
+	if err := fn(ctx, executor); err != nil {
+		return nil, fmt.Errorf(failure, migration.Name, err)
+	}
+
+	done := make(map[string]bool, len(executor.alterTypeSQLs))
+	for _, sql := range executor.alterTypeSQLs {
+		if done[sql] {
+			continue
+		}
+		err := run.statement(ctx, sql, func() error {
+			_, err := m.driver.Exec(ctx, sql)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf(failure, migration.Name, err)
+		}
+		done[sql] = true
+	}
+	return done, nil
+}
+
+// addDryRunResult collects the SQL of a migration in dry run mode and keeps
+// track of the migrations rolled back by the dry run
+func (m *Migrator) addDryRunResult(result DryRunResult) {
+	m.dryRunResults = append(m.dryRunResults, result)
+	if !result.Rollback {
+		delete(m.dryRunRolledBack, result.Migration)
+		return
+	}
+	if m.dryRunRolledBack == nil {
+		m.dryRunRolledBack = make(map[string]bool)
+	}
+	m.dryRunRolledBack[result.Migration] = true
+}
@@ -0,0 +1,173 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/flyits/migro/pkg/driver"
+)
+
+// 测试目标：验证迁移可以关闭事务，以及整个批次在单个事务中执行
+
+// mockBeginCountingDriver 记录开启事务的次数
+type mockBeginCountingDriver struct {
+	*mockDriver
+	begins int
+}
+
+func (d *mockBeginCountingDriver) Begin(ctx context.Context) (driver.Transaction, error) {
+	d.begins++
+	return d.mockDriver.Begin(ctx)
+}
+
+func TestMigrator_TransactionModes(t *testing.T) {
+	ctx := context.Background()
+	noop := func(ctx context.Context, e *Executor) error { return nil }
+
+	newMigrator := func(name string, migrations ...Migration) (*mockBeginCountingDriver, *Migrator) {
+		drv := &mockBeginCountingDriver{mockDriver: newMockDriver(name)}
+		m := NewMigrator(drv, "", "migrations")
+		m.RegisterAll(migrations)
+		return drv, m
+	}
+
+	t.Run("关闭事务的迁移不开启事务", func(t *testing.T) {
+		drv, m := newMigrator("postgres",
+			Migration{Name: "001_create_users", Up: noop},
+			Migration{Name: "002_add_index", Up: noop, DisableTransaction: true},
+		)
+		executed, err := m.Up(ctx, 0)
+		if err != nil {
+			t.Fatalf("Up failed: %v", err)
+		}
+		if len(executed) != 2 {
+			t.Fatalf("expected 2 executed migrations, got %v", executed)
+		}
+		if drv.begins != 1 {
+			t.Errorf("expected 1 transaction, got %d", drv.begins)
+		}
+		if len(drv.executedMigrations) != 1 || drv.executedMigrations[0].Migration != "002_add_index" {
+			t.Errorf("expected only 002_add_index to be recorded outside a transaction, got %v", drv.executedMigrations)
+		}
+	})
+
+	t.Run("批次事务只开启一次", func(t *testing.T) {
+		drv, m := newMigrator("sqlite",
+			Migration{Name: "001_create_users", Up: noop},
+			Migration{Name: "002_create_posts", Up: noop},
+		)
+		m.SetBatchTransaction(true)
+
+		executed, err := m.Up(ctx, 0)
+		if err != nil {
+			t.Fatalf("Up failed: %v", err)
+		}
+		if len(executed) != 2 {
+			t.Fatalf("expected 2 executed migrations, got %v", executed)
+		}
+		if drv.begins != 1 || !drv.tx.committed {
+			t.Errorf("expected a single committed transaction, got %d", drv.begins)
+		}
+	})
+
+	t.Run("批次中的失败回滚所有迁移", func(t *testing.T) {
+		failure := errors.New("boom")
+		drv, m := newMigrator("postgres",
+			Migration{Name: "001_create_users", Up: noop},
+			Migration{Name: "002_create_posts", Up: func(ctx context.Context, e *Executor) error { return failure }},
+		)
+		m.SetBatchTransaction(true)
+
+		executed, err := m.Up(ctx, 0)
+		if !errors.Is(err, failure) {
+			t.Fatalf("expected the migration error, got %v", err)
+		}
+		if len(executed) != 0 {
+			t.Errorf("expected nothing to be reported as executed, got %v", executed)
+		}
+		if !drv.tx.rolledBack || drv.tx.committed {
+			t.Error("expected the batch transaction to be rolled back")
+		}
+	})
+
+	t.Run("批次回滚", func(t *testing.T) {
+		drv, m := newMigrator("postgres",
+			Migration{Name: "001_create_users", Up: noop, Down: noop},
+			Migration{Name: "002_create_posts", Up: noop, Down: noop},
+		)
+		drv.executedMigrations = []driver.MigrationRecord{
+			{Migration: "001_create_users", Batch: 1},
+			{Migration: "002_create_posts", Batch: 1},
+		}
+		drv.lastBatch = 1
+		m.SetBatchTransaction(true)
+
+		rolledBack, err := m.Down(ctx, 0)
+		if err != nil {
+			t.Fatalf("Down failed: %v", err)
+		}
+		if len(rolledBack) != 2 || rolledBack[0] != "002_create_posts" {
+			t.Errorf("unexpected rolled back migrations %v", rolledBack)
+		}
+		if drv.begins != 1 || !drv.tx.committed {
+			t.Errorf("expected a single committed transaction, got %d", drv.begins)
+		}
+	})
+
+	t.Run("不支持事务 DDL 的驱动拒绝批次事务", func(t *testing.T) {
+		_, m := newMigrator("mysql", Migration{Name: "001_create_users", Up: noop})
+		m.SetBatchTransaction(true)
+		if _, err := m.Up(ctx, 0); err == nil {
+			t.Error("expected an error for a driver without transactional DDL")
+		}
+	})
+
+	t.Run("关闭事务的迁移不能在批次事务中执行", func(t *testing.T) {
+		drv, m := newMigrator("postgres",
+			Migration{Name: "001_create_users", Up: noop},
+			Migration{Name: "002_add_index", Up: noop, DisableTransaction: true},
+		)
+		m.SetBatchTransaction(true)
+		if _, err := m.Up(ctx, 0); err == nil {
+			t.Error("expected an error for a migration that disables transactions")
+		}
+		if drv.begins != 0 {
+			t.Error("expected no transaction to be started")
+		}
+	})
+
+	t.Run("dry run 记录事务模式", func(t *testing.T) {
+		tests := []struct {
+			driverName string
+			batch      bool
+			expected   []TransactionMode
+		}{
+			{"postgres", false, []TransactionMode{MigrationTransaction, NoTransaction}},
+			{"mysql", false, []TransactionMode{NoTransaction, NoTransaction}},
+			{"sqlite", true, []TransactionMode{BatchTransaction, BatchTransaction}},
+		}
+		for _, tt := range tests {
+			migrations := []Migration{{Name: "001_create_users", Up: noop}, {Name: "002_add_index", Up: noop}}
+			if !tt.batch {
+				migrations[1].DisableTransaction = true
+			}
+			_, m := newMigrator(tt.driverName, migrations...)
+			m.SetDryRun(true)
+			m.SetBatchTransaction(tt.batch)
+
+			if _, err := m.Up(ctx, 0); err != nil {
+				t.Fatalf("%s: Up failed: %v", tt.driverName, err)
+			}
+			results := m.DryRunResults()
+			if len(results) != len(tt.expected) {
+				t.Fatalf("%s: expected %d results, got %d", tt.driverName, len(tt.expected), len(results))
+			}
+			for i, mode := range tt.expected {
+				if results[i].Transaction != mode {
+					t.Errorf("%s: result %d: expected mode %d, got %d", tt.driverName, i, mode, results[i].Transaction)
+				}
+			}
+		}
+	})
+}
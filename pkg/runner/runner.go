@@ -0,0 +1,28 @@
+// Package runner provides the entry point of the migration runner binaries
+// that the migro CLI compiles from a project's migrations directory.
+//
+// A runner links the project's migrations package, whose init() functions
+// register every migration with pkg/migration, and then runs the regular
+// migro command line:
+//
+//	package main
+//
+//	import (
+//	    _ "example.com/app/migrations"
+//
+//	    "github.com/flyits/migro/pkg/runner"
+//	)
+//
+//	func main() {
+//	    runner.Main()
+//	}
+package runner
+
+import (
+	"github.com/flyits/migro/internal/cli"
+)
+
+// Main runs the migro command line with the migrations linked into the binary
+func Main() {
+	cli.Execute()
+}
@@ -21,6 +21,7 @@ type Blueprint interface {
 	JSON(name string) *Column
 	Binary(name string) *Column
 	UUID(name string) *Column
+	Enum(name string, values ...string) *Column
 	Timestamps()
 	SoftDeletes()
 
@@ -32,10 +33,14 @@ type Blueprint interface {
 	// Foreign key operations
 	Foreign(column string) *ForeignKey
 
+	// Check constraint operations
+	Check(name, expression string) *Check
+
 	// ALTER TABLE operations
 	DropColumn(name string)
 	DropIndex(name string)
 	DropForeign(name string)
+	DropCheck(name string)
 	RenameColumn(from, to string)
 
 	// Column modification operations
@@ -57,6 +62,7 @@ type Blueprint interface {
 	ChangeJSON(name string) *Column
 	ChangeBinary(name string) *Column
 	ChangeUUID(name string) *Column
+	ChangeEnum(name string, values ...string) *Column
 }
 
 // Ensure Table implements Blueprint
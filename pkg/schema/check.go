@@ -0,0 +1,7 @@
+package schema
+
+// Check represents a CHECK constraint
+type Check struct {
+	Name       string
+	Expression string // SQL boolean expression, e.g. price >= 0
+}
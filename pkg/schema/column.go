@@ -21,6 +21,7 @@ const (
 	TypeJSON
 	TypeBinary
 	TypeUUID
+	TypeEnum
 )
 
 // Column represents a database column definition
@@ -30,6 +31,7 @@ type Column struct {
 	Length          int
 	Precision       int
 	Scale           int
+	Values          []string // allowed values of enum columns
 	IsNullable      bool
 	DefaultValue    interface{}
 	IsAutoIncrement bool
@@ -37,10 +39,18 @@ type Column struct {
 	IsPrimary       bool
 	IsUnique        bool
 	ColumnComment   string
+	GeneratedAs     string // expression of a generated column
+	IsStored        bool   // the generated column is stored instead of computed when read
 	After           string // for MySQL ALTER TABLE
 	Change          bool   // indicates column modification
+	DatabaseType    string // type reported by the database, set when the column is inspected
+	PreviousName    string // name the column is renamed from, a hint for schema diffs
 }
 
+// Expression is a raw SQL expression. Used as a default value it is written
+// to the statement as is instead of being quoted as a string literal.
+type Expression string
+
 // Nullable sets the column as nullable
 func (c *Column) Nullable() *Column {
 	c.IsNullable = true
@@ -83,6 +93,30 @@ func (c *Column) Comment(text string) *Column {
 	return c
 }
 
+// StoredAs makes the column a generated column whose value is computed from
+// expression when a row is written and stored with the row
+func (c *Column) StoredAs(expression string) *Column {
+	c.GeneratedAs = expression
+	c.IsStored = true
+	return c
+}
+
+// VirtualAs makes the column a generated column whose value is computed from
+// expression when it is read. PostgreSQL only supports stored columns.
+func (c *Column) VirtualAs(expression string) *Column {
+	c.GeneratedAs = expression
+	c.IsStored = false
+	return c
+}
+
+// RenamedFrom marks the column as renamed from another column. Schema diffs
+// only rename columns that carry this hint; otherwise the old column is
+// dropped and the new one added.
+func (c *Column) RenamedFrom(name string) *Column {
+	c.PreviousName = name
+	return c
+}
+
 // PlaceAfter places this column after another column (MySQL only)
 func (c *Column) PlaceAfter(column string) *Column {
 	c.After = column
@@ -131,6 +131,20 @@ func TestColumn_PlaceAfter(t *testing.T) {
 	})
 }
 
+func TestColumn_RenamedFrom(t *testing.T) {
+	t.Run("sets PreviousName", func(t *testing.T) {
+		col := &Column{Name: "email_address", Type: TypeString}
+		result := col.RenamedFrom("email")
+
+		if col.PreviousName != "email" {
+			t.Errorf("expected PreviousName to be 'email', got '%s'", col.PreviousName)
+		}
+		if result != col {
+			t.Error("expected RenamedFrom() to return the same column for chaining")
+		}
+	})
+}
+
 // 测试链式调用组合
 func TestColumn_ChainedModifiers(t *testing.T) {
 	t.Run("multiple modifiers can be chained", func(t *testing.T) {
@@ -184,3 +198,26 @@ func TestColumnType_Values(t *testing.T) {
 		})
 	}
 }
+
+func TestColumn_Generated(t *testing.T) {
+	t.Run("StoredAs sets a stored expression", func(t *testing.T) {
+		col := &Column{Name: "full_name", Type: TypeString}
+		result := col.StoredAs("first_name || ' ' || last_name")
+
+		if col.GeneratedAs != "first_name || ' ' || last_name" || !col.IsStored {
+			t.Errorf("unexpected generated column %+v", col)
+		}
+		if result != col {
+			t.Error("expected StoredAs() to return the same column for chaining")
+		}
+	})
+
+	t.Run("VirtualAs sets a virtual expression", func(t *testing.T) {
+		col := &Column{Name: "total", Type: TypeDecimal}
+		col.StoredAs("price * quantity").VirtualAs("price * quantity")
+
+		if col.GeneratedAs != "price * quantity" || col.IsStored {
+			t.Errorf("unexpected generated column %+v", col)
+		}
+	})
+}
@@ -10,11 +10,28 @@ const (
 	IndexTypeFulltext
 )
 
+// Index sort orders
+const (
+	OrderAsc  = "ASC"
+	OrderDesc = "DESC"
+)
+
 // Index represents a database index definition
 type Index struct {
-	Name    string
-	Type    IndexType
-	Columns []string
+	Name      string
+	Type      IndexType
+	Columns   []string    // names of the indexed columns
+	Parts     []IndexPart // columns and expressions in index order, set when the index has expressions or column options
+	Predicate string      // WHERE clause of a partial index
+	Method    string      // index method, e.g. btree, hash, gin or brin
+}
+
+// IndexPart is a column or an expression of an index
+type IndexPart struct {
+	Column     string // empty for expressions
+	Expression string // SQL expression, e.g. lower(email)
+	Order      string // OrderAsc, OrderDesc or empty for the default order
+	Length     int    // prefix length of the column, 0 for the whole column
 }
 
 // NewIndex creates a new index with the given columns
@@ -48,3 +65,77 @@ func (i *Index) Fulltext() *Index {
 	i.Type = IndexTypeFulltext
 	return i
 }
+
+// Where makes this a partial index of the rows matching predicate,
+// e.g. Where("deleted_at IS NULL")
+func (i *Index) Where(predicate string) *Index {
+	i.Predicate = predicate
+	return i
+}
+
+// Using sets the index method, e.g. "gin", "brin", "hash" or "btree"
+func (i *Index) Using(method string) *Index {
+	i.Method = method
+	return i
+}
+
+// Expression adds an expression to the index, e.g. Expression("lower(email)").
+// Indexes with expressions must be named.
+func (i *Index) Expression(expression string) *Index {
+	i.Parts = append(i.Keys(), IndexPart{Expression: expression})
+	return i
+}
+
+// Asc sorts a column of the index in ascending order
+func (i *Index) Asc(column string) *Index {
+	i.part(column).Order = OrderAsc
+	return i
+}
+
+// Desc sorts a column of the index in descending order
+func (i *Index) Desc(column string) *Index {
+	i.part(column).Order = OrderDesc
+	return i
+}
+
+// Length indexes the first length characters of a column (MySQL only)
+func (i *Index) Length(column string, length int) *Index {
+	i.part(column).Length = length
+	return i
+}
+
+// HasExpressions reports whether the index has expressions
+func (i *Index) HasExpressions() bool {
+	for _, part := range i.Parts {
+		if part.Expression != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Keys returns the columns and expressions of the index in order
+func (i *Index) Keys() []IndexPart {
+	if len(i.Parts) > 0 {
+		return i.Parts
+	}
+	parts := make([]IndexPart, len(i.Columns))
+	for n, col := range i.Columns {
+		parts[n] = IndexPart{Column: col}
+	}
+	return parts
+}
+
+// part returns the part of a column, adding the column when the index does
+// not include it yet
+func (i *Index) part(column string) *IndexPart {
+	i.Parts = i.Keys()
+	for n := range i.Parts {
+		if i.Parts[n].Column == column {
+			return &i.Parts[n]
+		}
+	}
+	i.Columns = append(i.Columns, column)
+	i.Parts = append(i.Parts, IndexPart{Column: column})
+	return &i.Parts[len(i.Parts)-1]
+}
@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -121,3 +122,57 @@ func TestIndex_ChainedMethods(t *testing.T) {
 		}
 	})
 }
+
+func TestIndex_Keys(t *testing.T) {
+	t.Run("derived from columns", func(t *testing.T) {
+		idx := NewIndex("a", "b")
+		keys := idx.Keys()
+		if len(keys) != 2 || keys[0].Column != "a" || keys[1].Column != "b" {
+			t.Errorf("unexpected keys %+v", keys)
+		}
+		if len(idx.Parts) != 0 {
+			t.Errorf("expected Keys() to leave Parts empty, got %+v", idx.Parts)
+		}
+	})
+
+	t.Run("column options and expressions keep their order", func(t *testing.T) {
+		idx := NewIndex("tenant_id", "created_at").
+			Desc("created_at").
+			Length("tenant_id", 8).
+			Expression("lower(email)").
+			Asc("name")
+
+		expected := []IndexPart{
+			{Column: "tenant_id", Length: 8},
+			{Column: "created_at", Order: OrderDesc},
+			{Expression: "lower(email)"},
+			{Column: "name", Order: OrderAsc},
+		}
+		keys := idx.Keys()
+		if len(keys) != len(expected) {
+			t.Fatalf("expected %d keys, got %+v", len(expected), keys)
+		}
+		for i := range expected {
+			if keys[i] != expected[i] {
+				t.Errorf("key %d: expected %+v, got %+v", i, expected[i], keys[i])
+			}
+		}
+		// 表达式不计入 Columns，新增的列会加入 Columns
+		if strings.Join(idx.Columns, ",") != "tenant_id,created_at,name" {
+			t.Errorf("unexpected columns %v", idx.Columns)
+		}
+		if !idx.HasExpressions() {
+			t.Error("expected HasExpressions() to be true")
+		}
+	})
+
+	t.Run("Where and Using", func(t *testing.T) {
+		idx := NewIndex("email").Unique().Where("deleted_at IS NULL").Using("btree")
+		if idx.Predicate != "deleted_at IS NULL" || idx.Method != "btree" {
+			t.Errorf("unexpected index %+v", idx)
+		}
+		if idx.HasExpressions() {
+			t.Error("expected HasExpressions() to be false")
+		}
+	})
+}
@@ -1,11 +1,14 @@
 package schema
 
+import "strings"
+
 // Table represents a database table definition with fluent API
 type Table struct {
 	Name           string
 	Columns        []*Column
 	Indexes        []*Index
 	ForeignKeys    []*ForeignKey
+	Checks         []*Check
 	PrimaryKey     []string
 	Engine         string // MySQL specific
 	Charset        string // MySQL specific
@@ -15,6 +18,7 @@ type Table struct {
 	DropColumns    []string      // columns to drop in ALTER TABLE
 	DropIndexes    []string      // indexes to drop in ALTER TABLE
 	DropForeignKeys []string     // foreign keys to drop in ALTER TABLE
+	DropChecks     []string      // check constraints to drop in ALTER TABLE
 	RenameColumns  map[string]string // old name -> new name
 }
 
@@ -138,6 +142,14 @@ func (t *Table) UUID(name string) *Column {
 	return t.addColumn(name, TypeUUID)
 }
 
+// Enum adds a column restricted to the given values (ENUM for MySQL, an enum
+// type for PostgreSQL, TEXT with a CHECK constraint for SQLite)
+func (t *Table) Enum(name string, values ...string) *Column {
+	col := t.addColumn(name, TypeEnum)
+	col.Values = values
+	return col
+}
+
 // Timestamps adds created_at and updated_at timestamp columns
 func (t *Table) Timestamps() {
 	t.Timestamp("created_at").Nullable()
@@ -178,6 +190,13 @@ func (t *Table) Foreign(column string) *ForeignKey {
 	return fk
 }
 
+// Check adds a CHECK constraint, e.g. Check("products_price_positive", "price >= 0")
+func (t *Table) Check(name, expression string) *Check {
+	check := &Check{Name: name, Expression: expression}
+	t.Checks = append(t.Checks, check)
+	return check
+}
+
 // DropColumn marks a column for deletion (ALTER TABLE)
 func (t *Table) DropColumn(name string) {
 	t.DropColumns = append(t.DropColumns, name)
@@ -193,6 +212,11 @@ func (t *Table) DropForeign(name string) {
 	t.DropForeignKeys = append(t.DropForeignKeys, name)
 }
 
+// DropCheck marks a check constraint for deletion (ALTER TABLE)
+func (t *Table) DropCheck(name string) {
+	t.DropChecks = append(t.DropChecks, name)
+}
+
 // RenameColumn renames a column (ALTER TABLE)
 func (t *Table) RenameColumn(from, to string) {
 	t.RenameColumns[from] = to
@@ -300,6 +324,13 @@ func (t *Table) ChangeUUID(name string) *Column {
 	return t.ChangeColumn(name, TypeUUID)
 }
 
+// ChangeEnum modifies a column to an enum of the given values
+func (t *Table) ChangeEnum(name string, values ...string) *Column {
+	col := t.ChangeColumn(name, TypeEnum)
+	col.Values = values
+	return col
+}
+
 // SetEngine sets the storage engine (MySQL only)
 func (t *Table) SetEngine(engine string) *Table {
 	t.Engine = engine
@@ -317,3 +348,22 @@ func (t *Table) SetCollation(collation string) *Table {
 	t.Collation = collation
 	return t
 }
+
+// SplitTableName splits a schema-qualified table name such as
+// "billing.invoices" into the schema and the table. The schema is empty for
+// unqualified names.
+func SplitTableName(name string) (schemaName, table string) {
+	if schemaName, table, ok := strings.Cut(name, "."); ok {
+		return schemaName, table
+	}
+	return "", name
+}
+
+// JoinTableName returns the name of a table in a schema, or the table when
+// the schema is empty
+func JoinTableName(schemaName, table string) string {
+	if schemaName == "" {
+		return table
+	}
+	return schemaName + "." + table
+}
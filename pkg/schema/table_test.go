@@ -376,6 +376,29 @@ func TestTable_DropForeign(t *testing.T) {
 	})
 }
 
+func TestTable_Check(t *testing.T) {
+	t.Run("adds check constraint", func(t *testing.T) {
+		table := NewTable("products")
+		check := table.Check("products_price_positive", "price >= 0")
+
+		if len(table.Checks) != 1 || table.Checks[0] != check {
+			t.Fatalf("expected 1 check, got %v", table.Checks)
+		}
+		if check.Name != "products_price_positive" || check.Expression != "price >= 0" {
+			t.Errorf("unexpected check %+v", check)
+		}
+	})
+
+	t.Run("marks check for deletion", func(t *testing.T) {
+		table := NewTable("products")
+		table.DropCheck("products_price_positive")
+
+		if len(table.DropChecks) != 1 || table.DropChecks[0] != "products_price_positive" {
+			t.Errorf("expected 'products_price_positive' to drop, got %v", table.DropChecks)
+		}
+	})
+}
+
 func TestTable_RenameColumn(t *testing.T) {
 	t.Run("records column rename", func(t *testing.T) {
 		table := NewTable("users")
@@ -466,3 +489,18 @@ func TestTable_CompleteUserTableDefinition(t *testing.T) {
 		}
 	})
 }
+
+func TestSplitTableName(t *testing.T) {
+	tests := []struct {
+		name, schema, table string
+	}{
+		{"users", "", "users"},
+		{"billing.invoices", "billing", "invoices"},
+	}
+	for _, tt := range tests {
+		schemaName, table := SplitTableName(tt.name)
+		if schemaName != tt.schema || table != tt.table {
+			t.Errorf("SplitTableName(%q) = %q, %q, want %q, %q", tt.name, schemaName, table, tt.schema, tt.table)
+		}
+	}
+}
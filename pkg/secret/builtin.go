@@ -0,0 +1,65 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// CommandTimeout is how long the cmd resolver waits for a command
+var CommandTimeout = 30 * time.Second
+
+func init() {
+	Register("file", ResolverFunc(resolveFile))
+	Register("cmd", ResolverFunc(resolveCommand))
+	Register("env", ResolverFunc(resolveEnv))
+}
+
+// resolveFile returns the content of a file without the trailing newline,
+// e.g. a Docker or Kubernetes secret
+func resolveFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveCommand runs a shell command, e.g. a credential helper, and returns
+// its output without the trailing newline
+func resolveCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), CommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// resolveEnv returns an environment variable that must be set
+func resolveEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
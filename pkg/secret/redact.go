@@ -0,0 +1,91 @@
+package secret
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// shortSecretLength is the length under which secrets are only hidden as whole
+// words, hiding every occurrence of a few characters would garble the messages
+const shortSecretLength = 4
+
+// Mask replaces the secrets hidden by Redact
+const Mask = "****"
+
+var (
+	secretsMu sync.RWMutex
+	secrets   = make(map[string]bool)
+)
+
+// Remember registers a secret, such as a password, to be hidden by Redact.
+// Its URL-encoded form is hidden as well.
+func Remember(value string) {
+	if value == "" {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets[value] = true
+	for _, encoded := range []string{url.QueryEscape(value), url.PathEscape(value)} {
+		secrets[encoded] = true
+	}
+	// url.UserPassword escapes differently from both functions above
+	secrets[strings.TrimPrefix(url.UserPassword("", value).String(), ":")] = true
+}
+
+// Redact replaces the remembered secrets in s with Mask
+func Redact(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	if len(secrets) == 0 {
+		return s
+	}
+
+	// Longer secrets first, so that a secret containing another is hidden whole
+	values := make([]string, 0, len(secrets))
+	for value := range secrets {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	for _, value := range values {
+		if len(value) < shortSecretLength {
+			s = replaceWord(s, value)
+		} else {
+			s = strings.ReplaceAll(s, value, Mask)
+		}
+	}
+	return s
+}
+
+// replaceWord replaces the occurrences of value in s that are not part of a
+// longer word, such as the password of "mysql://app:pw1@db" but not the "pw1"
+// of "pw10"
+func replaceWord(s, value string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, value)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		end := i + len(value)
+		before, _ := utf8.DecodeLastRuneInString(s[:i])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		b.WriteString(s[:i])
+		if isWordRune(before) || isWordRune(after) {
+			b.WriteString(value)
+		} else {
+			b.WriteString(Mask)
+		}
+		s = s[end:]
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,93 @@
+// Package secret resolves the secret references of the migro configuration
+// file and keeps secrets out of the messages migro prints.
+//
+// A reference ${name:ref} is resolved by the resolver registered under name:
+//
+//	password: ${file:/run/secrets/db_pass}
+//	password: ${cmd:vault kv get -field=password secret/db}
+//	password: ${env:DB_PASS}
+//
+// Other references such as ${DB_PASS:default} read environment variables.
+// Defaults only apply to upper case names: ${name:ref} with an unknown
+// lower case name fails unless the environment variable name is set.
+// Projects register their own resolvers from the init() function of a
+// package linked into the migration runner, e.g. the migrations package:
+//
+//	func init() {
+//	    secret.Register("vault", secret.ResolverFunc(func(ref string) (string, error) {
+//	        return vaultClient.Read(ref)
+//	    }))
+//	}
+package secret
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Resolver returns the secret identified by a reference
+type Resolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// ResolverFunc adapts a function to the Resolver interface
+type ResolverFunc func(ref string) (string, error)
+
+// Resolve calls f(ref)
+func (f ResolverFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+var (
+	resolversMu sync.RWMutex
+	resolvers   = make(map[string]Resolver)
+)
+
+// Register registers a resolver for the references ${name:ref}
+func Register(name string, resolver Resolver) {
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	if resolver == nil {
+		panic("secret: Register resolver is nil")
+	}
+	if _, dup := resolvers[name]; dup {
+		panic("secret: Register called twice for resolver " + name)
+	}
+	resolvers[name] = resolver
+}
+
+// Lookup returns the resolver registered with the given name
+func Lookup(name string) (Resolver, bool) {
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+	resolver, ok := resolvers[name]
+	return resolver, ok
+}
+
+// Resolvers returns the sorted names of the registered resolvers
+func Resolvers() []string {
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+	names := make([]string, 0, len(resolvers))
+	for name := range resolvers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve resolves ref with the resolver registered with the given name and
+// remembers the secret so that Redact hides it
+func Resolve(name, ref string) (string, error) {
+	resolver, ok := Lookup(name)
+	if !ok {
+		return "", fmt.Errorf("secret: unknown resolver %q", name)
+	}
+	value, err := resolver.Resolve(ref)
+	if err != nil {
+		return "", fmt.Errorf("secret: failed to resolve ${%s:...}: %w", name, err)
+	}
+	Remember(value)
+	return value, nil
+}